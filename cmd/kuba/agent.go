@@ -0,0 +1,146 @@
+package kuba
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/lib/agent"
+	"github.com/mistweaverco/kuba/internal/lib/cache"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentIdleTimeout string
+	agentCacheTTL    string
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run the kuba secrets agent",
+	Long: `Run the kuba secrets agent in the foreground.
+
+The agent is a per-user daemon that keeps authenticated provider clients and an
+in-memory cache of secrets. It listens on a Unix socket that is only accessible
+by the current user and refuses connections from other users.
+
+While the agent is running, 'kuba run', 'kuba show' and 'kuba tui' fetch secrets
+through it instead of authenticating with every provider on each invocation.
+Provider credentials (e.g. AWS_PROFILE, AZURE_KEY_VAULT_URL) are taken from the
+environment the agent was started in.
+
+Examples:
+  kuba agent &
+  kuba agent --idle-timeout 1h --cache-ttl 10m
+  kuba agent status
+  kuba agent stop`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAgent()
+	},
+}
+
+var agentStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the status of the kuba agent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAgentStatus()
+	},
+}
+
+var agentStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the kuba agent",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAgentStop()
+	},
+}
+
+func init() {
+	agentCmd.Flags().StringVar(&agentIdleTimeout, "idle-timeout", "30m", "Stop the agent after this long without requests (e.g., 30m, 1h, false)")
+	agentCmd.Flags().StringVar(&agentCacheTTL, "cache-ttl", "5m", "Keep fetched secrets in memory for this long (e.g., 5m, 1h, false)")
+	agentCmd.AddCommand(agentStatusCmd)
+	agentCmd.AddCommand(agentStopCmd)
+	rootCmd.AddCommand(agentCmd)
+}
+
+func runAgent() error {
+	idleTimeout, _, err := cache.ParseDuration(agentIdleTimeout)
+	if err != nil {
+		return fmt.Errorf("invalid idle timeout: %w", err)
+	}
+	cacheTTL, _, err := cache.ParseDuration(agentCacheTTL)
+	if err != nil {
+		return fmt.Errorf("invalid cache TTL: %w", err)
+	}
+
+	// The agent itself must talk to the providers directly
+	factory := secrets.NewSecretManagerFactory()
+	factory.DisableAgent = true
+	newManager := func(ctx context.Context, provider, project string) (agent.SecretReader, error) {
+		return factory.CreateSecretManager(ctx, provider, project)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	socketPath := agent.SocketPath()
+	server := agent.NewServer(socketPath, newManager, idleTimeout, cacheTTL)
+	fmt.Fprintf(os.Stderr, "kuba agent listening on %s\n", socketPath)
+	return server.Serve(ctx)
+}
+
+func runAgentStatus() error {
+	client, err := agent.Dial()
+	if err != nil {
+		fmt.Println("Agent is not running.")
+		return nil
+	}
+
+	status, err := client.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get agent status: %w", err)
+	}
+
+	fmt.Println("Agent is running.")
+	fmt.Printf("PID: %d\n", status.PID)
+	fmt.Printf("Socket: %s\n", status.SocketPath)
+	fmt.Printf("Started: %s\n", status.StartedAt.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Last Activity: %s\n", status.LastActivity.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Idle Timeout: %s\n", status.IdleTimeout)
+	fmt.Printf("Cache TTL: %s\n", status.CacheTTL)
+	fmt.Printf("Cached Entries: %d\n", status.CachedEntries)
+	if len(status.Managers) > 0 {
+		fmt.Printf("Providers: %s\n", strings.Join(status.Managers, ", "))
+	}
+	return nil
+}
+
+func runAgentStop() error {
+	client, err := agent.Dial()
+	if err != nil {
+		fmt.Println("Agent is not running.")
+		return nil
+	}
+
+	if err := client.Stop(); err != nil {
+		return fmt.Errorf("failed to stop agent: %w", err)
+	}
+
+	// Wait for the socket to go away so a new agent can be started right after
+	for i := 0; i < 20; i++ {
+		if _, err := agent.Dial(); err != nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	fmt.Println("Agent stopped.")
+	return nil
+}
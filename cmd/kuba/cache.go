@@ -29,8 +29,10 @@ This command allows you to:
 - Show cache statistics
 - Configure cache settings
 
-The cache is stored in ~/.cache/kuba/db.sqlite and helps reduce API calls
-to cloud providers by storing secrets temporarily.`,
+The cache helps reduce API calls to cloud providers by storing secrets
+temporarily. Depending on the configured backend it is stored in
+~/.cache/kuba/db.sqlite (sqlite), ~/.cache/kuba/secrets.enc (file) or only in
+memory (memory).`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runCacheCommand()
@@ -101,7 +103,10 @@ var configCacheCmd = &cobra.Command{
 Examples:
   kuba config cache --enable --ttl 1d
   kuba config cache --disable
-  kuba config cache --ttl 2w`,
+  kuba config cache --ttl 2w
+  kuba config cache --stale-if-error 7d
+  kuba config cache --stale-while-revalidate 1h
+  kuba config cache --backend file`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runCacheConfigWithCmd(cmd)
@@ -136,6 +141,9 @@ func init() {
 	configCacheCmd.Flags().Bool("enable", false, "Enable caching")
 	configCacheCmd.Flags().Bool("disable", false, "Disable caching")
 	configCacheCmd.Flags().String("ttl", "", "Set cache TTL (e.g., 1d, 2w, 72h, 2y)")
+	configCacheCmd.Flags().String("stale-if-error", "", "Serve expired secrets for this long when providers are unreachable (e.g., 7d, false)")
+	configCacheCmd.Flags().String("stale-while-revalidate", "", "Serve expired secrets for this long while refreshing them in the background (e.g., 1h, false)")
+	configCacheCmd.Flags().String("backend", "", "Set cache backend (sqlite, file, memory)")
 	configCacheCmd.Flags().Bool("show", false, "Show current configuration")
 }
 
@@ -154,10 +162,7 @@ func runCacheList() error {
 
 	// Convert to cache types
 	cacheGlobalConfig := &cache.GlobalConfig{
-		Cache: cache.CacheConfig{
-			Enabled: globalConfig.Cache.Enabled,
-			TTL:     globalConfig.Cache.TTL,
-		},
+		Cache: globalConfig.Cache,
 	}
 
 	// Initialize cache manager
@@ -236,8 +241,14 @@ func runCacheClear(cmd *cobra.Command) error {
 	all, _ := cmd.Flags().GetBool("all")
 	expired, _ := cmd.Flags().GetBool("expired")
 
+	// Load global config to select the backend and retain stale entries
+	globalConfig, err := config.LoadGlobalConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load global config: %w", err)
+	}
+
 	// Initialize cache
-	cacheInstance, err := cache.NewCache()
+	cacheInstance, err := cache.NewBackend(globalConfig.Cache)
 	if err != nil {
 		return fmt.Errorf("failed to initialize cache: %w", err)
 	}
@@ -303,10 +314,7 @@ func runCacheStats() error {
 
 	// Convert to cache types
 	cacheGlobalConfig := &cache.GlobalConfig{
-		Cache: cache.CacheConfig{
-			Enabled: globalConfig.Cache.Enabled,
-			TTL:     globalConfig.Cache.TTL,
-		},
+		Cache: globalConfig.Cache,
 	}
 
 	// Initialize cache manager
@@ -326,8 +334,11 @@ func runCacheStats() error {
 	fmt.Printf("Enabled: %v\n", stats["enabled"])
 
 	if enabled, ok := stats["enabled"].(bool); ok && enabled {
+		fmt.Printf("Backend: %v\n", stats["backend"])
 		fmt.Printf("Total Entries: %v\n", stats["total_entries"])
 		fmt.Printf("TTL: %v\n", stats["ttl"])
+		fmt.Printf("Stale If Error: %v\n", stats["stale_if_error"])
+		fmt.Printf("Stale While Revalidate: %v\n", stats["stale_while_revalidate"])
 
 		if envCounts, ok := stats["environment_counts"].(map[string]int); ok {
 			fmt.Println("Entries by Environment:")
@@ -353,6 +364,9 @@ func runCacheConfigWithCmd(cmd *cobra.Command) error {
 		fmt.Println("Current Cache Configuration:")
 		fmt.Printf("Enabled: %v\n", globalConfig.Cache.Enabled)
 		fmt.Printf("TTL: %s\n", globalConfig.Cache.TTL)
+		fmt.Printf("Stale If Error: %s\n", globalConfig.Cache.StaleIfError)
+		fmt.Printf("Stale While Revalidate: %s\n", globalConfig.Cache.StaleWhileRevalidate)
+		fmt.Printf("Backend: %s\n", displayCacheBackend(globalConfig.Cache.Backend))
 		return nil
 	}
 
@@ -388,6 +402,35 @@ func runCacheConfigWithCmd(cmd *cobra.Command) error {
 		modified = true
 	}
 
+	staleIfErrorStr, _ := cmd.Flags().GetString("stale-if-error")
+	if staleIfErrorStr != "" {
+		duration, _, err := cache.ParseDuration(staleIfErrorStr)
+		if err != nil {
+			return fmt.Errorf("invalid stale-if-error format: %w", err)
+		}
+		globalConfig.Cache.StaleIfError = duration
+		modified = true
+	}
+
+	staleWhileRevalidateStr, _ := cmd.Flags().GetString("stale-while-revalidate")
+	if staleWhileRevalidateStr != "" {
+		duration, _, err := cache.ParseDuration(staleWhileRevalidateStr)
+		if err != nil {
+			return fmt.Errorf("invalid stale-while-revalidate format: %w", err)
+		}
+		globalConfig.Cache.StaleWhileRevalidate = duration
+		modified = true
+	}
+
+	backend, _ := cmd.Flags().GetString("backend")
+	if backend != "" {
+		if !cache.IsValidBackend(backend) {
+			return fmt.Errorf("invalid cache backend '%s': must be one of: %s, %s, %s", backend, cache.BackendSQLite, cache.BackendFile, cache.BackendMemory)
+		}
+		globalConfig.Cache.Backend = backend
+		modified = true
+	}
+
 	if !modified {
 		fmt.Println("No changes specified. Use --help to see available options.")
 		return nil
@@ -401,6 +444,9 @@ func runCacheConfigWithCmd(cmd *cobra.Command) error {
 	fmt.Println("Cache configuration updated successfully.")
 	fmt.Printf("Enabled: %v\n", globalConfig.Cache.Enabled)
 	fmt.Printf("TTL: %s\n", globalConfig.Cache.TTL)
+	fmt.Printf("Stale If Error: %s\n", globalConfig.Cache.StaleIfError)
+	fmt.Printf("Stale While Revalidate: %s\n", globalConfig.Cache.StaleWhileRevalidate)
+	fmt.Printf("Backend: %s\n", displayCacheBackend(globalConfig.Cache.Backend))
 
 	return nil
 }
@@ -418,8 +464,14 @@ func runCacheExpire(cmd *cobra.Command) error {
 		return fmt.Errorf("invalid TTL format: %w", err)
 	}
 
+	// Load global config to select the backend and retain stale entries
+	globalConfig, err := config.LoadGlobalConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load global config: %w", err)
+	}
+
 	// Initialize cache
-	cacheInstance, err := cache.NewCache()
+	cacheInstance, err := cache.NewBackend(globalConfig.Cache)
 	if err != nil {
 		return fmt.Errorf("failed to initialize cache: %w", err)
 	}
@@ -465,3 +517,11 @@ func maskSecret(value string) string {
 	}
 	return value[:2] + strings.Repeat("*", len(value)-4) + value[len(value)-2:]
 }
+
+// displayCacheBackend returns the backend name for display, marking the default
+func displayCacheBackend(backend string) string {
+	if backend == "" {
+		return "default"
+	}
+	return backend
+}
@@ -0,0 +1,294 @@
+package kuba
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	copyFromEnv    string
+	copyToEnv      string
+	copyConfigFile string
+	copyRename     []string
+	copyOverwrite  bool
+	copyDryRun     bool
+)
+
+// getEnvironmentSecrets resolves all secrets of an environment from the
+// providers, bypassing the cache and the agent so that no outdated value is
+// copied, and fails when a provider returns an error.
+// It is a variable to allow overriding in tests.
+var getEnvironmentSecrets = func(ctx context.Context, env *config.Environment) (map[string]string, error) {
+	factory := secrets.NewSecretManagerFactory()
+	factory.DisableAgent = true
+	factory.FailOnError = true
+	return factory.GetSecretsForEnvironment(ctx, env)
+}
+
+var copyCmd = &cobra.Command{
+	Use:   "copy",
+	Short: "Copy secrets from one environment into another",
+	Long: `Copy secrets from one environment into another environment's provider.
+
+Every secret of the source environment (secret-key and secret-path mappings)
+is resolved and written into the provider of the target environment. The
+target environment's mappings in kuba.yaml are updated to point to the new
+secrets. Plain value mappings are not copied.
+
+Source secrets are read from the providers, never from the cache or the
+agent. Nothing is copied when a provider fails or a secret-key mapping does
+not resolve.
+
+Secret keys are kept as they are unless a --rename rule matches. Rules are
+OLD=NEW for a single key, or OLD*=NEW* to replace a key prefix; the first
+matching rule wins. Secrets loaded via secret-path use the lowercased
+variable name as their key.
+
+Existing secrets in the target provider are never overwritten unless
+--overwrite is given.
+
+Examples:
+  kuba copy --from-env legacy --to-env default
+  kuba copy --from-env legacy --to-env default --rename 'prod_*=app-*' --dry-run
+  kuba copy --from-env staging --to-env production --overwrite`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCopy()
+	},
+}
+
+func init() {
+	copyCmd.Flags().StringVar(&copyFromEnv, "from-env", "", "Environment to copy secrets from")
+	copyCmd.Flags().StringVar(&copyToEnv, "to-env", "", "Environment to copy secrets into")
+	copyCmd.Flags().StringVarP(&copyConfigFile, "config", "c", "", "Path to kuba.yaml configuration file")
+	copyCmd.Flags().StringArrayVar(&copyRename, "rename", nil, "Secret key renaming rule OLD=NEW or OLD*=NEW* (can be repeated)")
+	copyCmd.Flags().BoolVar(&copyOverwrite, "overwrite", false, "Overwrite secrets that already exist in the target provider")
+	copyCmd.Flags().BoolVar(&copyDryRun, "dry-run", false, "Show what would be copied without writing anything")
+
+	copyCmd.MarkFlagRequired("from-env")
+	copyCmd.MarkFlagRequired("to-env")
+
+	rootCmd.AddCommand(copyCmd)
+}
+
+// renameRule rewrites a secret key, either exactly or by prefix
+type renameRule struct {
+	from   string
+	to     string
+	prefix bool
+}
+
+// parseRenameRules parses OLD=NEW and OLD*=NEW* rules
+func parseRenameRules(raw []string) ([]renameRule, error) {
+	rules := make([]renameRule, 0, len(raw))
+	for _, r := range raw {
+		from, to, ok := strings.Cut(r, "=")
+		if !ok || from == "" {
+			return nil, fmt.Errorf("invalid rename rule '%s': expected OLD=NEW", r)
+		}
+		fromPrefix := strings.HasSuffix(from, "*")
+		toPrefix := strings.HasSuffix(to, "*")
+		if fromPrefix != toPrefix {
+			return nil, fmt.Errorf("invalid rename rule '%s': use '*' on both sides to rename a prefix", r)
+		}
+		if strings.Count(from, "*") > 1 || strings.Count(to, "*") > 1 || (!fromPrefix && (strings.Contains(from, "*") || strings.Contains(to, "*"))) {
+			return nil, fmt.Errorf("invalid rename rule '%s': '*' is only supported at the end", r)
+		}
+		if fromPrefix {
+			rules = append(rules, renameRule{from: strings.TrimSuffix(from, "*"), to: strings.TrimSuffix(to, "*"), prefix: true})
+		} else {
+			if to == "" {
+				return nil, fmt.Errorf("invalid rename rule '%s': new key cannot be empty", r)
+			}
+			rules = append(rules, renameRule{from: from, to: to})
+		}
+	}
+	return rules, nil
+}
+
+// renameSecretKey applies the first matching rule to the key
+func renameSecretKey(key string, rules []renameRule) string {
+	for _, rule := range rules {
+		if rule.prefix {
+			if strings.HasPrefix(key, rule.from) {
+				return rule.to + strings.TrimPrefix(key, rule.from)
+			}
+			continue
+		}
+		if key == rule.from {
+			return rule.to
+		}
+	}
+	return key
+}
+
+func runCopy() error {
+	logger := log.NewLogger()
+
+	if copyFromEnv == copyToEnv {
+		return fmt.Errorf("source and target environment must be different")
+	}
+	rules, err := parseRenameRules(copyRename)
+	if err != nil {
+		return err
+	}
+
+	configPath := copyConfigFile
+	if configPath == "" {
+		found, err := config.FindConfigFile()
+		if err != nil {
+			return fmt.Errorf("failed to find configuration file: %w", err)
+		}
+		configPath = found
+	}
+
+	kubaConfig, err := config.LoadKubaConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	fromEnv, err := kubaConfig.GetEnvironment(copyFromEnv)
+	if err != nil {
+		return fmt.Errorf("failed to get environment '%s': %w", copyFromEnv, err)
+	}
+	toEnv, err := kubaConfig.GetEnvironment(copyToEnv)
+	if err != nil {
+		return fmt.Errorf("failed to get environment '%s': %w", copyToEnv, err)
+	}
+
+	ctx := context.Background()
+
+	// Resolve the secrets of the source environment only
+	sourceEnv := *fromEnv
+	sourceEnv.Env = map[string]config.EnvItem{}
+	for name, item := range fromEnv.Env {
+		if item.SecretKey != "" || item.SecretPath != "" {
+			sourceEnv.Env[name] = item
+		}
+	}
+	if len(sourceEnv.Env) == 0 {
+		fmt.Printf("Environment '%s' has no secrets to copy.\n", copyFromEnv)
+		return nil
+	}
+
+	values, err := getEnvironmentSecrets(ctx, &sourceEnv)
+	if err != nil {
+		return fmt.Errorf("failed to get secrets for '%s': %w", copyFromEnv, err)
+	}
+	var missing []string
+	for name, item := range sourceEnv.Env {
+		if _, ok := values[name]; item.SecretKey != "" && !ok {
+			missing = append(missing, fmt.Sprintf("%s (%s)", name, item.SecretKey))
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("secrets of '%s' could not be resolved: %s", copyFromEnv, strings.Join(missing, ", "))
+	}
+
+	managers := newWriteManagers(ctx)
+	defer managers.Close()
+
+	actions, err := planCopy(managers, fromEnv, toEnv, values, rules)
+	if err != nil {
+		return err
+	}
+	logger.Debug("Planned copy", "from", copyFromEnv, "to", copyToEnv, "count", len(actions))
+
+	if !copyOverwrite {
+		var existing []string
+		for _, a := range actions {
+			if a.Exists {
+				existing = append(existing, a.SecretKey)
+			}
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("secrets already exist in '%s': %s (use --overwrite to replace them)", copyToEnv, strings.Join(existing, ", "))
+		}
+	}
+
+	if !copyDryRun {
+		if err := applyGCPReplicationDefaults(managers, actions); err != nil {
+			return err
+		}
+	}
+
+	// Drop cached values of the written secrets, also when a later write fails
+	var changed []secrets.ChangedSecret
+	defer func() { secrets.InvalidateSecrets(kubaConfig, configPath, changed) }()
+	for i, a := range actions {
+		verb := "create"
+		if a.Exists {
+			verb = conflictOverwrite
+		}
+		fmt.Printf("[%d/%d] %s %s -> %s (%s)\n", i+1, len(actions), pushActionSymbol(verb), a.EnvVar, a.SecretKey, verb)
+		if copyDryRun {
+			continue
+		}
+
+		mutator, err := managers.mutator(a.Provider, a.Project)
+		if err != nil {
+			return err
+		}
+		if a.Exists {
+			if err := mutator.UpdateSecret(a.SecretKey, a.Value); err != nil {
+				return fmt.Errorf("failed to update secret '%s': %w", a.SecretKey, err)
+			}
+		} else if err := mutator.CreateSecret(a.SecretKey, a.Value, ""); err != nil {
+			return fmt.Errorf("failed to create secret '%s': %w", a.SecretKey, err)
+		}
+		changed = append(changed, secrets.ChangedSecret{Provider: a.Provider, Project: a.Project, SecretKey: a.SecretKey})
+
+		if err := writeEnvMapping(configPath, copyToEnv, toEnv, a); err != nil {
+			return fmt.Errorf("failed to update configuration: %w", err)
+		}
+	}
+
+	if copyDryRun {
+		fmt.Printf("Dry run: %d secret(s) would be copied from '%s' to '%s'.\n", len(actions), copyFromEnv, copyToEnv)
+		return nil
+	}
+
+	fmt.Printf("Copied %d secret(s) from '%s' to '%s'.\n", len(actions), copyFromEnv, copyToEnv)
+	return nil
+}
+
+// planCopy determines the target secret key for every resolved variable and
+// whether it already exists in the target provider
+func planCopy(managers *writeManagers, fromEnv, toEnv *config.Environment, values map[string]string, rules []renameRule) ([]pushAction, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	actions := make([]pushAction, 0, len(names))
+	for _, name := range names {
+		sourceKey := strings.ToLower(name)
+		if item, ok := fromEnv.Env[name]; ok && item.SecretKey != "" {
+			sourceKey = item.SecretKey
+		}
+		a := pushAction{
+			EnvVar:    name,
+			SecretKey: renameSecretKey(sourceKey, rules),
+			Value:     values[name],
+		}
+		a.Provider, a.Project = writeTarget(toEnv, name)
+
+		sm, err := managers.get(a.Provider, a.Project)
+		if err != nil {
+			return nil, err
+		}
+		if a.Exists, err = secretExists(sm, a.Project, a.SecretKey); err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
@@ -0,0 +1,210 @@
+package kuba
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetCopyFlags(t *testing.T) {
+	t.Cleanup(func() {
+		copyFromEnv = ""
+		copyToEnv = ""
+		copyConfigFile = ""
+		copyRename = nil
+		copyOverwrite = false
+		copyDryRun = false
+	})
+}
+
+func useSourceSecrets(t *testing.T, values map[string]string) {
+	prev := getEnvironmentSecrets
+	getEnvironmentSecrets = func(ctx context.Context, env *config.Environment) (map[string]string, error) {
+		out := map[string]string{}
+		for name := range env.Env {
+			if value, ok := values[name]; ok {
+				out[name] = value
+			}
+		}
+		return out, nil
+	}
+	t.Cleanup(func() { getEnvironmentSecrets = prev })
+}
+
+func writeCopyConfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "kuba.yaml")
+	content := `legacy:
+  provider: aws
+  env:
+    DB_PASSWORD:
+      secret-key: legacy_db_password
+    PLAIN:
+      value: not-a-secret
+default:
+  provider: gcp
+  project: "123"
+  env:
+    OTHER:
+      secret-key: other
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func runCopyQuietly(t *testing.T) (string, error) {
+	return captureStdout(t, runCopy)
+}
+
+func TestRunCopyCopiesSecretsWithRenameRules(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	useSourceSecrets(t, map[string]string{"DB_PASSWORD": "s3cret"})
+	resetCopyFlags(t)
+	store := useFakeSecretStore(t, map[string]string{})
+
+	copyConfigFile = writeCopyConfig(t)
+	copyFromEnv = "legacy"
+	copyToEnv = "default"
+	copyRename = []string{"legacy_*=app-*"}
+
+	output, err := runCopyQuietly(t)
+	require.NoError(t, err)
+	assert.Contains(t, output, "[1/1] + DB_PASSWORD -> app-db_password (create)")
+	assert.Equal(t, map[string]string{"app-db_password": "s3cret"}, store.secrets)
+
+	cfg, err := config.LoadKubaConfig(copyConfigFile)
+	require.NoError(t, err)
+	env, err := cfg.GetEnvironment("default")
+	require.NoError(t, err)
+	assert.Equal(t, "app-db_password", env.Env["DB_PASSWORD"].SecretKey)
+	assert.Equal(t, "other", env.Env["OTHER"].SecretKey)
+	_, copiedPlain := env.Env["PLAIN"]
+	assert.False(t, copiedPlain)
+}
+
+func TestRunCopyMapsInheritedItemsToTheirStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	useSourceSecrets(t, map[string]string{"DB_PASSWORD": "s3cret"})
+	resetCopyFlags(t)
+	var targets []string
+	store := &fakeSecretStore{secrets: map[string]string{}}
+	prev := newSecretManager
+	newSecretManager = func(ctx context.Context, provider, project string) (secrets.SecretManager, error) {
+		targets = append(targets, provider+"/"+project)
+		return store, nil
+	}
+	t.Cleanup(func() { newSecretManager = prev })
+
+	copyConfigFile = filepath.Join(t.TempDir(), "kuba.yaml")
+	content := `legacy:
+  provider: gcp
+  project: "123"
+  env:
+    DB_PASSWORD:
+      secret-key: legacy_db_password
+base:
+  provider: aws
+  env:
+    DB_PASSWORD:
+      secret-key: db-password
+default:
+  inherits: base
+  provider: gcp
+  project: "123"
+  env:
+    OTHER:
+      secret-key: other
+`
+	require.NoError(t, os.WriteFile(copyConfigFile, []byte(content), 0644))
+	copyFromEnv = "legacy"
+	copyToEnv = "default"
+	copyOverwrite = true
+
+	_, err := runCopyQuietly(t)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"aws/" + config.DefaultProject}, targets)
+
+	cfg, err := config.LoadKubaConfig(copyConfigFile)
+	require.NoError(t, err)
+	env, err := cfg.GetEnvironment("default")
+	require.NoError(t, err)
+	item := env.Env["DB_PASSWORD"]
+	assert.Equal(t, "default", item.DefinedIn)
+	assert.Equal(t, "aws", item.Provider)
+	assert.Equal(t, config.DefaultProject, item.Project)
+}
+
+func TestRunCopyRefusesToOverwrite(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	useSourceSecrets(t, map[string]string{"DB_PASSWORD": "new"})
+	resetCopyFlags(t)
+	store := useFakeSecretStore(t, map[string]string{"legacy_db_password": "old"})
+
+	copyConfigFile = writeCopyConfig(t)
+	copyFromEnv = "legacy"
+	copyToEnv = "default"
+
+	_, err := runCopyQuietly(t)
+	require.Error(t, err)
+	assert.Equal(t, "old", store.secrets["legacy_db_password"])
+
+	copyOverwrite = true
+	_, err = runCopyQuietly(t)
+	require.NoError(t, err)
+	assert.Equal(t, "new", store.secrets["legacy_db_password"])
+}
+
+func TestRunCopyFailsWhenSourceSecretsAreMissing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	useSourceSecrets(t, map[string]string{})
+	resetCopyFlags(t)
+	store := useFakeSecretStore(t, map[string]string{})
+
+	copyConfigFile = writeCopyConfig(t)
+	copyFromEnv = "legacy"
+	copyToEnv = "default"
+
+	output, err := runCopyQuietly(t)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "DB_PASSWORD (legacy_db_password)")
+	assert.NotContains(t, output, "Copied")
+	assert.Empty(t, store.secrets)
+}
+
+func TestRunCopyFailsWhenTargetCannotBeRead(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	useSourceSecrets(t, map[string]string{"DB_PASSWORD": "s3cret"})
+	resetCopyFlags(t)
+	store := useFakeSecretStore(t, map[string]string{})
+	store.readErr = fmt.Errorf("permission denied")
+
+	copyConfigFile = writeCopyConfig(t)
+	copyFromEnv = "legacy"
+	copyToEnv = "default"
+	copyOverwrite = true
+
+	_, err := runCopyQuietly(t)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+	assert.Empty(t, store.secrets)
+}
+
+func TestParseRenameRules(t *testing.T) {
+	rules, err := parseRenameRules([]string{"old-key=new-key", "legacy_*=app-*"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "new-key", renameSecretKey("old-key", rules))
+	assert.Equal(t, "app-db", renameSecretKey("legacy_db", rules))
+	assert.Equal(t, "untouched", renameSecretKey("untouched", rules))
+
+	for _, invalid := range []string{"missing-separator", "=new", "a*=b", "a=b*", "a*b*=c*", "a="} {
+		_, err := parseRenameRules([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
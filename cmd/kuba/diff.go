@@ -0,0 +1,464 @@
+package kuba
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffConfigFiles []string
+	diffDotenvFile  string
+	diffValues      bool
+	diffReveal      bool
+	diffRedact      string
+	diffOutput      string
+	diffConfirm     bool
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <env-a> [env-b]",
+	Short: "Show differences between two environments",
+	Long: `Show differences between two environments.
+
+By default the effective mappings (after inheritance) are compared: provider,
+project, reference kind (secret-key, secret-path or value) and key.
+With --values the resolved values are compared as well. Values, including
+the literal values of value mappings, are shown as hashes (or masked with
+--redact mask) unless --reveal is given. Hashes use a random key for every
+run: equal values have equal hashes within one diff, but hashes can't be
+compared across runs or used to guess the values.
+
+Pass --config twice to compare environments from two different kuba.yaml
+files; the first file is used for env-a and the second for env-b.
+Use --dotenv to compare the resolved values of a single environment against
+a dotenv file.
+
+The command exits with status 1 when differences are found and with status 2
+when the comparison fails, which makes it usable as a CI gate.
+
+Policy rules in kuba.yaml or the global configuration can require --confirm
+(or its alias --i-know) to reveal the values of an environment, or deny
+revealing them.
+
+Examples:
+  kuba diff staging production
+  kuba diff staging production --values
+  kuba diff default default --config old/kuba.yaml --config kuba.yaml
+  kuba diff staging --dotenv .env.staging --reveal
+  kuba diff staging production --output json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hasDiff, err := runDiffCommand(args)
+		if err != nil {
+			// Status 1 is reserved for differences
+			cmd.PrintErrln(cmd.ErrPrefix(), err.Error())
+			osExit(diffErrorExitCode)
+			return nil
+		}
+		if hasDiff {
+			osExit(1)
+		}
+		return nil
+	},
+}
+
+// diffErrorExitCode is the exit status of kuba diff when the comparison fails
+const diffErrorExitCode = 2
+
+func init() {
+	diffCmd.Flags().StringSliceVarP(&diffConfigFiles, "config", "c", nil, "Path to kuba.yaml configuration file (pass twice to compare two files)")
+	diffCmd.Flags().StringVar(&diffDotenvFile, "dotenv", "", "Compare the resolved values of env-a against this dotenv file")
+	diffCmd.Flags().BoolVar(&diffValues, "values", false, "Also compare resolved values")
+	diffCmd.Flags().BoolVar(&diffReveal, "reveal", false, "Show values in plain text")
+	diffCmd.Flags().StringVar(&diffRedact, "redact", "hash", "How to display values without --reveal: hash, mask")
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "human", "Output format: human (default), json")
+	diffCmd.Flags().BoolVar(&diffConfirm, "confirm", false, "Confirm revealing an environment whose policy requires it")
+	diffCmd.Flags().BoolVar(&diffConfirm, "i-know", false, "Alias of --confirm")
+	rootCmd.AddCommand(diffCmd)
+}
+
+// mappingRef describes the effective source of an environment variable
+type mappingRef struct {
+	Provider string `json:"provider"`
+	Project  string `json:"project,omitempty"`
+	Kind     string `json:"kind"`
+	Key      string `json:"key"`
+}
+
+// String formats the mapping for human output
+func (m mappingRef) String() string {
+	source := m.Provider
+	if m.Project != "" {
+		source += "/" + m.Project
+	}
+	return fmt.Sprintf("%s %s=%s", source, m.Kind, m.Key)
+}
+
+// mappingDiff is a difference between the mappings of one variable
+type mappingDiff struct {
+	Variable string      `json:"variable"`
+	Change   string      `json:"change"`
+	Left     *mappingRef `json:"left,omitempty"`
+	Right    *mappingRef `json:"right,omitempty"`
+}
+
+// valueDiff is a difference between the resolved values of one variable
+type valueDiff struct {
+	Variable string  `json:"variable"`
+	Change   string  `json:"change"`
+	Left     *string `json:"left,omitempty"`
+	Right    *string `json:"right,omitempty"`
+}
+
+// diffResult is the complete comparison of two environments
+type diffResult struct {
+	Left     string        `json:"left"`
+	Right    string        `json:"right"`
+	Mappings []mappingDiff `json:"mappings,omitempty"`
+	Values   []valueDiff   `json:"values,omitempty"`
+}
+
+// hasDifferences reports whether the comparison found anything
+func (d diffResult) hasDifferences() bool {
+	return len(d.Mappings) > 0 || len(d.Values) > 0
+}
+
+// Change kinds used in diff output
+const (
+	diffAdded   = "added"
+	diffRemoved = "removed"
+	diffChanged = "changed"
+)
+
+func runDiffCommand(args []string) (bool, error) {
+	logger := log.NewLogger()
+
+	if diffOutput != "human" && diffOutput != "json" {
+		return false, fmt.Errorf("invalid output format '%s': must be one of: human, json", diffOutput)
+	}
+	if diffRedact != "hash" && diffRedact != "mask" {
+		return false, fmt.Errorf("invalid redact mode '%s': must be one of: hash, mask", diffRedact)
+	}
+	if len(diffConfigFiles) > 2 {
+		return false, fmt.Errorf("--config can be given at most twice")
+	}
+	if diffDotenvFile != "" && len(args) != 1 {
+		return false, fmt.Errorf("--dotenv compares a single environment, got %d", len(args))
+	}
+	if diffDotenvFile == "" && len(args) != 2 {
+		return false, fmt.Errorf("two environments are required unless --dotenv is given")
+	}
+
+	// Resolve the config file for each side
+	leftConfigPath, rightConfigPath := "", ""
+	switch len(diffConfigFiles) {
+	case 0:
+		found, err := config.FindConfigFile()
+		if err != nil {
+			return false, fmt.Errorf("failed to find configuration file: %w", err)
+		}
+		leftConfigPath, rightConfigPath = found, found
+	case 1:
+		leftConfigPath, rightConfigPath = diffConfigFiles[0], diffConfigFiles[0]
+	case 2:
+		leftConfigPath, rightConfigPath = diffConfigFiles[0], diffConfigFiles[1]
+	}
+
+	leftName := args[0]
+	leftEnv, err := loadDiffEnvironment(leftConfigPath, leftName)
+	if err != nil {
+		return false, err
+	}
+
+	ctx := context.Background()
+	// A secret that can't be read must not show up as removed
+	factory := secrets.NewSecretManagerFactory()
+	factory.FailOnError = true
+	defer factory.WaitForRefresh()
+
+	result := diffResult{Left: diffSideLabel(leftConfigPath, leftName, len(diffConfigFiles) == 2)}
+
+	if diffDotenvFile != "" {
+		result.Right = diffDotenvFile
+		dotenvValues, err := parseDotenvFile(diffDotenvFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse dotenv file: %w", err)
+		}
+		leftValues, err := factory.GetSecretsForEnvironmentWithCache(ctx, leftEnv, leftConfigPath, leftName)
+		if err != nil {
+			return false, fmt.Errorf("failed to get secrets for '%s': %w", leftName, err)
+		}
+		result.Values = diffResolvedValues(leftValues, dotenvValues)
+	} else {
+		rightName := args[1]
+		rightEnv, err := loadDiffEnvironment(rightConfigPath, rightName)
+		if err != nil {
+			return false, err
+		}
+		result.Right = diffSideLabel(rightConfigPath, rightName, len(diffConfigFiles) == 2)
+		result.Mappings = diffEnvironmentMappings(leftEnv, rightEnv)
+
+		if diffValues {
+			logger.Debug("Resolving values for diff", "left", leftName, "right", rightName)
+			leftValues, err := factory.GetSecretsForEnvironmentWithCache(ctx, leftEnv, leftConfigPath, leftName)
+			if err != nil {
+				return false, fmt.Errorf("failed to get secrets for '%s': %w", leftName, err)
+			}
+			rightValues, err := factory.GetSecretsForEnvironmentWithCache(ctx, rightEnv, rightConfigPath, rightName)
+			if err != nil {
+				return false, fmt.Errorf("failed to get secrets for '%s': %w", rightName, err)
+			}
+			result.Values = diffResolvedValues(leftValues, rightValues)
+		}
+	}
+
+	if !diffReveal {
+		redactor, err := newValueRedactor(diffRedact)
+		if err != nil {
+			return false, err
+		}
+		redactMappingDiffs(result.Mappings, redactor)
+		redactValueDiffs(result.Values, redactor)
+	}
+
+	if diffOutput == "json" {
+		payload, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return false, fmt.Errorf("failed to format diff as json: %w", err)
+		}
+		fmt.Println(string(payload))
+	} else {
+		printDiffResult(result)
+	}
+
+	return result.hasDifferences(), nil
+}
+
+// loadDiffEnvironment loads a single environment from a kuba.yaml file
+func loadDiffEnvironment(configPath, envName string) (*config.Environment, error) {
+	kubaConfig, err := config.LoadKubaConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	env, err := kubaConfig.GetEnvironment(envName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get environment '%s': %w", envName, err)
+	}
+	// Without --reveal, values are hashed or masked, so the policy only
+	// applies to revealed values
+	if diffReveal {
+		policy := kubaConfig.PolicyFor(envName)
+		if policy.RequireConfirm && !diffConfirm {
+			return nil, fmt.Errorf("policy requires --confirm to reveal environment '%s'", envName)
+		}
+		if policy.RequireSensitive {
+			return nil, fmt.Errorf("policy denies --reveal for environment '%s'", envName)
+		}
+	}
+	return env, nil
+}
+
+// diffSideLabel names one side of the comparison, including the file when
+// two different files are compared
+func diffSideLabel(configPath, envName string, includeFile bool) string {
+	if includeFile {
+		return configPath + ":" + envName
+	}
+	return envName
+}
+
+// effectiveMappings returns the effective mapping of every variable in the
+// environment, resolving provider and project defaults. Projects are
+// normalized, so an empty project and the default project compare equal.
+func effectiveMappings(env *config.Environment) map[string]mappingRef {
+	mappings := make(map[string]mappingRef, len(env.Env))
+	for _, item := range env.GetEnvItems() {
+		ref := mappingRef{Provider: item.Provider, Project: item.Project}
+		if ref.Provider == "" {
+			ref.Provider = env.Provider
+		}
+		if ref.Project == "" {
+			ref.Project = env.Project
+		}
+		ref.Project = secrets.NormalizeProject(ref.Provider, ref.Project)
+		switch {
+		case item.SecretKey != "":
+			ref.Kind, ref.Key = "secret-key", item.SecretKey
+		case item.SecretPath != "":
+			ref.Kind, ref.Key = "secret-path", item.SecretPath
+		default:
+			ref.Kind, ref.Key = "value", fmt.Sprintf("%v", item.Value)
+		}
+		mappings[item.EnvironmentVariable] = ref
+	}
+	return mappings
+}
+
+// diffEnvironmentMappings compares the effective mappings of two environments
+func diffEnvironmentMappings(left, right *config.Environment) []mappingDiff {
+	leftMappings := effectiveMappings(left)
+	rightMappings := effectiveMappings(right)
+
+	var diffs []mappingDiff
+	for _, name := range unionKeys(leftMappings, rightMappings) {
+		l, inLeft := leftMappings[name]
+		r, inRight := rightMappings[name]
+		switch {
+		case !inRight:
+			diffs = append(diffs, mappingDiff{Variable: name, Change: diffRemoved, Left: &l})
+		case !inLeft:
+			diffs = append(diffs, mappingDiff{Variable: name, Change: diffAdded, Right: &r})
+		case l != r:
+			diffs = append(diffs, mappingDiff{Variable: name, Change: diffChanged, Left: &l, Right: &r})
+		}
+	}
+	return diffs
+}
+
+// diffResolvedValues compares two sets of resolved values
+func diffResolvedValues(left, right map[string]string) []valueDiff {
+	var diffs []valueDiff
+	for _, name := range unionKeys(left, right) {
+		l, inLeft := left[name]
+		r, inRight := right[name]
+		switch {
+		case !inRight:
+			diffs = append(diffs, valueDiff{Variable: name, Change: diffRemoved, Left: &l})
+		case !inLeft:
+			diffs = append(diffs, valueDiff{Variable: name, Change: diffAdded, Right: &r})
+		case l != r:
+			diffs = append(diffs, valueDiff{Variable: name, Change: diffChanged, Left: &l, Right: &r})
+		}
+	}
+	return diffs
+}
+
+// valueRedactor replaces values with hashes or masked values. Hashes are
+// HMACs with a key generated for the redactor, so a short value can't be
+// recovered from them by brute force.
+type valueRedactor struct {
+	mode string
+	key  []byte
+}
+
+// newValueRedactor creates a redactor with a random key
+func newValueRedactor(mode string) (*valueRedactor, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate hash key: %w", err)
+	}
+	return &valueRedactor{mode: mode, key: key}, nil
+}
+
+// redact returns the hash or the masked form of a value
+func (r *valueRedactor) redact(value string) string {
+	if r.mode == "mask" {
+		return maskSecret(value)
+	}
+	return r.hash(value)
+}
+
+// hash returns a short fingerprint of a value, stable for the redactor
+func (r *valueRedactor) hash(value string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(value))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil))[:12]
+}
+
+// redactMappingDiffs replaces the literal values of value mappings with
+// hashes or masked values
+func redactMappingDiffs(diffs []mappingDiff, redactor *valueRedactor) {
+	redact := func(ref *mappingRef) {
+		if ref != nil && ref.Kind == "value" {
+			ref.Key = redactor.redact(ref.Key)
+		}
+	}
+	for i := range diffs {
+		redact(diffs[i].Left)
+		redact(diffs[i].Right)
+	}
+}
+
+// redactValueDiffs replaces values with hashes or masked values
+func redactValueDiffs(diffs []valueDiff, redactor *valueRedactor) {
+	redact := func(value *string) *string {
+		if value == nil {
+			return nil
+		}
+		redacted := redactor.redact(*value)
+		return &redacted
+	}
+	for i := range diffs {
+		diffs[i].Left = redact(diffs[i].Left)
+		diffs[i].Right = redact(diffs[i].Right)
+	}
+}
+
+// printDiffResult prints the comparison in a human readable format
+func printDiffResult(result diffResult) {
+	fmt.Printf("--- %s\n", result.Left)
+	fmt.Printf("+++ %s\n", result.Right)
+
+	if !result.hasDifferences() {
+		fmt.Println("No differences found.")
+		return
+	}
+
+	if len(result.Mappings) > 0 {
+		fmt.Println("\nMappings:")
+		for _, d := range result.Mappings {
+			switch d.Change {
+			case diffRemoved:
+				fmt.Printf("- %s: %s\n", d.Variable, d.Left)
+			case diffAdded:
+				fmt.Printf("+ %s: %s\n", d.Variable, d.Right)
+			default:
+				fmt.Printf("~ %s: %s -> %s\n", d.Variable, d.Left, d.Right)
+			}
+		}
+	}
+
+	if len(result.Values) > 0 {
+		fmt.Println("\nValues:")
+		for _, d := range result.Values {
+			switch d.Change {
+			case diffRemoved:
+				fmt.Printf("- %s=%s\n", d.Variable, *d.Left)
+			case diffAdded:
+				fmt.Printf("+ %s=%s\n", d.Variable, *d.Right)
+			default:
+				fmt.Printf("~ %s: %s -> %s\n", d.Variable, *d.Left, *d.Right)
+			}
+		}
+	}
+
+	fmt.Printf("\n%d mapping difference(s), %d value difference(s)\n", len(result.Mappings), len(result.Values))
+}
+
+// unionKeys returns the sorted union of the keys of two maps
+func unionKeys[V any](left, right map[string]V) []string {
+	seen := make(map[string]struct{}, len(left)+len(right))
+	for key := range left {
+		seen[key] = struct{}{}
+	}
+	for key := range right {
+		seen[key] = struct{}{}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
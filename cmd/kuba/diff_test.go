@@ -0,0 +1,293 @@
+package kuba
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetDiffFlags(t *testing.T) {
+	t.Cleanup(func() {
+		diffConfigFiles = nil
+		diffDotenvFile = ""
+		diffValues = false
+		diffReveal = false
+		diffRedact = "hash"
+		diffOutput = "human"
+		diffConfirm = false
+	})
+}
+
+func writeDiffFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func captureDiffOutput(t *testing.T, args []string) (bool, []byte) {
+	var hasDiff bool
+	output, err := captureStdout(t, func() error {
+		var runErr error
+		hasDiff, runErr = runDiffCommand(args)
+		return runErr
+	})
+	require.NoError(t, err)
+	return hasDiff, []byte(output)
+}
+
+const diffTestConfig = `
+default:
+  provider: local
+  env:
+    SHARED:
+      value: same
+    CHANGED:
+      value: left
+    ONLY_DEFAULT:
+      value: one
+staging:
+  provider: local
+  env:
+    SHARED:
+      value: same
+    CHANGED:
+      value: right
+    ONLY_STAGING:
+      value: two
+`
+
+func TestRunDiffCommandComparesMappings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetDiffFlags(t)
+
+	diffConfigFiles = []string{writeDiffFile(t, "kuba.yaml", diffTestConfig)}
+	diffOutput = "json"
+
+	hasDiff, output := captureDiffOutput(t, []string{"default", "staging"})
+	assert.True(t, hasDiff)
+
+	var result diffResult
+	require.NoError(t, json.Unmarshal(output, &result))
+
+	require.Len(t, result.Mappings, 3)
+	assert.Equal(t, "CHANGED", result.Mappings[0].Variable)
+	assert.Equal(t, diffChanged, result.Mappings[0].Change)
+	assert.Equal(t, "ONLY_DEFAULT", result.Mappings[1].Variable)
+	assert.Equal(t, diffRemoved, result.Mappings[1].Change)
+	assert.Equal(t, "ONLY_STAGING", result.Mappings[2].Variable)
+	assert.Equal(t, diffAdded, result.Mappings[2].Change)
+	assert.Empty(t, result.Values)
+
+	// Literal values are redacted in mappings like in value diffs
+	assert.Regexp(t, `^hmac:[0-9a-f]{12}$`, result.Mappings[0].Left.Key)
+	assert.NotEqual(t, result.Mappings[0].Left.Key, result.Mappings[0].Right.Key)
+	assert.NotContains(t, string(output), "\"one\"")
+
+	diffReveal = true
+	_, output = captureDiffOutput(t, []string{"default", "staging"})
+	require.NoError(t, json.Unmarshal(output, &result))
+	assert.Equal(t, "left", result.Mappings[0].Left.Key)
+}
+
+func TestRunDiffCommandNoDifferences(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetDiffFlags(t)
+
+	diffConfigFiles = []string{writeDiffFile(t, "kuba.yaml", diffTestConfig)}
+	diffValues = true
+
+	hasDiff, output := captureDiffOutput(t, []string{"default", "default"})
+	assert.False(t, hasDiff)
+	assert.Contains(t, string(output), "No differences found.")
+}
+
+func TestRunDiffCommandHashesValuesUnlessRevealed(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetDiffFlags(t)
+
+	diffConfigFiles = []string{writeDiffFile(t, "kuba.yaml", diffTestConfig)}
+	diffValues = true
+	diffOutput = "json"
+
+	_, output := captureDiffOutput(t, []string{"default", "staging"})
+	var result diffResult
+	require.NoError(t, json.Unmarshal(output, &result))
+	require.Len(t, result.Values, 3)
+	assert.Regexp(t, `^hmac:[0-9a-f]{12}$`, *result.Values[0].Left)
+	assert.NotEqual(t, *result.Values[0].Left, *result.Values[0].Right)
+
+	diffReveal = true
+	_, output = captureDiffOutput(t, []string{"default", "staging"})
+	require.NoError(t, json.Unmarshal(output, &result))
+	assert.Equal(t, "left", *result.Values[0].Left)
+	assert.Equal(t, "right", *result.Values[0].Right)
+}
+
+func TestValueRedactorKeysHashesPerRun(t *testing.T) {
+	first, err := newValueRedactor("hash")
+	require.NoError(t, err)
+	second, err := newValueRedactor("hash")
+	require.NoError(t, err)
+
+	assert.Equal(t, first.redact("1234"), first.redact("1234"))
+	assert.NotEqual(t, first.redact("1234"), first.redact("1235"))
+	assert.NotEqual(t, first.redact("1234"), second.redact("1234"))
+
+	masked, err := newValueRedactor("mask")
+	require.NoError(t, err)
+	assert.Equal(t, maskSecret("1234"), masked.redact("1234"))
+}
+
+func TestRunDiffCommandAgainstDotenv(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetDiffFlags(t)
+
+	diffConfigFiles = []string{writeDiffFile(t, "kuba.yaml", diffTestConfig)}
+	diffDotenvFile = writeDiffFile(t, ".env", "SHARED=same\nCHANGED=left\nONLY_DEFAULT=one\n")
+
+	hasDiff, _ := captureDiffOutput(t, []string{"default"})
+	assert.False(t, hasDiff)
+
+	diffReveal = true
+	hasDiff, output := captureDiffOutput(t, []string{"staging"})
+	assert.True(t, hasDiff)
+	assert.Contains(t, string(output), "~ CHANGED: right -> left")
+	assert.Contains(t, string(output), "- ONLY_STAGING=two")
+	assert.Contains(t, string(output), "+ ONLY_DEFAULT=one")
+}
+
+func TestRunDiffCommandComparesTwoConfigFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetDiffFlags(t)
+
+	left := writeDiffFile(t, "left.yaml", `
+default:
+  provider: gcp
+  project: "123"
+  env:
+    DB_PASSWORD:
+      secret-key: db-password
+`)
+	right := writeDiffFile(t, "right.yaml", `
+default:
+  provider: gcp
+  project: "456"
+  env:
+    DB_PASSWORD:
+      secret-key: db-password
+`)
+	diffConfigFiles = []string{left, right}
+
+	hasDiff, output := captureDiffOutput(t, []string{"default", "default"})
+	assert.True(t, hasDiff)
+	assert.Contains(t, string(output), "~ DB_PASSWORD: gcp/123 secret-key=db-password -> gcp/456 secret-key=db-password")
+}
+
+func TestRunDiffCommandEnforcesPolicyWhenRevealing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetDiffFlags(t)
+
+	diffConfigFiles = []string{writeDiffFile(t, "kuba.yaml", `
+policy:
+  - env: staging
+    require-confirm: true
+  - env: production
+    require-sensitive: true
+`+diffTestConfig+`
+production:
+  provider: local
+  env:
+    SHARED:
+      value: same
+`)}
+	diffValues = true
+
+	// Hashed values are allowed without confirmation
+	_, _ = captureDiffOutput(t, []string{"default", "staging"})
+
+	diffReveal = true
+	_, err := runDiffCommand([]string{"default", "staging"})
+	require.EqualError(t, err, "policy requires --confirm to reveal environment 'staging'")
+
+	diffConfirm = true
+	_, _ = captureDiffOutput(t, []string{"default", "staging"})
+
+	_, err = runDiffCommand([]string{"default", "production"})
+	require.EqualError(t, err, "policy denies --reveal for environment 'production'")
+}
+
+func TestRunDiffCommandFailsWhenSecretsCannotBeRead(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("KUBA_AGENT_SOCKET", filepath.Join(t.TempDir(), "agent.sock"))
+	t.Setenv("OPENBAO_ADDR", "")
+	resetDiffFlags(t)
+
+	diffConfigFiles = []string{writeDiffFile(t, "kuba.yaml", `
+default:
+  provider: openbao
+  env:
+    DB_PASSWORD:
+      secret-key: db-password
+staging:
+  provider: local
+  env:
+    OTHER:
+      value: x
+`)}
+	diffValues = true
+
+	_, err := runDiffCommand([]string{"default", "staging"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "OPENBAO_ADDR")
+}
+
+func TestDiffCommandExitCodes(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetDiffFlags(t)
+	prevOsExit := osExit
+	t.Cleanup(func() { osExit = prevOsExit })
+	var exitedWith []int
+	osExit = func(code int) {
+		exitedWith = append(exitedWith, code)
+	}
+
+	diffConfigFiles = []string{writeDiffFile(t, "kuba.yaml", diffTestConfig)}
+	diffCmd.SetErr(io.Discard)
+	t.Cleanup(func() { diffCmd.SetErr(nil) })
+
+	_, err := captureStdout(t, func() error { return diffCmd.RunE(diffCmd, []string{"default", "staging"}) })
+	require.NoError(t, err)
+	require.NoError(t, diffCmd.RunE(diffCmd, []string{"default", "missing"}))
+	assert.Equal(t, []int{1, diffErrorExitCode}, exitedWith)
+}
+
+func TestDiffEnvironmentMappingsNormalizesProjects(t *testing.T) {
+	left := &config.Environment{Provider: "aws", Env: map[string]config.EnvItem{
+		"DB_PASSWORD": {SecretKey: "db-password"},
+	}}
+	right := &config.Environment{Provider: "aws", Project: secrets.DefaultProject, Env: map[string]config.EnvItem{
+		"DB_PASSWORD": {SecretKey: "db-password"},
+	}}
+	assert.Empty(t, diffEnvironmentMappings(left, right))
+
+	right.Project = "other"
+	assert.Len(t, diffEnvironmentMappings(left, right), 1)
+}
+
+func TestRunDiffCommandRejectsInvalidArguments(t *testing.T) {
+	resetDiffFlags(t)
+
+	_, err := runDiffCommand([]string{"default"})
+	assert.Error(t, err)
+
+	diffOutput = "xml"
+	_, err = runDiffCommand([]string{"default", "staging"})
+	assert.Error(t, err)
+}
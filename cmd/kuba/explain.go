@@ -0,0 +1,238 @@
+package kuba
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+)
+
+// sourceMissing marks variables the providers returned no value for
+const sourceMissing = "missing"
+
+// variableExplanation describes where a resolved variable came from
+type variableExplanation struct {
+	Name           string                 `json:"name"`
+	Value          string                 `json:"value"`
+	Environment    string                 `json:"environment"`
+	Inherited      bool                   `json:"inherited"`
+	File           string                 `json:"file,omitempty"`
+	Override       bool                   `json:"override,omitempty"`
+	Provider       string                 `json:"provider"`
+	Project        string                 `json:"project,omitempty"`
+	SecretKey      string                 `json:"secret_key,omitempty"`
+	SecretPath     string                 `json:"secret_path,omitempty"`
+	Source         string                 `json:"source"`
+	CachedAt       *time.Time             `json:"cached_at,omitempty"`
+	ExpiresAt      *time.Time             `json:"expires_at,omitempty"`
+	Stale          bool                   `json:"stale,omitempty"`
+	Interpolations []config.Interpolation `json:"interpolations,omitempty"`
+}
+
+// explainEnvironment explains every variable of the environment. Values are
+// always masked.
+func explainEnvironment(env *config.Environment, envName string, resolution *secrets.Resolution) []variableExplanation {
+	// Interpolations in kuba.yaml values are resolved against the other
+	// values first, secrets are only available at run time
+	valueVars := make(map[string]string)
+	for name, item := range env.Env {
+		if item.Value != nil {
+			valueVars[name] = resolution.Values[name]
+		}
+	}
+
+	var explanations []variableExplanation
+	for _, item := range env.GetEnvItems() {
+		base := variableExplanation{
+			Name:        item.EnvironmentVariable,
+			Environment: item.DefinedIn,
+			Inherited:   item.DefinedIn != "" && item.DefinedIn != envName,
+			File:        item.Source.String(),
+			Override:    item.Override,
+			Provider:    env.Provider,
+			Project:     env.Project,
+			SecretKey:   item.SecretKey,
+			SecretPath:  item.SecretPath,
+			Source:      sourceMissing,
+		}
+		if base.Environment == "" {
+			base.Environment = envName
+		}
+		if item.Provider != "" {
+			base.Provider = item.Provider
+		}
+		if item.Project != "" {
+			base.Project = item.Project
+		}
+
+		if item.Value != nil {
+			template := item.RawValue
+			if template == "" {
+				template = fmt.Sprintf("%v", item.Value)
+			}
+			base.Interpolations = config.TraceInterpolations(template, valueVars)
+			for i, trace := range base.Interpolations {
+				if _, ok := resolution.Values[trace.Variable]; ok && trace.Source == config.InterpolationUnresolved {
+					base.Interpolations[i].Source = config.InterpolationConfig
+				}
+			}
+		}
+
+		if item.SecretPath != "" {
+			found := false
+			for name, source := range resolution.Sources {
+				if source.Item != item.EnvironmentVariable || name == item.EnvironmentVariable {
+					continue
+				}
+				found = true
+				e := base
+				e.Name = name
+				explanations = append(explanations, withSource(e, source, resolution.Values[name]))
+			}
+			if found {
+				continue
+			}
+		}
+
+		if source, ok := resolution.Sources[item.EnvironmentVariable]; ok {
+			base = withSource(base, source, resolution.Values[item.EnvironmentVariable])
+		}
+		explanations = append(explanations, base)
+	}
+
+	sort.Slice(explanations, func(i, j int) bool {
+		return explanations[i].Name < explanations[j].Name
+	})
+	return explanations
+}
+
+func withSource(e variableExplanation, source secrets.ValueSource, value string) variableExplanation {
+	e.Source = source.Origin
+	e.Value = maskSecret(value)
+	if source.Origin == secrets.OriginCache {
+		cachedAt, expiresAt := source.CachedAt, source.ExpiresAt
+		e.CachedAt = &cachedAt
+		e.ExpiresAt = &expiresAt
+		e.Stale = source.Stale
+	}
+	return e
+}
+
+// filterExplanations keeps the explanations whose name matches the patterns
+func filterExplanations(explanations []variableExplanation, patterns []string) []variableExplanation {
+	if len(patterns) == 0 {
+		return explanations
+	}
+	names := make(map[string]string, len(explanations))
+	for _, e := range explanations {
+		names[e.Name] = ""
+	}
+	matched := filterSecrets(names, patterns)
+	filtered := make([]variableExplanation, 0, len(matched))
+	for _, e := range explanations {
+		if _, ok := matched[e.Name]; ok {
+			filtered = append(filtered, e)
+		}
+	}
+	return filtered
+}
+
+// writeExplanations writes the explanations as text or, with format json, as
+// a JSON array
+func writeExplanations(w io.Writer, format string, explanations []variableExplanation) error {
+	switch format {
+	case "json":
+		if explanations == nil {
+			explanations = []variableExplanation{}
+		}
+		payload, err := json.MarshalIndent(explanations, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format explanation as json: %w", err)
+		}
+		fmt.Fprintln(w, string(payload))
+		return nil
+	case "dotenv", "":
+	default:
+		return fmt.Errorf("--explain supports the json output format only")
+	}
+
+	for i, e := range explanations {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, e.Name)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintf(tw, "  value:\t%s\n", e.Value)
+		defined := e.Environment
+		if e.Inherited {
+			defined += " (inherited)"
+		}
+		if e.Override {
+			defined += " (local override)"
+		}
+		fmt.Fprintf(tw, "  defined in:\t%s\n", defined)
+		if e.File != "" {
+			fmt.Fprintf(tw, "  file:\t%s\n", e.File)
+		}
+
+		switch {
+		case e.SecretKey != "":
+			fmt.Fprintf(tw, "  provider:\t%s\n", providerLabel(e.Provider, e.Project))
+			fmt.Fprintf(tw, "  ref:\tsecret-key %s\n", e.SecretKey)
+		case e.SecretPath != "":
+			fmt.Fprintf(tw, "  provider:\t%s\n", providerLabel(e.Provider, e.Project))
+			fmt.Fprintf(tw, "  ref:\tsecret-path %s\n", e.SecretPath)
+		default:
+			fmt.Fprintf(tw, "  ref:\tvalue\n")
+		}
+
+		source := e.Source
+		switch {
+		case e.Source == secrets.OriginCache:
+			source = fmt.Sprintf("cache (cached %s, expires %s)",
+				e.CachedAt.Format("2006-01-02 15:04:05"), e.ExpiresAt.Format("2006-01-02 15:04:05"))
+			if e.Stale {
+				source += ", stale"
+			}
+		case e.Source == secrets.OriginProvider:
+			source = "provider (live fetch)"
+		case e.Source == secrets.OriginValue:
+			source = "kuba.yaml"
+		}
+		fmt.Fprintf(tw, "  source:\t%s\n", source)
+
+		for _, trace := range e.Interpolations {
+			fmt.Fprintf(tw, "  interpolation:\t%s from %s\n", trace.Expression, interpolationLabel(trace.Source))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func providerLabel(provider, project string) string {
+	if project == "" {
+		return provider
+	}
+	return provider + " (project " + project + ")"
+}
+
+func interpolationLabel(source string) string {
+	switch source {
+	case config.InterpolationConfig:
+		return "kuba.yaml"
+	case config.InterpolationOS:
+		return "OS environment"
+	case config.InterpolationDefault:
+		return "default"
+	default:
+		return source
+	}
+}
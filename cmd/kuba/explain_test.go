@@ -0,0 +1,133 @@
+package kuba
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunShowCommandExplainJSON(t *testing.T) {
+	t.Cleanup(func() {
+		showEnvironment = ""
+		showConfigFile = ""
+		showOutput = "dotenv"
+		showExplain = false
+	})
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	configPath := filepath.Join(dir, "kuba.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+base:
+  provider: local
+  env:
+    HOST:
+      value: db.local
+    URL:
+      value: "postgres://${HOST}:${PORT:-5432}"
+prod:
+  provider: local
+  inherits: base
+  env:
+    HOST:
+      value: db.production
+`), 0644))
+
+	showEnvironment = "prod"
+	showConfigFile = configPath
+	showOutput = "json"
+	showExplain = true
+
+	originalStdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+
+	runErr := runShowCommand(nil, false)
+
+	require.NoError(t, w.Close())
+	os.Stdout = originalStdout
+	require.NoError(t, runErr)
+
+	outputBytes, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	var output []variableExplanation
+	require.NoError(t, json.Unmarshal(outputBytes, &output))
+	require.Len(t, output, 2)
+
+	host, url := output[0], output[1]
+	assert.Equal(t, "HOST", host.Name)
+	assert.Equal(t, "prod", host.Environment)
+	assert.False(t, host.Inherited)
+	assert.Equal(t, secrets.OriginValue, host.Source)
+	assert.Equal(t, "db*********on", host.Value)
+
+	assert.Equal(t, "URL", url.Name)
+	assert.Equal(t, "base", url.Environment)
+	assert.True(t, url.Inherited)
+	assert.Equal(t, configPath+":7:5", url.File)
+	assert.Equal(t, []config.Interpolation{
+		{Expression: "${HOST}", Variable: "HOST", Source: config.InterpolationConfig},
+		{Expression: "${PORT:-5432}", Variable: "PORT", Source: config.InterpolationDefault},
+	}, url.Interpolations)
+	assert.NotContains(t, string(outputBytes), "db.production")
+}
+
+func TestExplainEnvironmentSecrets(t *testing.T) {
+	expires := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	env := &config.Environment{
+		Provider: "gcp",
+		Project:  "main",
+		Env: map[string]config.EnvItem{
+			"DB_PASSWORD": {SecretKey: "db-password", DefinedIn: "default"},
+			"API_KEY":     {SecretKey: "api-key", Provider: "aws", DefinedIn: "default"},
+			"GONE":        {SecretKey: "gone", DefinedIn: "default"},
+		},
+	}
+	resolution := &secrets.Resolution{
+		Values: map[string]string{"DB_PASSWORD": "hunter22", "API_KEY": "abcdef"},
+		Sources: map[string]secrets.ValueSource{
+			"DB_PASSWORD": {Origin: secrets.OriginCache, Item: "DB_PASSWORD", CachedAt: expires.Add(-time.Hour), ExpiresAt: expires, Stale: true},
+			"API_KEY":     {Origin: secrets.OriginProvider, Item: "API_KEY"},
+		},
+	}
+
+	explanations := explainEnvironment(env, "default", resolution)
+	require.Len(t, explanations, 3)
+
+	apiKey, dbPassword, gone := explanations[0], explanations[1], explanations[2]
+	assert.Equal(t, "aws", apiKey.Provider)
+	assert.Equal(t, "main", apiKey.Project)
+	assert.Equal(t, secrets.OriginProvider, apiKey.Source)
+	assert.Nil(t, apiKey.ExpiresAt)
+
+	assert.Equal(t, sourceMissing, gone.Source)
+
+	assert.Equal(t, secrets.OriginCache, dbPassword.Source)
+	require.NotNil(t, dbPassword.ExpiresAt)
+	assert.Equal(t, expires, *dbPassword.ExpiresAt)
+	assert.True(t, dbPassword.Stale)
+
+	var buf bytes.Buffer
+	require.NoError(t, writeExplanations(&buf, "dotenv", filterExplanations(explanations, []string{"db_*"})))
+	text := buf.String()
+	assert.Contains(t, text, "DB_PASSWORD\n")
+	assert.Contains(t, text, "secret-key db-password")
+	assert.Contains(t, text, "gcp (project main)")
+	assert.Contains(t, text, "expires 2026-01-02 03:04:05), stale")
+	assert.NotContains(t, text, "hunter22")
+	assert.NotContains(t, text, "API_KEY")
+
+	assert.Error(t, writeExplanations(&buf, "yaml", explanations))
+}
@@ -0,0 +1,206 @@
+package kuba
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importEnv        string
+	importConfigFile string
+	importFilter     string
+	importYes        bool
+	importDryRun     bool
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Generate kuba.yaml mappings from secrets in a provider",
+	Long: `Discover the secrets in an environment's provider and add secret-key
+mappings for them to kuba.yaml.
+
+For every secret an environment variable name is proposed (uppercased, with
+invalid characters replaced by underscores). You are asked to confirm each
+mapping unless --yes is given. Secrets that are already mapped, or whose
+proposed variable name is already taken, are skipped.
+
+Comments in kuba.yaml are preserved. Secret values are never read.
+
+Supported providers: gcp, aws, azure, openbao.
+
+Examples:
+  kuba import --env staging
+  kuba import --env staging --filter 'app-*'
+  kuba import --env staging --filter 'app-*' --yes`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runImport(cmd.InOrStdin())
+	},
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&importEnv, "env", "e", "default", "Environment to import mappings into")
+	importCmd.Flags().StringVarP(&importConfigFile, "config", "c", "", "Path to kuba.yaml configuration file")
+	importCmd.Flags().StringVar(&importFilter, "filter", "", "Only import secrets matching this pattern (e.g. 'app-*')")
+	importCmd.Flags().BoolVarP(&importYes, "yes", "y", false, "Import all proposed mappings without asking")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show proposed mappings without writing anything")
+	rootCmd.AddCommand(importCmd)
+}
+
+// importCandidate is a proposed mapping for a provider secret
+type importCandidate struct {
+	SecretKey string
+	EnvVar    string
+}
+
+func runImport(in io.Reader) error {
+	logger := log.NewLogger()
+
+	if importFilter != "" {
+		if _, err := path.Match(importFilter, ""); err != nil {
+			return fmt.Errorf("invalid filter '%s': %w", importFilter, err)
+		}
+	}
+
+	configPath := importConfigFile
+	if configPath == "" {
+		found, err := config.FindConfigFile()
+		if err != nil {
+			return fmt.Errorf("failed to find configuration file: %w", err)
+		}
+		configPath = found
+	}
+
+	kubaConfig, err := config.LoadKubaConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	env, err := kubaConfig.GetEnvironment(importEnv)
+	if err != nil {
+		return fmt.Errorf("failed to get environment '%s': %w", importEnv, err)
+	}
+
+	ctx := context.Background()
+	sm, err := newSecretManager(ctx, env.Provider, env.Project)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager: %w", err)
+	}
+	defer sm.Close()
+
+	lister, err := secrets.AsLister(sm)
+	if err != nil {
+		return fmt.Errorf("cannot import from provider '%s': %w", env.Provider, err)
+	}
+	secretNames, err := lister.ListSecrets()
+	if err != nil {
+		return fmt.Errorf("failed to list secrets: %w", err)
+	}
+	logger.Debug("Listed provider secrets", "provider", env.Provider, "count", len(secretNames))
+
+	candidates := proposeImports(env, secretNames, importFilter)
+	if len(candidates) == 0 {
+		fmt.Println("No new secrets to import.")
+		return nil
+	}
+
+	accepted := candidates
+	if !importYes && !importDryRun {
+		accepted = confirmImports(in, candidates)
+	}
+
+	for _, c := range accepted {
+		fmt.Printf("+ %s -> %s\n", c.EnvVar, c.SecretKey)
+		if importDryRun {
+			continue
+		}
+		if err := config.AddOrUpdateEnvSecretKeyMapping(configPath, importEnv, c.EnvVar, c.SecretKey); err != nil {
+			return fmt.Errorf("failed to update configuration: %w", err)
+		}
+	}
+
+	if importDryRun {
+		fmt.Printf("Dry run: %d mapping(s) would be added to '%s'.\n", len(accepted), importEnv)
+		return nil
+	}
+
+	fmt.Printf("Added %d mapping(s) to '%s'.\n", len(accepted), importEnv)
+	return nil
+}
+
+// proposeImports proposes environment variable names for provider secrets
+// that are not mapped yet
+func proposeImports(env *config.Environment, secretNames []string, filter string) []importCandidate {
+	mappedKeys := map[string]string{}
+	usedNames := map[string]bool{}
+	for name, item := range env.Env {
+		usedNames[name] = true
+		if item.SecretKey != "" {
+			mappedKeys[item.SecretKey] = name
+		}
+	}
+
+	sorted := append([]string(nil), secretNames...)
+	sort.Strings(sorted)
+
+	var candidates []importCandidate
+	for _, secretKey := range sorted {
+		if filter != "" && !matchesImportFilter(filter, secretKey) {
+			continue
+		}
+		if envVar, ok := mappedKeys[secretKey]; ok {
+			fmt.Printf("= %s is already mapped to %s\n", secretKey, envVar)
+			continue
+		}
+		envVar := secrets.ProposeEnvVarName(secretKey)
+		if usedNames[envVar] {
+			fmt.Printf("! skipping %s: %s is already defined\n", secretKey, envVar)
+			continue
+		}
+		usedNames[envVar] = true
+		candidates = append(candidates, importCandidate{SecretKey: secretKey, EnvVar: envVar})
+	}
+	return candidates
+}
+
+// matchesImportFilter matches the filter against the full secret name and,
+// for path-like names, against the last path segment
+func matchesImportFilter(filter, secretKey string) bool {
+	if ok, _ := path.Match(filter, secretKey); ok {
+		return true
+	}
+	ok, _ := path.Match(filter, path.Base(secretKey))
+	return ok
+}
+
+// confirmImports asks for every candidate whether it should be imported
+func confirmImports(in io.Reader, candidates []importCandidate) []importCandidate {
+	reader := bufio.NewReader(in)
+	var accepted []importCandidate
+	for i, c := range candidates {
+		fmt.Printf("Import '%s' as %s? [Y/n/a/q] ", c.SecretKey, c.EnvVar)
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			fmt.Println()
+			return accepted
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "", "y", "yes":
+			accepted = append(accepted, c)
+		case "a", "all":
+			return append(accepted, candidates[i:]...)
+		case "q", "quit":
+			return accepted
+		}
+	}
+	return accepted
+}
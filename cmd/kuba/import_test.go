@@ -0,0 +1,126 @@
+package kuba
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetImportFlags(t *testing.T) {
+	t.Cleanup(func() {
+		importEnv = "default"
+		importConfigFile = ""
+		importFilter = ""
+		importYes = false
+		importDryRun = false
+	})
+}
+
+func writeImportConfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "kuba.yaml")
+	content := `default:
+  provider: aws
+  env:
+    # already mapped
+    EXISTING:
+      secret-key: existing
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func runImportQuietly(t *testing.T, input string) (string, error) {
+	return captureStdout(t, func() error { return runImport(strings.NewReader(input)) })
+}
+
+func loadImportedEnv(t *testing.T, configPath string) map[string]config.EnvItem {
+	cfg, err := config.LoadKubaConfig(configPath)
+	require.NoError(t, err)
+	env, err := cfg.GetEnvironment("default")
+	require.NoError(t, err)
+	return env.Env
+}
+
+func TestRunImportWithYesAndFilter(t *testing.T) {
+	resetImportFlags(t)
+	useFakeSecretStore(t, map[string]string{
+		"existing":     "x",
+		"app-db-pass":  "x",
+		"app.api-key":  "x",
+		"other-secret": "x",
+	})
+
+	importConfigFile = writeImportConfig(t)
+	importFilter = "app*"
+	importYes = true
+
+	_, err := runImportQuietly(t, "")
+	require.NoError(t, err)
+
+	env := loadImportedEnv(t, importConfigFile)
+	assert.Equal(t, "app-db-pass", env["APP_DB_PASS"].SecretKey)
+	assert.Equal(t, "app.api-key", env["APP_API_KEY"].SecretKey)
+	assert.Equal(t, "existing", env["EXISTING"].SecretKey)
+	assert.NotContains(t, env, "OTHER_SECRET")
+
+	raw, err := os.ReadFile(importConfigFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "# already mapped")
+}
+
+func TestRunImportAsksForConfirmation(t *testing.T) {
+	resetImportFlags(t)
+	useFakeSecretStore(t, map[string]string{
+		"alpha": "x",
+		"beta":  "x",
+		"gamma": "x",
+		"delta": "x",
+	})
+
+	importConfigFile = writeImportConfig(t)
+
+	// Sorted order: alpha, beta, delta, gamma
+	output, err := runImportQuietly(t, "y\nn\nq\n")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Import 'alpha' as ALPHA?")
+
+	env := loadImportedEnv(t, importConfigFile)
+	assert.Contains(t, env, "ALPHA")
+	assert.NotContains(t, env, "BETA")
+	assert.NotContains(t, env, "DELTA")
+	assert.NotContains(t, env, "GAMMA")
+}
+
+func TestRunImportDryRunWritesNothing(t *testing.T) {
+	resetImportFlags(t)
+	useFakeSecretStore(t, map[string]string{"new-secret": "x"})
+
+	importConfigFile = writeImportConfig(t)
+	importDryRun = true
+	before, err := os.ReadFile(importConfigFile)
+	require.NoError(t, err)
+
+	output, err := runImportQuietly(t, "")
+	require.NoError(t, err)
+	assert.Contains(t, output, "+ NEW_SECRET -> new-secret")
+
+	after, err := os.ReadFile(importConfigFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
+
+func TestProposeImportsSkipsTakenNames(t *testing.T) {
+	env := &config.Environment{Env: map[string]config.EnvItem{
+		"DB_PASS": {SecretKey: "legacy-db-pass"},
+	}}
+
+	candidates := proposeImports(env, []string{"db-pass", "legacy-db-pass", "api-key", "api.key"}, "")
+	assert.Equal(t, []importCandidate{
+		{SecretKey: "api-key", EnvVar: "API_KEY"},
+	}, candidates)
+}
@@ -1,10 +1,13 @@
 package kuba
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
+	"github.com/mistweaverco/kuba/internal/config"
 	"github.com/mistweaverco/kuba/internal/lib/fileutils"
 	"github.com/mistweaverco/kuba/internal/lib/log"
 	"github.com/mistweaverco/kuba/internal/templates"
@@ -14,8 +17,10 @@ import (
 var initCmd = &cobra.Command{
 	Use:   "init [template]",
 	Short: "Create a default configuration file",
-	Long:  "This command initializes a kuba.yaml configuration file, optionally using a named template.",
-	Args:  cobra.MaximumNArgs(1),
+	Long: `This command initializes a kuba.yaml configuration file, optionally using a named template.
+
+It also offers to add the personal override file ` + config.LocalOverrideFile + ` to .gitignore.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		logger := log.NewLogger()
 		logger.Debug("Initializing kuba configuration")
@@ -47,10 +52,52 @@ var initCmd = &cobra.Command{
 			return fmt.Errorf("failed to write %s: %w", target, err)
 		}
 		logger.Debug("Configuration file created successfully", "source", source)
-		return nil
+
+		return offerGitignoreLocalOverride(cmd.InOrStdin(), ".gitignore")
 	},
 }
 
+// offerGitignoreLocalOverride asks whether the local override file should be
+// added to the .gitignore file, unless it is already listed there
+func offerGitignoreLocalOverride(in io.Reader, gitignorePath string) error {
+	existing, err := os.ReadFile(gitignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", gitignorePath, err)
+	}
+	for _, line := range strings.Split(string(existing), "\n") {
+		line = strings.TrimSpace(line)
+		if line == config.LocalOverrideFile || line == "/"+config.LocalOverrideFile {
+			return nil
+		}
+	}
+
+	fmt.Printf("Add %s to %s? [Y/n] ", config.LocalOverrideFile, gitignorePath)
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Println()
+		return nil
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "", "y", "yes":
+	default:
+		return nil
+	}
+
+	entry := config.LocalOverrideFile + "\n"
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		entry = "\n" + entry
+	}
+	f, err := os.OpenFile(gitignorePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", gitignorePath, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(entry); err != nil {
+		return fmt.Errorf("failed to write %s: %w", gitignorePath, err)
+	}
+	return nil
+}
+
 func stringsOrNone(items []string) string {
 	if len(items) == 0 {
 		return "(none)"
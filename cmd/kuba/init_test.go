@@ -75,3 +75,38 @@ func TestInitWithMissingTemplateListsAvailable(t *testing.T) {
 		t.Fatalf("expected template list to contain alpha, got: %s", msg)
 	}
 }
+
+func TestOfferGitignoreLocalOverride(t *testing.T) {
+	dir := t.TempDir()
+	gitignore := filepath.Join(dir, ".gitignore")
+
+	if err := offerGitignoreLocalOverride(strings.NewReader("n\n"), gitignore); err != nil {
+		t.Fatalf("offer failed: %v", err)
+	}
+	if _, err := os.Stat(gitignore); !os.IsNotExist(err) {
+		t.Fatalf("expected no .gitignore after declining, got %v", err)
+	}
+
+	if err := os.WriteFile(gitignore, []byte("node_modules"), 0644); err != nil {
+		t.Fatalf("write .gitignore: %v", err)
+	}
+	if err := offerGitignoreLocalOverride(strings.NewReader("\n"), gitignore); err != nil {
+		t.Fatalf("offer failed: %v", err)
+	}
+	got, err := os.ReadFile(gitignore)
+	if err != nil {
+		t.Fatalf("read .gitignore: %v", err)
+	}
+	if string(got) != "node_modules\nkuba.local.yaml\n" {
+		t.Fatalf("unexpected .gitignore:\n%s", got)
+	}
+
+	// Already listed: nothing is asked or written
+	if err := offerGitignoreLocalOverride(strings.NewReader("y\n"), gitignore); err != nil {
+		t.Fatalf("offer failed: %v", err)
+	}
+	again, _ := os.ReadFile(gitignore)
+	if string(again) != string(got) {
+		t.Fatalf("expected .gitignore to be unchanged, got:\n%s", again)
+	}
+}
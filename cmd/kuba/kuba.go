@@ -21,6 +21,8 @@ var rootCmd = &cobra.Command{
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		// Initialize logging with debug mode
 		log.SetDebugMode(cfg.Flags.Debug)
+		config.SetLocalOverrides(!cfg.Flags.NoLocal)
+		config.SetOSEnvInterpolation(!cfg.Flags.NoOSEnv)
 	},
 	Run: func(cmd *cobra.Command, files []string) {
 		if cfg.Flags.Version {
@@ -41,6 +43,8 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&cfg.Flags.Debug, "debug", "d", false, "Enable debug mode for verbose logging")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Flags.NoLocal, "no-local", false, "Ignore the "+config.LocalOverrideFile+" override file")
+	rootCmd.PersistentFlags().BoolVar(&cfg.Flags.NoOSEnv, "no-os-env", false, "Do not read the OS environment when interpolating values")
 }
 
 // osExit is a variable to allow overriding in tests
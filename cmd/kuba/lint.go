@@ -0,0 +1,291 @@
+package kuba
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+	"github.com/mistweaverco/kuba/internal/lib/version"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintConfigFile string
+	lintOutput     string
+	lintRecursive  bool
+	lintJobs       int
+)
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Check a kuba.yaml configuration for problems",
+	Long: `Check a kuba.yaml configuration, including the files it includes and the
+local override file, and report all problems at once.
+
+Each problem is reported with its file, line and column. Unknown keys are
+reported with a suggestion when they look like a misspelled key.
+
+The output is human readable by default. Use --output sarif to produce a
+SARIF report, e.g. for code scanning annotations in CI.
+
+Use --recursive to lint every kuba.yaml in the current directory and below
+it, e.g. all services of a monorepo. Files ignored by git are skipped.
+
+The command exits with status 1 when errors are found.
+
+Examples:
+  kuba lint
+  kuba lint --config services/billing/kuba.yaml
+  kuba lint --output sarif > kuba.sarif
+  kuba lint --recursive`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		failed, err := runLint(cmd.OutOrStdout())
+		if err != nil {
+			return err
+		}
+		if failed {
+			osExit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	lintCmd.Flags().StringVarP(&lintConfigFile, "config", "c", "", "Path to kuba.yaml configuration file")
+	lintCmd.Flags().StringVarP(&lintOutput, "output", "o", "human", "Output format: human (default), sarif")
+	lintCmd.Flags().BoolVarP(&lintRecursive, "recursive", "r", false, "Lint all kuba.yaml files in the current directory and below")
+	lintCmd.Flags().IntVarP(&lintJobs, "jobs", "j", defaultRecursiveJobs, "Number of configurations to lint concurrently with --recursive")
+	rootCmd.AddCommand(lintCmd)
+}
+
+// runLint writes the diagnostics of the configuration to w and reports
+// whether any of them is an error
+func runLint(w io.Writer) (bool, error) {
+	if lintOutput != "human" && lintOutput != "sarif" {
+		return false, fmt.Errorf("invalid output format '%s': must be one of: human, sarif", lintOutput)
+	}
+
+	if lintRecursive {
+		return runLintRecursive(w)
+	}
+
+	cfgPath := lintConfigFile
+	if cfgPath == "" {
+		path, err := config.FindConfigFile()
+		if err != nil {
+			return false, fmt.Errorf("failed to find configuration file: %w", err)
+		}
+		cfgPath = path
+	}
+
+	diagnostics := lintConfig(cfgPath)
+	failed := hasLintErrors(diagnostics)
+
+	if lintOutput == "sarif" {
+		return failed, writeSARIF(w, diagnostics)
+	}
+	writeLintReport(w, cfgPath, diagnostics)
+	return failed, nil
+}
+
+// runLintRecursive lints all configurations below the working directory and
+// reports whether any of them has errors
+func runLintRecursive(w io.Writer) (bool, error) {
+	paths, err := findRecursiveConfigs(lintConfigFile)
+	if err != nil {
+		return false, err
+	}
+	results := forEachConfig(paths, lintJobs, lintConfig)
+
+	if lintOutput == "sarif" {
+		var diagnostics []config.Diagnostic
+		for _, d := range results {
+			diagnostics = append(diagnostics, d...)
+		}
+		return hasLintErrors(diagnostics), writeSARIF(w, diagnostics)
+	}
+
+	failed := 0
+	for i, diagnostics := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		writeLintReport(w, paths[i], diagnostics)
+		if hasLintErrors(diagnostics) {
+			failed++
+		}
+	}
+	fmt.Fprintf(w, "\n%d of %d configurations have errors\n", failed, len(paths))
+	return failed > 0, nil
+}
+
+// lintConfig loads the configuration and returns its diagnostics
+func lintConfig(cfgPath string) []config.Diagnostic {
+	logger := log.NewLogger()
+
+	var diagnostics []config.Diagnostic
+	if _, err := config.LoadKubaConfig(cfgPath); err != nil {
+		diagnostics = config.DiagnosticsFromError(err, cfgPath)
+	}
+	logger.Debug("Configuration linted", "path", cfgPath, "problems", len(diagnostics))
+	return diagnostics
+}
+
+// hasLintErrors reports whether any of the diagnostics is an error
+func hasLintErrors(diagnostics []config.Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Severity == config.SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// writeLintReport writes the diagnostics in a human readable format
+func writeLintReport(w io.Writer, cfgPath string, diagnostics []config.Diagnostic) {
+	if len(diagnostics) == 0 {
+		fmt.Fprintf(w, "%s: no problems found\n", cfgPath)
+		return
+	}
+	for _, d := range diagnostics {
+		pos := d.Position.String()
+		if pos == "" {
+			pos = cfgPath
+		}
+		fmt.Fprintf(w, "%s: %s: %s\n", pos, d.Severity, d.Text())
+	}
+	if len(diagnostics) == 1 {
+		fmt.Fprintln(w, "1 problem found")
+	} else {
+		fmt.Fprintf(w, "%d problems found\n", len(diagnostics))
+	}
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// writeSARIF writes the diagnostics as a SARIF 2.1.0 log
+func writeSARIF(w io.Writer, diagnostics []config.Diagnostic) error {
+	results := make([]sarifResult, 0, len(diagnostics))
+	ruleIDs := make(map[string]bool)
+	for _, d := range diagnostics {
+		ruleIDs[d.Rule] = true
+		result := sarifResult{
+			RuleID:  d.Rule,
+			Level:   string(d.Severity),
+			Message: sarifMessage{Text: d.Text()},
+		}
+		if d.Position.File != "" {
+			location := sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: sarifURI(d.Position.File)},
+				},
+			}
+			if d.Position.Line > 0 {
+				location.PhysicalLocation.Region = &sarifRegion{
+					StartLine:   d.Position.Line,
+					StartColumn: d.Position.Column,
+				}
+			}
+			result.Locations = []sarifLocation{location}
+		}
+		results = append(results, result)
+	}
+
+	rules := make([]sarifRule, 0, len(ruleIDs))
+	for id := range ruleIDs {
+		rules = append(rules, sarifRule{ID: id})
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	report := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "kuba",
+				Version:        version.VERSION,
+				InformationURI: "https://kuba.mwco.app",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// sarifURI returns the file as a slash separated path relative to the
+// working directory when possible, which is what code scanning expects
+func sarifURI(file string) string {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(abs)
+}
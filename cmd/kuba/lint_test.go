@@ -0,0 +1,102 @@
+package kuba
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetLintFlags(t *testing.T) {
+	t.Cleanup(func() {
+		lintConfigFile = ""
+		lintOutput = "human"
+	})
+}
+
+func writeLintConfig(t *testing.T, content string) string {
+	t.Setenv("KUBA_OVERRIDE_FILE", "")
+	path := filepath.Join(t.TempDir(), "kuba.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLintHuman(t *testing.T) {
+	resetLintFlags(t)
+	lintConfigFile = writeLintConfig(t, `default:
+  provider: gcp
+  project: p
+  env:
+    DB_PASSWORD:
+      secret_key: db-password
+`)
+
+	var out bytes.Buffer
+	failed, err := runLint(&out)
+	require.NoError(t, err)
+	assert.True(t, failed)
+	assert.Equal(t,
+		lintConfigFile+":5:5: error: environment 'default': env item 'DB_PASSWORD': either secret-key, secret-path, or value is required\n"+
+			lintConfigFile+":6:7: error: environment 'default': env item 'DB_PASSWORD': unknown key 'secret_key' (did you mean `secret-key`?)\n"+
+			"2 problems found\n",
+		out.String())
+}
+
+func TestLintNoProblems(t *testing.T) {
+	resetLintFlags(t)
+	lintConfigFile = writeLintConfig(t, `default:
+  provider: local
+  env:
+    LOG_LEVEL:
+      value: debug
+`)
+
+	var out bytes.Buffer
+	failed, err := runLint(&out)
+	require.NoError(t, err)
+	assert.False(t, failed)
+	assert.Equal(t, lintConfigFile+": no problems found\n", out.String())
+}
+
+func TestLintSARIF(t *testing.T) {
+	resetLintFlags(t)
+	lintConfigFile = writeLintConfig(t, `default:
+  provider: nope
+  env:
+    LOG_LEVEL:
+      value: debug
+`)
+	lintOutput = "sarif"
+
+	var out bytes.Buffer
+	failed, err := runLint(&out)
+	require.NoError(t, err)
+	assert.True(t, failed)
+
+	var report sarifLog
+	require.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	assert.Equal(t, "2.1.0", report.Version)
+	require.Len(t, report.Runs, 1)
+	assert.Equal(t, "kuba", report.Runs[0].Tool.Driver.Name)
+	assert.Equal(t, []sarifRule{{ID: "provider"}}, report.Runs[0].Tool.Driver.Rules)
+	require.Len(t, report.Runs[0].Results, 1)
+
+	result := report.Runs[0].Results[0]
+	assert.Equal(t, "provider", result.RuleID)
+	assert.Equal(t, "error", result.Level)
+	assert.Equal(t, "environment 'default': invalid provider 'nope'", result.Message.Text)
+	require.Len(t, result.Locations, 1)
+	assert.Equal(t, filepath.ToSlash(lintConfigFile), result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, &sarifRegion{StartLine: 1, StartColumn: 1}, result.Locations[0].PhysicalLocation.Region)
+}
+
+func TestLintInvalidOutput(t *testing.T) {
+	resetLintFlags(t)
+	lintOutput = "xml"
+	_, err := runLint(&bytes.Buffer{})
+	assert.ErrorContains(t, err, "invalid output format 'xml'")
+}
@@ -0,0 +1,389 @@
+package kuba
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pushFrom       string
+	pushSet        []string
+	pushEnv        string
+	pushConfigFile string
+	pushPrefix     string
+	pushOnConflict string
+	pushDryRun     bool
+)
+
+// Conflict policies for secrets that already exist in the provider
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictFail      = "fail"
+)
+
+// newSecretManager creates a secret manager for write operations.
+// It is a variable to allow overriding in tests.
+var newSecretManager = func(ctx context.Context, provider, project string) (secrets.SecretManager, error) {
+	return secrets.NewSecretManagerFactory().CreateSecretManager(ctx, provider, project)
+}
+
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Upload a dotenv file or literal values into a provider",
+	Long: `Upload a dotenv file or literal values into the provider of an environment.
+
+For each variable a secret is created (or updated) in the environment's
+provider and kuba.yaml is updated with a secret-key mapping for it, so
+plaintext values never end up in kuba.yaml. Variables the environment maps
+with their own provider or project are written there, and their other
+mapping settings are kept.
+
+The secret key is the lowercased variable name, prefixed with --prefix.
+If the environment already maps the variable to a secret-key, that key is
+reused instead.
+
+When a secret already exists, --on-conflict decides what happens:
+  fail       abort before anything is written (default)
+  skip       leave the existing secret untouched, only add the mapping
+  overwrite  write the new value as the latest version
+
+For GCP, new secrets use the default regions from the global configuration
+(see 'kuba config defaults') for user-managed replication.
+
+Examples:
+  kuba push --from .env --env staging
+  kuba push --from .env --env staging --prefix app- --dry-run
+  kuba push --set API_KEY=abc123 --env production --on-conflict overwrite`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPush()
+	},
+}
+
+func init() {
+	pushCmd.Flags().StringVar(&pushFrom, "from", "", "Dotenv file to read variables from")
+	pushCmd.Flags().StringArrayVar(&pushSet, "set", nil, "Literal KEY=VALUE to push (can be repeated)")
+	pushCmd.Flags().StringVarP(&pushEnv, "env", "e", "default", "Environment to push to")
+	pushCmd.Flags().StringVarP(&pushConfigFile, "config", "c", "", "Path to kuba.yaml configuration file")
+	pushCmd.Flags().StringVar(&pushPrefix, "prefix", "", "Prefix for generated secret keys")
+	pushCmd.Flags().StringVar(&pushOnConflict, "on-conflict", conflictFail, "What to do with existing secrets: fail, skip, overwrite")
+	pushCmd.Flags().BoolVar(&pushDryRun, "dry-run", false, "Show what would be pushed without writing anything")
+	rootCmd.AddCommand(pushCmd)
+}
+
+// pushAction is the planned change for a single variable
+type pushAction struct {
+	EnvVar    string
+	Provider  string
+	Project   string
+	SecretKey string
+	Value     string
+	Exists    bool
+}
+
+// writeManagers creates the secret managers that secrets are written with,
+// one per provider and project, and reuses them for all variables
+type writeManagers struct {
+	ctx      context.Context
+	managers map[string]secrets.SecretManager
+}
+
+func newWriteManagers(ctx context.Context) *writeManagers {
+	return &writeManagers{ctx: ctx, managers: map[string]secrets.SecretManager{}}
+}
+
+// get returns the manager for provider and project, which supports writing
+func (w *writeManagers) get(provider, project string) (secrets.SecretManager, error) {
+	key := provider + "|" + project
+	if sm, ok := w.managers[key]; ok {
+		return sm, nil
+	}
+	sm, err := newSecretManager(w.ctx, provider, project)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret manager: %w", err)
+	}
+	if _, err := secrets.AsMutator(sm); err != nil {
+		sm.Close()
+		return nil, fmt.Errorf("cannot write to provider '%s': %w", provider, err)
+	}
+	w.managers[key] = sm
+	return sm, nil
+}
+
+// mutator returns the mutator for provider and project
+func (w *writeManagers) mutator(provider, project string) (secrets.SecretMutator, error) {
+	sm, err := w.get(provider, project)
+	if err != nil {
+		return nil, err
+	}
+	return secrets.AsMutator(sm)
+}
+
+func (w *writeManagers) Close() {
+	for _, sm := range w.managers {
+		sm.Close()
+	}
+}
+
+// secretExists reports whether a secret exists in the provider. Errors other
+// than the provider reporting the secret as missing are returned, so that
+// they are not mistaken for a secret that can be created.
+func secretExists(sm secrets.SecretManager, project, secretKey string) (bool, error) {
+	_, err := sm.GetSecret(project, secretKey)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, secrets.ErrSecretNotFound) {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check whether secret '%s' exists: %w", secretKey, err)
+}
+
+func runPush() error {
+	logger := log.NewLogger()
+
+	if !isValidConflictPolicy(pushOnConflict) {
+		return fmt.Errorf("invalid conflict policy '%s': must be one of: fail, skip, overwrite", pushOnConflict)
+	}
+	if pushFrom == "" && len(pushSet) == 0 {
+		return fmt.Errorf("nothing to push: use --from and/or --set")
+	}
+
+	values := map[string]string{}
+	if pushFrom != "" {
+		fileValues, err := parseDotenvFile(pushFrom)
+		if err != nil {
+			return fmt.Errorf("failed to parse dotenv file: %w", err)
+		}
+		for k, v := range fileValues {
+			values[k] = v
+		}
+	}
+	for _, pair := range pushSet {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return fmt.Errorf("invalid --set value '%s': expected KEY=VALUE", pair)
+		}
+		values[key] = value
+	}
+
+	configPath := pushConfigFile
+	if configPath == "" {
+		found, err := config.FindConfigFile()
+		if err != nil {
+			return fmt.Errorf("failed to find configuration file: %w", err)
+		}
+		configPath = found
+	}
+
+	kubaConfig, err := config.LoadKubaConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	env, err := kubaConfig.GetEnvironment(pushEnv)
+	if err != nil {
+		return fmt.Errorf("failed to get environment '%s': %w", pushEnv, err)
+	}
+
+	managers := newWriteManagers(context.Background())
+	defer managers.Close()
+
+	actions, err := planPush(managers, env, values, pushPrefix)
+	if err != nil {
+		return err
+	}
+	logger.Debug("Planned push", "env", pushEnv, "provider", env.Provider, "count", len(actions))
+
+	if pushOnConflict == conflictFail {
+		var existing []string
+		for _, a := range actions {
+			if a.Exists {
+				existing = append(existing, a.SecretKey)
+			}
+		}
+		if len(existing) > 0 {
+			return fmt.Errorf("secrets already exist: %s (use --on-conflict skip or overwrite)", strings.Join(existing, ", "))
+		}
+	}
+
+	if !pushDryRun {
+		if err := applyGCPReplicationDefaults(managers, actions); err != nil {
+			return err
+		}
+	}
+
+	created, updated, skipped := 0, 0, 0
+	// Drop cached values of the written secrets, also when a later write fails
+	var changed []secrets.ChangedSecret
+	defer func() { secrets.InvalidateSecrets(kubaConfig, configPath, changed) }()
+	for _, a := range actions {
+		verb := "create"
+		if a.Exists {
+			verb = pushOnConflict
+		}
+		fmt.Printf("%s %s -> %s (%s)\n", pushActionSymbol(verb), a.EnvVar, a.SecretKey, verb)
+		if pushDryRun {
+			continue
+		}
+
+		mutator, err := managers.mutator(a.Provider, a.Project)
+		if err != nil {
+			return err
+		}
+		switch verb {
+		case "create":
+			if err := mutator.CreateSecret(a.SecretKey, a.Value, ""); err != nil {
+				return fmt.Errorf("failed to create secret '%s': %w", a.SecretKey, err)
+			}
+			created++
+		case conflictOverwrite:
+			if err := mutator.UpdateSecret(a.SecretKey, a.Value); err != nil {
+				return fmt.Errorf("failed to update secret '%s': %w", a.SecretKey, err)
+			}
+			updated++
+		default:
+			skipped++
+		}
+		if verb != conflictSkip {
+			changed = append(changed, secrets.ChangedSecret{Provider: a.Provider, Project: a.Project, SecretKey: a.SecretKey})
+		}
+
+		if err := writeEnvMapping(configPath, pushEnv, env, a); err != nil {
+			return fmt.Errorf("failed to update configuration: %w", err)
+		}
+	}
+
+	if pushDryRun {
+		fmt.Printf("Dry run: %d variable(s) would be pushed to %s.\n", len(actions), env.Provider)
+		return nil
+	}
+
+	fmt.Printf("Pushed to %s: %d created, %d updated, %d skipped.\n", env.Provider, created, updated, skipped)
+	return nil
+}
+
+// planPush determines the provider, project and secret key for every
+// variable and whether the secret already exists in the provider. Variables
+// mapped with their own provider or project are written there.
+func planPush(managers *writeManagers, env *config.Environment, values map[string]string, prefix string) ([]pushAction, error) {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	actions := make([]pushAction, 0, len(names))
+	for _, name := range names {
+		a := pushAction{
+			EnvVar:    name,
+			SecretKey: prefix + strings.ToLower(name),
+			Value:     values[name],
+		}
+		a.Provider, a.Project = writeTarget(env, name)
+		if item, ok := env.Env[name]; ok && item.SecretKey != "" {
+			a.SecretKey = item.SecretKey
+		}
+
+		sm, err := managers.get(a.Provider, a.Project)
+		if err != nil {
+			return nil, err
+		}
+		if a.Exists, err = secretExists(sm, a.Project, a.SecretKey); err != nil {
+			return nil, err
+		}
+		actions = append(actions, a)
+	}
+	return actions, nil
+}
+
+// writeTarget returns the provider and project a variable's secret is written
+// to: the ones of its existing mapping, or else the environment's
+func writeTarget(env *config.Environment, name string) (string, string) {
+	provider, project := env.Provider, env.Project
+	if item, ok := env.Env[name]; ok {
+		if item.Provider != "" {
+			provider = item.Provider
+		}
+		if item.Project != "" {
+			project = item.Project
+		}
+	}
+	return provider, project
+}
+
+// writeEnvMapping maps the action's variable to its secret in kuba.yaml. A
+// new mapping names the provider and project the secret was written to when
+// they are not the environment's, e.g. for items inherited from another
+// provider.
+func writeEnvMapping(configPath, envName string, env *config.Environment, a pushAction) error {
+	provider, project := "", ""
+	if a.Provider != env.Provider {
+		provider = a.Provider
+	}
+	if a.Project != env.Project {
+		project = a.Project
+	}
+	return config.AddOrUpdateEnvSecretKeyMappingWithStore(configPath, envName, a.EnvVar, a.SecretKey, provider, project)
+}
+
+// applyGCPReplicationDefaults configures user-managed replication for new GCP
+// secrets of the actions from the default regions in the global configuration
+func applyGCPReplicationDefaults(managers *writeManagers, actions []pushAction) error {
+	var globalConfig *config.GlobalConfig
+	applied := map[string]bool{}
+	for _, a := range actions {
+		key := a.Provider + "|" + a.Project
+		if applied[key] {
+			continue
+		}
+		applied[key] = true
+
+		sm, err := managers.get(a.Provider, a.Project)
+		if err != nil {
+			return err
+		}
+		gcpSM, ok := sm.(*secrets.GCPSecretManager)
+		if !ok {
+			continue
+		}
+
+		if globalConfig == nil {
+			if globalConfig, err = config.LoadGlobalConfig(); err != nil {
+				return fmt.Errorf("failed to load global config: %w", err)
+			}
+		}
+		if err := gcpSM.ApplyCreateDefaults(globalConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isValidConflictPolicy(policy string) bool {
+	switch policy {
+	case conflictFail, conflictSkip, conflictOverwrite:
+		return true
+	}
+	return false
+}
+
+func pushActionSymbol(verb string) string {
+	switch verb {
+	case "create":
+		return "+"
+	case conflictOverwrite:
+		return "~"
+	default:
+		return "="
+	}
+}
@@ -0,0 +1,334 @@
+package kuba
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecretStore is an in-memory SecretManager, SecretMutator and SecretLister
+type fakeSecretStore struct {
+	secrets map[string]string
+	// readErr is returned by GetSecret when set
+	readErr error
+}
+
+func (f *fakeSecretStore) GetSecret(projectID, secretID string) (string, error) {
+	if f.readErr != nil {
+		return "", f.readErr
+	}
+	value, ok := f.secrets[secretID]
+	if !ok {
+		return "", fmt.Errorf("%w: '%s'", secrets.ErrSecretNotFound, secretID)
+	}
+	return value, nil
+}
+
+func (f *fakeSecretStore) GetSecrets(projectID string, secretIDs []string) (map[string]string, error) {
+	out := map[string]string{}
+	for _, id := range secretIDs {
+		if value, ok := f.secrets[id]; ok {
+			out[id] = value
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeSecretStore) GetSecretsByPath(projectID, secretPath string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (f *fakeSecretStore) Close() error { return nil }
+
+func (f *fakeSecretStore) ListSecrets() ([]string, error) {
+	names := make([]string, 0, len(f.secrets))
+	for name := range f.secrets {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (f *fakeSecretStore) CreateSecret(secretName, secretValue, description string) error {
+	if _, ok := f.secrets[secretName]; ok {
+		return fmt.Errorf("secret '%s' already exists", secretName)
+	}
+	f.secrets[secretName] = secretValue
+	return nil
+}
+
+func (f *fakeSecretStore) UpdateSecret(secretName, secretValue string) error {
+	f.secrets[secretName] = secretValue
+	return nil
+}
+
+func (f *fakeSecretStore) DeleteSecret(secretName string, forceDelete bool) error {
+	delete(f.secrets, secretName)
+	return nil
+}
+
+func useFakeSecretStore(t *testing.T, initial map[string]string) *fakeSecretStore {
+	store := &fakeSecretStore{secrets: initial}
+	prev := newSecretManager
+	newSecretManager = func(ctx context.Context, provider, project string) (secrets.SecretManager, error) {
+		return store, nil
+	}
+	t.Cleanup(func() { newSecretManager = prev })
+	return store
+}
+
+func resetPushFlags(t *testing.T) {
+	t.Cleanup(func() {
+		pushFrom = ""
+		pushSet = nil
+		pushEnv = "default"
+		pushConfigFile = ""
+		pushPrefix = ""
+		pushOnConflict = conflictFail
+		pushDryRun = false
+	})
+}
+
+func writePushConfig(t *testing.T) string {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kuba.yaml")
+	content := `# staging secrets
+staging:
+  provider: gcp
+  project: "123"
+  env:
+    EXISTING:
+      secret-key: existing-secret
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// captureStdout runs fn with os.Stdout redirected and returns what it wrote
+func captureStdout(t *testing.T, fn func() error) (string, error) {
+	originalStdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+
+	// Drain the pipe while fn runs, so large outputs don't block it
+	output := make(chan []byte, 1)
+	go func() {
+		data, _ := io.ReadAll(r)
+		output <- data
+	}()
+
+	runErr := fn()
+
+	require.NoError(t, w.Close())
+	os.Stdout = originalStdout
+	data := <-output
+	require.NoError(t, r.Close())
+	return string(data), runErr
+}
+
+func runPushQuietly(t *testing.T) error {
+	_, err := captureStdout(t, runPush)
+	return err
+}
+
+func TestRunPushCreatesSecretsAndMappings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetPushFlags(t)
+	store := useFakeSecretStore(t, map[string]string{})
+
+	pushConfigFile = writePushConfig(t)
+	pushFrom = filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(pushFrom, []byte("DB_PASSWORD=s3cret\n"), 0644))
+	pushSet = []string{"API_KEY=abc=123"}
+	pushEnv = "staging"
+	pushPrefix = "app-"
+
+	require.NoError(t, runPushQuietly(t))
+
+	assert.Equal(t, map[string]string{
+		"app-db_password": "s3cret",
+		"app-api_key":     "abc=123",
+	}, store.secrets)
+
+	cfg, err := config.LoadKubaConfig(pushConfigFile)
+	require.NoError(t, err)
+	env, err := cfg.GetEnvironment("staging")
+	require.NoError(t, err)
+	assert.Equal(t, "app-db_password", env.Env["DB_PASSWORD"].SecretKey)
+	assert.Equal(t, "app-api_key", env.Env["API_KEY"].SecretKey)
+	assert.Equal(t, "existing-secret", env.Env["EXISTING"].SecretKey)
+
+	raw, err := os.ReadFile(pushConfigFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "# staging secrets")
+}
+
+func TestRunPushConflictPolicies(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetPushFlags(t)
+	store := useFakeSecretStore(t, map[string]string{"existing-secret": "old"})
+
+	pushConfigFile = writePushConfig(t)
+	pushSet = []string{"EXISTING=new"}
+	pushEnv = "staging"
+
+	// fail is the default and leaves the secret untouched
+	require.Error(t, runPushQuietly(t))
+	assert.Equal(t, "old", store.secrets["existing-secret"])
+
+	pushOnConflict = conflictSkip
+	require.NoError(t, runPushQuietly(t))
+	assert.Equal(t, "old", store.secrets["existing-secret"])
+
+	pushOnConflict = conflictOverwrite
+	require.NoError(t, runPushQuietly(t))
+	assert.Equal(t, "new", store.secrets["existing-secret"])
+}
+
+func TestRunPushDryRunWritesNothing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetPushFlags(t)
+	store := useFakeSecretStore(t, map[string]string{})
+
+	pushConfigFile = writePushConfig(t)
+	before, err := os.ReadFile(pushConfigFile)
+	require.NoError(t, err)
+
+	pushSet = []string{"NEW_VAR=value"}
+	pushEnv = "staging"
+	pushDryRun = true
+
+	require.NoError(t, runPushQuietly(t))
+	assert.Empty(t, store.secrets)
+
+	after, err := os.ReadFile(pushConfigFile)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
+
+func TestRunPushFailsWhenExistenceCannotBeChecked(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetPushFlags(t)
+	store := useFakeSecretStore(t, map[string]string{})
+	store.readErr = fmt.Errorf("permission denied")
+
+	pushConfigFile = writePushConfig(t)
+	pushSet = []string{"NEW_VAR=value"}
+	pushEnv = "staging"
+	pushOnConflict = conflictOverwrite
+
+	err := runPushQuietly(t)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "permission denied")
+	assert.Empty(t, store.secrets)
+}
+
+func TestRunPushKeepsItemProviderAndProject(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetPushFlags(t)
+	store := &fakeSecretStore{secrets: map[string]string{}}
+	var targets []string
+	prev := newSecretManager
+	newSecretManager = func(ctx context.Context, provider, project string) (secrets.SecretManager, error) {
+		targets = append(targets, provider+"/"+project)
+		return store, nil
+	}
+	t.Cleanup(func() { newSecretManager = prev })
+
+	pushConfigFile = filepath.Join(t.TempDir(), "kuba.yaml")
+	content := `staging:
+  provider: gcp
+  project: "123"
+  env:
+    DB_PASSWORD:
+      secret-key: old-key
+      provider: aws
+      project: shared
+      validate:
+        min-length: 8
+`
+	require.NoError(t, os.WriteFile(pushConfigFile, []byte(content), 0644))
+	pushSet = []string{"DB_PASSWORD=s3cret-value"}
+	pushEnv = "staging"
+
+	require.NoError(t, runPushQuietly(t))
+	assert.Equal(t, []string{"aws/shared"}, targets)
+	assert.Equal(t, "s3cret-value", store.secrets["old-key"])
+
+	cfg, err := config.LoadKubaConfig(pushConfigFile)
+	require.NoError(t, err)
+	env, err := cfg.GetEnvironment("staging")
+	require.NoError(t, err)
+	item := env.Env["DB_PASSWORD"]
+	assert.Equal(t, "old-key", item.SecretKey)
+	assert.Equal(t, "aws", item.Provider)
+	assert.Equal(t, "shared", item.Project)
+	require.NotNil(t, item.Validate)
+	assert.Equal(t, 8, item.Validate.MinLength)
+}
+
+func TestRunPushMapsInheritedItemsToTheirStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetPushFlags(t)
+	var targets []string
+	store := &fakeSecretStore{secrets: map[string]string{}}
+	prev := newSecretManager
+	newSecretManager = func(ctx context.Context, provider, project string) (secrets.SecretManager, error) {
+		targets = append(targets, provider+"/"+project)
+		return store, nil
+	}
+	t.Cleanup(func() { newSecretManager = prev })
+
+	pushConfigFile = filepath.Join(t.TempDir(), "kuba.yaml")
+	content := `base:
+  provider: aws
+  env:
+    SHARED_TOKEN:
+      secret-key: shared-token
+staging:
+  inherits: base
+  provider: gcp
+  project: "123"
+  env:
+    OWN:
+      secret-key: own
+`
+	require.NoError(t, os.WriteFile(pushConfigFile, []byte(content), 0644))
+	pushSet = []string{"SHARED_TOKEN=token"}
+	pushEnv = "staging"
+
+	require.NoError(t, runPushQuietly(t))
+	assert.Equal(t, []string{"aws/" + config.DefaultProject}, targets)
+
+	// The new mapping of staging must point to where the secret was written
+	cfg, err := config.LoadKubaConfig(pushConfigFile)
+	require.NoError(t, err)
+	env, err := cfg.GetEnvironment("staging")
+	require.NoError(t, err)
+	item := env.Env["SHARED_TOKEN"]
+	assert.Equal(t, "staging", item.DefinedIn)
+	assert.Equal(t, "shared-token", item.SecretKey)
+	assert.Equal(t, "aws", item.Provider)
+	assert.Equal(t, config.DefaultProject, item.Project)
+}
+
+func TestRunPushRejectsInvalidInput(t *testing.T) {
+	resetPushFlags(t)
+
+	assert.Error(t, runPush())
+
+	pushSet = []string{"NOVALUE"}
+	assert.Error(t, runPush())
+
+	pushSet = []string{"A=b"}
+	pushOnConflict = "merge"
+	assert.Error(t, runPush())
+}
@@ -0,0 +1,52 @@
+package kuba
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/mistweaverco/kuba/internal/config"
+)
+
+// defaultRecursiveJobs is the default number of configurations processed
+// concurrently with --recursive
+const defaultRecursiveJobs = 4
+
+// findRecursiveConfigs returns the kuba.yaml files in the working directory
+// and below it, for commands run with --recursive
+func findRecursiveConfigs(configFile string) ([]string, error) {
+	if configFile != "" {
+		return nil, fmt.Errorf("--recursive cannot be combined with --config")
+	}
+	files, err := config.FindConfigFiles(".")
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no %s files found", config.ConfigFileName)
+	}
+	return files, nil
+}
+
+// forEachConfig calls fn for every configuration file, with at most jobs
+// calls running at the same time, and returns the results in the order of
+// paths
+func forEachConfig[T any](paths []string, jobs int, fn func(cfgPath string) T) []T {
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	results := make([]T, len(paths))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, cfgPath := range paths {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = fn(cfgPath)
+		}()
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,149 @@
+package kuba
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeMonorepo creates a git repository with a kuba.yaml per service in a
+// temporary directory and changes into it
+func writeMonorepo(t *testing.T, files map[string]string) {
+	t.Helper()
+	t.Setenv("KUBA_OVERRIDE_FILE", "")
+	t.Setenv("KUBA_ENV", "")
+	root := t.TempDir()
+	files[".git/HEAD"] = "ref: refs/heads/main\n"
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	t.Chdir(root)
+}
+
+func TestForEachConfigKeepsOrderAndLimitsJobs(t *testing.T) {
+	var running, peak atomic.Int32
+	paths := []string{"a", "b", "c", "d", "e", "f"}
+	results := forEachConfig(paths, 2, func(cfgPath string) string {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return cfgPath + cfgPath
+	})
+
+	assert.Equal(t, []string{"aa", "bb", "cc", "dd", "ee", "ff"}, results)
+	assert.LessOrEqual(t, peak.Load(), int32(2))
+}
+
+func TestFindRecursiveConfigsRejectsConfigFlag(t *testing.T) {
+	_, err := findRecursiveConfigs("kuba.yaml")
+	require.EqualError(t, err, "--recursive cannot be combined with --config")
+}
+
+func TestLintRecursive(t *testing.T) {
+	resetLintFlags(t)
+	t.Cleanup(func() {
+		lintRecursive = false
+		lintJobs = defaultRecursiveJobs
+	})
+	writeMonorepo(t, map[string]string{
+		".gitignore": "vendor/\n",
+		"services/api/kuba.yaml": `default:
+  provider: local
+  env:
+    LOG_LEVEL:
+      value: debug
+`,
+		"services/web/kuba.yaml": `default:
+  provider: nope
+  env:
+    LOG_LEVEL:
+      value: debug
+`,
+		"vendor/lib/kuba.yaml": "not: [valid",
+	})
+	lintRecursive = true
+
+	var out bytes.Buffer
+	failed, err := runLint(&out)
+	require.NoError(t, err)
+	assert.True(t, failed)
+	assert.Equal(t,
+		filepath.FromSlash("services/api/kuba.yaml")+": no problems found\n"+
+			"\n"+
+			filepath.FromSlash("services/web/kuba.yaml")+":1:1: error: environment 'default': invalid provider 'nope'\n"+
+			"1 problem found\n"+
+			"\n"+
+			"1 of 2 configurations have errors\n",
+		out.String())
+}
+
+func TestShowRecursiveJSON(t *testing.T) {
+	t.Cleanup(func() {
+		showEnvironment = ""
+		showConfigFile = ""
+		showOutput = "dotenv"
+		showRecursive = false
+		showJobs = defaultRecursiveJobs
+	})
+	writeMonorepo(t, map[string]string{
+		"services/api/kuba.yaml": `default:
+  provider: local
+  env:
+    PORT:
+      value: "8080"
+`,
+		"services/web/kuba.yaml": `staging:
+  provider: local
+  env:
+    PORT:
+      value: "3000"
+`,
+	})
+	showRecursive = true
+	showOutput = "json"
+
+	originalStdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+
+	runErr := runShowCommand(nil, false)
+
+	require.NoError(t, w.Close())
+	os.Stdout = originalStdout
+	outputBytes, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	require.EqualError(t, runErr, "1 of 2 configurations failed")
+
+	var output map[string]showResult
+	require.NoError(t, json.Unmarshal(outputBytes, &output))
+	assert.Equal(t, showResult{Environment: "default", Values: map[string]string{"PORT": "8080"}}, output["services/api/kuba.yaml"])
+	assert.Equal(t, "default", output["services/web/kuba.yaml"].Environment)
+	assert.Contains(t, output["services/web/kuba.yaml"].Error, "failed to get environment 'default'")
+}
+
+func TestShowRecursiveRequiresJSON(t *testing.T) {
+	t.Cleanup(func() { showRecursive = false })
+	showRecursive = true
+
+	err := runShowCommand(nil, false)
+	require.EqualError(t, err, "--recursive requires --output json")
+}
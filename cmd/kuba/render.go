@@ -0,0 +1,348 @@
+package kuba
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/template"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renderEnv        string
+	renderConfigFile string
+	renderInput      string
+	renderOutput     string
+	renderTmpfs      bool
+	renderOffline    bool
+	renderConfirm    bool
+)
+
+// renderedFileEnvVar is set for the command run after rendering and points to
+// the rendered file
+const renderedFileEnvVar = "KUBA_RENDERED_FILE"
+
+var renderCmd = &cobra.Command{
+	Use:   "render -i <template> [-o <file>] [-- command [args...]]",
+	Short: "Render a config file from a template with resolved secrets",
+	Long: `Render a config file from a Go text/template with the resolved secrets of
+an environment, for software that can't read environment variables.
+
+Every environment variable of the environment is available by name, e.g.
+{{ .DB_PASSWORD }}. Unknown names render as an empty string. Helper functions:
+
+  default DEFAULT VALUE   VALUE, or DEFAULT if VALUE is empty
+  required MSG VALUE      VALUE, or fail with MSG if VALUE is empty
+  quote VALUE             VALUE as a double-quoted string
+  b64enc VALUE            base64-encoded VALUE
+  b64dec VALUE            base64-decoded VALUE
+  toJson VALUE            VALUE as JSON
+
+Without --output the result is written to stdout. Files are written atomically
+and are only readable by the owner (0600).
+
+With --tmpfs the file is written into a memory-backed directory
+($XDG_RUNTIME_DIR or /dev/shm) instead, so it never touches the disk. Its path
+is printed to stdout.
+
+When a command is given after --, it runs like 'kuba run' with the secrets
+and ` + renderedFileEnvVar + ` pointing to the rendered file, which is deleted
+once the command exits.
+
+Policy rules in kuba.yaml or the global configuration can require --confirm
+(or its alias --i-know) to render an environment. Environments whose policy
+requires redacted output can't be rendered.
+
+Examples:
+  kuba render --env prod -i app.conf.tmpl -o app.conf
+  kuba render --env prod -i .npmrc.tmpl > .npmrc
+  kuba render --env prod -i pgbouncer.ini.tmpl --tmpfs -- sh -c 'pgbouncer "$` + renderedFileEnvVar + `"'`,
+	Args: cobra.ArbitraryArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 && cmd.ArgsLenAtDash() != 0 {
+			return fmt.Errorf("unexpected arguments %v: put the command after --", args)
+		}
+		return runRender(args)
+	},
+}
+
+func init() {
+	renderCmd.Flags().StringVarP(&renderEnv, "env", "e", "default", "Environment to use")
+	renderCmd.Flags().StringVarP(&renderConfigFile, "config", "c", "", "Path to kuba.yaml configuration file")
+	renderCmd.Flags().StringVarP(&renderInput, "input", "i", "", "Template file to render")
+	renderCmd.Flags().StringVarP(&renderOutput, "output", "o", "", "File to write (default: stdout)")
+	renderCmd.Flags().BoolVar(&renderTmpfs, "tmpfs", false, "Write the file into a memory-backed directory")
+	renderCmd.Flags().BoolVar(&renderOffline, "offline", false, "Only use cached secrets, do not contact cloud providers")
+	renderCmd.Flags().BoolVar(&renderConfirm, "confirm", false, "Confirm rendering an environment whose policy requires it")
+	renderCmd.Flags().BoolVar(&renderConfirm, "i-know", false, "Alias of --confirm")
+
+	renderCmd.MarkFlagRequired("input")
+
+	rootCmd.AddCommand(renderCmd)
+}
+
+func runRender(command []string) error {
+	logger := log.NewLogger()
+
+	if len(command) > 0 && renderOutput == "" && !renderTmpfs {
+		return fmt.Errorf("a command requires --output or --tmpfs")
+	}
+
+	tmpl, err := os.ReadFile(renderInput)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+
+	configPath := renderConfigFile
+	if configPath == "" {
+		found, err := config.FindConfigFile()
+		if err != nil {
+			return fmt.Errorf("failed to find configuration file: %w", err)
+		}
+		configPath = found
+	}
+
+	kubaConfig, err := config.LoadKubaConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	env, err := kubaConfig.GetEnvironment(renderEnv)
+	if err != nil {
+		return fmt.Errorf("failed to get environment '%s': %w", renderEnv, err)
+	}
+	// Rendered files hold the values in plain text
+	policy := kubaConfig.PolicyFor(renderEnv)
+	if policy.RequireConfirm && !renderConfirm {
+		return fmt.Errorf("policy requires --confirm to render environment '%s'", renderEnv)
+	}
+	if policy.RequireSensitive {
+		return fmt.Errorf("policy denies rendering environment '%s' as its values must be redacted", renderEnv)
+	}
+
+	factory := secrets.NewSecretManagerFactory()
+	factory.Offline = renderOffline
+	defer factory.WaitForRefresh()
+
+	values, err := factory.GetSecretsForEnvironmentWithCache(context.Background(), env, configPath, renderEnv)
+	if err != nil {
+		return fmt.Errorf("failed to get secrets: %w", err)
+	}
+	logger.Debug("Secrets retrieved successfully", "count", len(values))
+
+	out, err := renderTemplate(filepath.Base(renderInput), string(tmpl), values)
+	if err != nil {
+		return err
+	}
+
+	if renderOutput == "" && !renderTmpfs {
+		_, err := os.Stdout.Write(out)
+		return err
+	}
+
+	path := renderOutput
+	cleanupDir := ""
+	if renderTmpfs {
+		dir, err := tmpfsDir()
+		if err != nil {
+			return err
+		}
+		workDir, err := os.MkdirTemp(dir, "kuba-render-")
+		if err != nil {
+			return fmt.Errorf("failed to create directory in tmpfs: %w", err)
+		}
+		cleanupDir = workDir
+		name := filepath.Base(renderOutput)
+		if renderOutput == "" {
+			name = strings.TrimSuffix(filepath.Base(renderInput), ".tmpl")
+		}
+		path = filepath.Join(workDir, name)
+	}
+
+	if err := writeFileAtomic(path, out, 0600); err != nil {
+		if cleanupDir != "" {
+			os.RemoveAll(cleanupDir)
+		}
+		return err
+	}
+	logger.Debug("Rendered template", "input", renderInput, "output", path)
+
+	if len(command) == 0 {
+		if renderTmpfs {
+			fmt.Println(path)
+		}
+		return nil
+	}
+
+	exitCode, err := runWithRenderedFile(command, values, path)
+	if cleanupDir != "" {
+		os.RemoveAll(cleanupDir)
+	} else {
+		os.Remove(path)
+	}
+	logger.Debug("Removed rendered file", "path", path)
+	if err != nil {
+		return err
+	}
+	if exitCode != 0 {
+		factory.WaitForRefresh()
+		osExit(exitCode)
+	}
+	return nil
+}
+
+// renderTemplate executes a text/template with the resolved secrets
+func renderTemplate(name, text string, values map[string]string) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=zero").Funcs(renderFuncs()).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderFuncs returns the helper functions available in templates
+func renderFuncs() template.FuncMap {
+	return template.FuncMap{
+		"default": func(def, value string) string {
+			if value == "" {
+				return def
+			}
+			return value
+		},
+		"required": func(msg, value string) (string, error) {
+			if value == "" {
+				return "", fmt.Errorf("%s", msg)
+			}
+			return value, nil
+		},
+		"quote": strconv.Quote,
+		"b64enc": func(value string) string {
+			return base64.StdEncoding.EncodeToString([]byte(value))
+		},
+		"b64dec": func(value string) (string, error) {
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return "", fmt.Errorf("b64dec: %w", err)
+			}
+			return string(decoded), nil
+		},
+		"toJson": func(value any) (string, error) {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return "", fmt.Errorf("toJson: %w", err)
+			}
+			return string(encoded), nil
+		},
+	}
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers never see a partially written file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", path, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// tmpfsDir returns a memory-backed directory to render files into
+func tmpfsDir() (string, error) {
+	candidates := []string{os.Getenv("XDG_RUNTIME_DIR")}
+	if runtime.GOOS == "linux" {
+		candidates = append(candidates, "/dev/shm")
+	}
+	for _, dir := range candidates {
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("no tmpfs available: set XDG_RUNTIME_DIR to a memory-backed directory")
+}
+
+// runWithRenderedFile runs command with the secrets merged into the OS
+// environment and returns its exit code. Interrupts are forwarded to the
+// command, so the rendered file can be removed after it exited.
+func runWithRenderedFile(command []string, values map[string]string, path string) (int, error) {
+	cmdEnv := os.Environ()
+	for key, value := range values {
+		cmdEnv = append(cmdEnv, fmt.Sprintf("%s=%s", key, value))
+	}
+	cmdEnv = append(cmdEnv, fmt.Sprintf("%s=%s", renderedFileEnvVar, path))
+
+	resolved, err := lookPathWithEnv(command[0], cmdEnv)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find command %q in PATH: %w", command[0], err)
+	}
+	cmd := exec.Command(resolved, command[1:]...)
+	cmd.Env = cmdEnv
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	if err := cmd.Start(); err != nil {
+		signal.Stop(signals)
+		return 0, fmt.Errorf("command failed: %w", err)
+	}
+	go func() {
+		for sig := range signals {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	err = cmd.Wait()
+	signal.Stop(signals)
+	close(signals)
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			// The exit code is -1 when the command was killed by a signal.
+			return max(exitErr.ExitCode(), 1), nil
+		}
+		return 0, fmt.Errorf("command failed: %w", err)
+	}
+	return 0, nil
+}
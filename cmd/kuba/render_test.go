@@ -0,0 +1,204 @@
+package kuba
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetRenderFlags(t *testing.T) {
+	t.Cleanup(func() {
+		renderEnv = "default"
+		renderConfigFile = ""
+		renderInput = ""
+		renderOutput = ""
+		renderTmpfs = false
+		renderOffline = false
+		renderConfirm = false
+	})
+}
+
+func TestRenderTemplateHelpers(t *testing.T) {
+	values := map[string]string{
+		"DB_PASSWORD": `pa"ss`,
+		"TOKEN":       "c2VjcmV0",
+		"EMPTY":       "",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+	}{
+		{"value", "{{ .DB_PASSWORD }}", `pa"ss`},
+		{"unknown key", "[{{ .MISSING }}]", "[]"},
+		{"default", `{{ .EMPTY | default "fallback" }}`, "fallback"},
+		{"default keeps value", `{{ .TOKEN | default "fallback" }}`, "c2VjcmV0"},
+		{"quote", "{{ quote .DB_PASSWORD }}", `"pa\"ss"`},
+		{"b64enc", "{{ b64enc .DB_PASSWORD }}", "cGEic3M="},
+		{"b64dec", "{{ b64dec .TOKEN }}", "secret"},
+		{"toJson", "{{ toJson .DB_PASSWORD }}", `"pa\"ss"`},
+		{"required", `{{ required "token missing" .TOKEN }}`, "c2VjcmV0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out, err := renderTemplate("test", test.template, values)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, string(out))
+		})
+	}
+}
+
+func TestRenderTemplateErrors(t *testing.T) {
+	values := map[string]string{"TOKEN": "not base64!"}
+
+	_, err := renderTemplate("test", `{{ required "API_KEY is required" .API_KEY }}`, values)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "API_KEY is required")
+
+	_, err = renderTemplate("test", "{{ b64dec .TOKEN }}", values)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "b64dec")
+
+	_, err = renderTemplate("test", "{{ .TOKEN", values)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse template")
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.conf")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+
+	require.NoError(t, writeFileAtomic(path, []byte("new"), 0600))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(content))
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files should be cleaned up")
+}
+
+func TestRunRenderWritesOutputFile(t *testing.T) {
+	resetRenderFlags(t)
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	configPath := filepath.Join(dir, "kuba.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`---
+default:
+  provider: local
+  env:
+    DB_USER:
+      value: "app"
+    DB_PASSWORD:
+      value: "s3cret"
+`), 0644))
+	input := filepath.Join(dir, "db.conf.tmpl")
+	require.NoError(t, os.WriteFile(input, []byte("user={{ .DB_USER }}\npassword={{ quote .DB_PASSWORD }}\n"), 0644))
+
+	renderConfigFile = configPath
+	renderInput = input
+	renderOutput = filepath.Join(dir, "db.conf")
+
+	require.NoError(t, runRender(nil))
+
+	content, err := os.ReadFile(renderOutput)
+	require.NoError(t, err)
+	assert.Equal(t, "user=app\npassword=\"s3cret\"\n", string(content))
+}
+
+func TestRunRenderEnforcesPolicy(t *testing.T) {
+	resetRenderFlags(t)
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	configPath := filepath.Join(dir, "kuba.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`---
+policy:
+  - env: prod*
+    require-confirm: true
+  - env: production
+    require-sensitive: true
+production:
+  provider: local
+  env:
+    DB_PASSWORD:
+      value: "s3cret"
+prod-eu:
+  provider: local
+  env:
+    DB_PASSWORD:
+      value: "s3cret"
+`), 0644))
+	input := filepath.Join(dir, "db.conf.tmpl")
+	require.NoError(t, os.WriteFile(input, []byte("password={{ .DB_PASSWORD }}\n"), 0644))
+
+	renderConfigFile = configPath
+	renderInput = input
+	renderOutput = filepath.Join(dir, "db.conf")
+
+	renderEnv = "prod-eu"
+	require.EqualError(t, runRender(nil), "policy requires --confirm to render environment 'prod-eu'")
+	renderConfirm = true
+	require.NoError(t, runRender(nil))
+
+	renderEnv = "production"
+	require.NoError(t, os.Remove(renderOutput))
+	require.EqualError(t, runRender(nil), "policy denies rendering environment 'production' as its values must be redacted")
+	assert.NoFileExists(t, renderOutput)
+}
+
+func TestRunRenderRemovesFileAfterCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	resetRenderFlags(t)
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	configPath := filepath.Join(dir, "kuba.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`---
+default:
+  provider: local
+  env:
+    API_KEY:
+      value: "abc"
+`), 0644))
+	input := filepath.Join(dir, "app.conf.tmpl")
+	require.NoError(t, os.WriteFile(input, []byte("key={{ .API_KEY }}"), 0644))
+	copied := filepath.Join(dir, "seen.conf")
+
+	renderConfigFile = configPath
+	renderInput = input
+	renderOutput = filepath.Join(dir, "app.conf")
+
+	require.NoError(t, runRender([]string{"sh", "-c", `cp "$KUBA_RENDERED_FILE" ` + copied}))
+
+	content, err := os.ReadFile(copied)
+	require.NoError(t, err)
+	assert.Equal(t, "key=abc", string(content))
+	assert.NoFileExists(t, renderOutput)
+}
+
+func TestRunRenderCommandRequiresFile(t *testing.T) {
+	resetRenderFlags(t)
+	renderInput = "app.conf.tmpl"
+
+	err := runRender([]string{"true"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--output or --tmpfs")
+}
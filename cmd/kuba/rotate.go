@@ -0,0 +1,239 @@
+package kuba
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/cache"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rotateEnv             string
+	rotateConfigFile      string
+	rotateGenerator       string
+	rotateLength          int
+	rotateCharset         string
+	rotateCommand         string
+	rotateDisablePrevious bool
+	rotateGracePeriod     string
+)
+
+// Named character sets for the random generator
+var rotateCharsets = map[string]string{
+	"alphanumeric": "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"hex":          "0123456789abcdef",
+	"ascii":        "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789!#$%&()*+,-./:;<=>?@[]^_{|}~",
+}
+
+var rotateCmd = &cobra.Command{
+	Use:   "rotate <ENV_VAR>",
+	Short: "Rotate a secret by writing a newly generated version",
+	Long: `Rotate the secret behind an environment variable.
+
+A new value is generated and written as a new version of the secret using the
+provider's native versioning (GCP secret versions, AWS PutSecretValue, Azure
+Key Vault versions, OpenBao KV v2). The previous and new version IDs are
+printed and cached values of the secret are cleared.
+
+Generators:
+  random   random string of --length characters from --charset (default)
+           charsets: alphanumeric, hex, ascii
+  uuid     random UUID (v4)
+  command  trimmed output of --command, run through the shell
+
+With --disable-previous the previous version is disabled after the grace
+period, so running services have time to pick up the new value. On AWS the
+AWSPREVIOUS label is removed instead since versions can't be disabled.
+
+Examples:
+  kuba rotate DB_PASSWORD --env prod
+  kuba rotate API_TOKEN --env prod --generator uuid
+  kuba rotate DB_PASSWORD --env prod --length 48 --charset ascii --disable-previous --grace-period 10m
+  kuba rotate SIGNING_KEY --env prod --generator command --command 'openssl rand -base64 32'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRotate(args[0])
+	},
+}
+
+func init() {
+	rotateCmd.Flags().StringVarP(&rotateEnv, "env", "e", "default", "Environment of the secret")
+	rotateCmd.Flags().StringVarP(&rotateConfigFile, "config", "c", "", "Path to kuba.yaml configuration file")
+	rotateCmd.Flags().StringVar(&rotateGenerator, "generator", "random", "Value generator: random, uuid, command")
+	rotateCmd.Flags().IntVar(&rotateLength, "length", 32, "Length of random values")
+	rotateCmd.Flags().StringVar(&rotateCharset, "charset", "alphanumeric", "Character set of random values: alphanumeric, hex, ascii")
+	rotateCmd.Flags().StringVar(&rotateCommand, "command", "", "Command printing the new value (for --generator command)")
+	rotateCmd.Flags().BoolVar(&rotateDisablePrevious, "disable-previous", false, "Disable the previous version after the grace period")
+	rotateCmd.Flags().StringVar(&rotateGracePeriod, "grace-period", "0s", "Time to wait before disabling the previous version (e.g., 10m, 1h, 1d)")
+	rootCmd.AddCommand(rotateCmd)
+}
+
+func runRotate(envVar string) error {
+	logger := log.NewLogger()
+
+	gracePeriod, _, err := cache.ParseDuration(rotateGracePeriod)
+	if err != nil {
+		return fmt.Errorf("invalid grace period: %w", err)
+	}
+
+	configPath := rotateConfigFile
+	if configPath == "" {
+		found, err := config.FindConfigFile()
+		if err != nil {
+			return fmt.Errorf("failed to find configuration file: %w", err)
+		}
+		configPath = found
+	}
+
+	kubaConfig, err := config.LoadKubaConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+	env, err := kubaConfig.GetEnvironment(rotateEnv)
+	if err != nil {
+		return fmt.Errorf("failed to get environment '%s': %w", rotateEnv, err)
+	}
+	item, ok := env.Env[envVar]
+	if !ok {
+		return fmt.Errorf("environment variable '%s' is not defined in '%s'", envVar, rotateEnv)
+	}
+	if item.SecretKey == "" {
+		return fmt.Errorf("environment variable '%s' is not mapped to a secret-key", envVar)
+	}
+
+	provider, project := item.Provider, item.Project
+	if provider == "" {
+		provider = env.Provider
+	}
+	if project == "" {
+		project = env.Project
+	}
+	project = secrets.NormalizeProject(provider, project)
+
+	value, err := generateSecretValue(rotateGenerator)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	sm, err := newSecretManager(ctx, provider, project)
+	if err != nil {
+		return fmt.Errorf("failed to create secret manager: %w", err)
+	}
+	defer sm.Close()
+
+	versioner, err := secrets.AsVersioner(sm)
+	if err != nil {
+		return fmt.Errorf("cannot rotate secrets of provider '%s': %w", provider, err)
+	}
+
+	previous, err := versioner.CurrentVersion(item.SecretKey)
+	if err != nil {
+		return err
+	}
+	current, err := versioner.AddVersion(item.SecretKey, value)
+	if err != nil {
+		return err
+	}
+	logger.Debug("Rotated secret", "secret", item.SecretKey, "previous", previous, "current", current)
+
+	fmt.Printf("Rotated %s (%s): version %s -> %s\n", envVar, item.SecretKey, previous, current)
+
+	secrets.InvalidateSecrets(kubaConfig, configPath, []secrets.ChangedSecret{{Provider: provider, Project: project, SecretKey: item.SecretKey}})
+
+	if !rotateDisablePrevious {
+		return nil
+	}
+
+	if gracePeriod > 0 {
+		fmt.Printf("Waiting %s before disabling version %s...\n", gracePeriod, previous)
+		select {
+		case <-time.After(gracePeriod):
+		case <-ctx.Done():
+			return fmt.Errorf("interrupted: version %s of %s was not disabled", previous, item.SecretKey)
+		}
+	}
+
+	if err := versioner.DisableVersion(item.SecretKey, previous); err != nil {
+		return err
+	}
+	fmt.Printf("Disabled version %s of %s\n", previous, item.SecretKey)
+	return nil
+}
+
+// generateSecretValue generates a new secret value with the given generator
+func generateSecretValue(generator string) (string, error) {
+	switch generator {
+	case "random":
+		charset := rotateCharsets[rotateCharset]
+		if charset == "" {
+			return "", fmt.Errorf("invalid charset '%s': must be one of: alphanumeric, hex, ascii", rotateCharset)
+		}
+		if rotateLength <= 0 {
+			return "", fmt.Errorf("length must be greater than zero")
+		}
+		return randomString(rotateLength, charset)
+	case "uuid":
+		return uuid.NewString(), nil
+	case "command":
+		if strings.TrimSpace(rotateCommand) == "" {
+			return "", fmt.Errorf("--command is required for the command generator")
+		}
+		return runGeneratorCommand(rotateCommand)
+	default:
+		return "", fmt.Errorf("invalid generator '%s': must be one of: random, uuid, command", generator)
+	}
+}
+
+// randomString returns a cryptographically random string of length
+// characters from charset
+func randomString(length int, charset string) (string, error) {
+	max := big.NewInt(int64(len(charset)))
+	var b strings.Builder
+	b.Grow(length)
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate random value: %w", err)
+		}
+		b.WriteByte(charset[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// runGeneratorCommand runs command through the shell and returns its output
+// without the trailing newline
+func runGeneratorCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("generator command failed: %w", err)
+	}
+	value := strings.TrimRight(string(out), "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("generator command produced no output")
+	}
+	return value, nil
+}
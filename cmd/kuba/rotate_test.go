@@ -0,0 +1,250 @@
+package kuba
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/agent"
+	"github.com/mistweaverco/kuba/internal/lib/cache"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeVersionedStore is a fakeSecretStore that keeps every version
+type fakeVersionedStore struct {
+	fakeSecretStore
+	versions map[string][]string
+	disabled map[string]bool
+}
+
+func (f *fakeVersionedStore) CurrentVersion(secretName string) (string, error) {
+	versions := f.versions[secretName]
+	if len(versions) == 0 {
+		return "", fmt.Errorf("%w: '%s'", secrets.ErrSecretNotFound, secretName)
+	}
+	return strconv.Itoa(len(versions)), nil
+}
+
+func (f *fakeVersionedStore) AddVersion(secretName, secretValue string) (string, error) {
+	f.versions[secretName] = append(f.versions[secretName], secretValue)
+	f.secrets[secretName] = secretValue
+	return strconv.Itoa(len(f.versions[secretName])), nil
+}
+
+func (f *fakeVersionedStore) DisableVersion(secretName, versionID string) error {
+	f.disabled[secretName+"@"+versionID] = true
+	return nil
+}
+
+func useFakeVersionedStore(t *testing.T) *fakeVersionedStore {
+	store := &fakeVersionedStore{
+		fakeSecretStore: fakeSecretStore{secrets: map[string]string{"db-password": "old"}},
+		versions:        map[string][]string{"db-password": {"old"}},
+		disabled:        map[string]bool{},
+	}
+	prev := newSecretManager
+	newSecretManager = func(ctx context.Context, provider, project string) (secrets.SecretManager, error) {
+		return store, nil
+	}
+	t.Cleanup(func() { newSecretManager = prev })
+	return store
+}
+
+func resetRotateFlags(t *testing.T) {
+	t.Cleanup(func() {
+		rotateEnv = "default"
+		rotateConfigFile = ""
+		rotateGenerator = "random"
+		rotateLength = 32
+		rotateCharset = "alphanumeric"
+		rotateCommand = ""
+		rotateDisablePrevious = false
+		rotateGracePeriod = "0s"
+	})
+}
+
+func writeRotateConfig(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "kuba.yaml")
+	content := `prod:
+  provider: gcp
+  project: "123"
+  env:
+    DB_PASSWORD:
+      secret-key: db-password
+    PLAIN:
+      value: plain
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func runRotateQuietly(t *testing.T, envVar string) (string, error) {
+	return captureStdout(t, func() error { return runRotate(envVar) })
+}
+
+func TestRunRotateAddsVersionAndDisablesPrevious(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("KUBA_AGENT_SOCKET", filepath.Join(t.TempDir(), "agent.sock"))
+	resetRotateFlags(t)
+	store := useFakeVersionedStore(t)
+
+	rotateConfigFile = writeRotateConfig(t)
+	rotateEnv = "prod"
+	rotateLength = 40
+	rotateCharset = "hex"
+	rotateDisablePrevious = true
+
+	output, err := runRotateQuietly(t, "DB_PASSWORD")
+	require.NoError(t, err)
+	assert.Contains(t, output, "Rotated DB_PASSWORD (db-password): version 1 -> 2")
+
+	newValue := store.secrets["db-password"]
+	assert.Len(t, newValue, 40)
+	assert.Regexp(t, "^[0-9a-f]+$", newValue)
+	assert.True(t, store.disabled["db-password@1"])
+}
+
+func TestRunRotateClearsCachedValues(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("KUBA_AGENT_SOCKET", filepath.Join(t.TempDir(), "agent.sock"))
+	resetRotateFlags(t)
+	useFakeVersionedStore(t)
+
+	globalConfig := config.DefaultGlobalConfig()
+	globalConfig.Cache.Enabled = true
+	globalConfig.Cache.Backend = cache.BackendFile
+	require.NoError(t, config.SaveGlobalConfig(globalConfig))
+
+	rotateConfigFile = writeRotateConfig(t)
+	rotateEnv = "prod"
+	rotateGenerator = "uuid"
+
+	manager, err := cache.NewManager(&cache.GlobalConfig{Cache: globalConfig.Cache})
+	require.NoError(t, err)
+	require.NoError(t, manager.Set(rotateConfigFile, "prod", "DB_PASSWORD", "old", time.Hour))
+	require.NoError(t, manager.Set(rotateConfigFile, "prod", "PLAIN", "plain", time.Hour))
+	require.NoError(t, manager.Close())
+
+	_, err = runRotateQuietly(t, "DB_PASSWORD")
+	require.NoError(t, err)
+
+	manager, err = cache.NewManager(&cache.GlobalConfig{Cache: globalConfig.Cache})
+	require.NoError(t, err)
+	defer manager.Close()
+	_, found, err := manager.Get(rotateConfigFile, "prod", "DB_PASSWORD")
+	require.NoError(t, err)
+	assert.False(t, found)
+	_, found, err = manager.Get(rotateConfigFile, "prod", "PLAIN")
+	require.NoError(t, err)
+	assert.True(t, found)
+}
+
+// startTestAgent runs a kuba agent serving secrets from reader on the socket
+// the commands dial
+func startTestAgent(t *testing.T, reader agent.SecretReader) *agent.Client {
+	t.Helper()
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("agent peer credentials are not supported on " + runtime.GOOS)
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	t.Setenv("KUBA_AGENT_SOCKET", socketPath)
+	newManager := func(ctx context.Context, provider, project string) (agent.SecretReader, error) {
+		return reader, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server := agent.NewServer(socketPath, newManager, 0, time.Hour)
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	var err error
+	for i := 0; i < 100; i++ {
+		var client *agent.Client
+		if client, err = agent.Dial(); err == nil {
+			return client
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("agent did not start: %v", err)
+	return nil
+}
+
+func TestRunRotateClearsAgentCacheForProvidersWithoutProjects(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetRotateFlags(t)
+	store := useFakeVersionedStore(t)
+	client := startTestAgent(t, store)
+
+	path := filepath.Join(t.TempDir(), "kuba.yaml")
+	content := `prod:
+  provider: aws
+  env:
+    DB_PASSWORD:
+      secret-key: db-password
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	rotateConfigFile = path
+	rotateEnv = "prod"
+
+	// Lookups without a project are cached by the agent under the default
+	// project key
+	values, err := client.GetSecrets("aws", secrets.DefaultProject, []string{"db-password"})
+	require.NoError(t, err)
+	require.Equal(t, "old", values["db-password"])
+
+	_, err = runRotateQuietly(t, "DB_PASSWORD")
+	require.NoError(t, err)
+
+	values, err = client.GetSecrets("aws", secrets.DefaultProject, []string{"db-password"})
+	require.NoError(t, err)
+	assert.Equal(t, store.secrets["db-password"], values["db-password"])
+	assert.NotEqual(t, "old", values["db-password"])
+}
+
+func TestRunRotateRejectsUnmappedVariables(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetRotateFlags(t)
+	useFakeVersionedStore(t)
+
+	rotateConfigFile = writeRotateConfig(t)
+	rotateEnv = "prod"
+
+	_, err := runRotateQuietly(t, "PLAIN")
+	assert.Error(t, err)
+	_, err = runRotateQuietly(t, "MISSING")
+	assert.Error(t, err)
+}
+
+func TestGenerateSecretValue(t *testing.T) {
+	resetRotateFlags(t)
+
+	value, err := generateSecretValue("uuid")
+	require.NoError(t, err)
+	assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$", value)
+
+	rotateCommand = "echo generated"
+	value, err = generateSecretValue("command")
+	require.NoError(t, err)
+	assert.Equal(t, "generated", value)
+
+	rotateCharset = "emoji"
+	_, err = generateSecretValue("random")
+	assert.Error(t, err)
+
+	_, err = generateSecretValue("magic")
+	assert.Error(t, err)
+}
@@ -20,6 +20,7 @@ var (
 	configFile  string
 	contain     bool
 	commandFlag string
+	runOffline  bool
 )
 
 var runCmd = &cobra.Command{
@@ -36,12 +37,16 @@ This command will:
 By default, secrets are merged with the current OS environment. Use --contain to only use
 environment variables from kuba.yaml.
 
+Use --offline to only use secrets from the cache, e.g. when the cloud providers
+cannot be reached.
+
 Example:
   kuba run -- node server.js
   kuba run --env production -- python app.py
   kuba run --config ./config/kuba.yaml -- docker-compose up
   kuba run --contain -- node server.js
-  kuba run --command 'echo "$SOME_SECRET"'`,
+  kuba run --command 'echo "$SOME_SECRET"'
+  kuba run --offline -- node server.js`,
 	Args: func(cmd *cobra.Command, args []string) error {
 		// If --command is provided, args are optional
 		if cmd.Flags().Changed("command") {
@@ -59,10 +64,11 @@ Example:
 }
 
 func init() {
-	runCmd.Flags().StringVarP(&environment, "env", "e", "default", "Environment to use (default: default)")
+	runCmd.Flags().StringVarP(&environment, "env", "e", "", "Environment to use (default: $KUBA_ENV, a matching select rule, or default)")
 	runCmd.Flags().StringVarP(&configFile, "config", "c", "", "Path to kuba.yaml configuration file")
 	runCmd.Flags().BoolVar(&contain, "contain", false, "Only use environment variables from kuba.yaml, do not merge with OS environment")
 	runCmd.Flags().StringVar(&commandFlag, "command", "", "Run an arbitrary command string in a shell with access to injected environment variables")
+	runCmd.Flags().BoolVar(&runOffline, "offline", false, "Only use cached secrets, do not contact cloud providers")
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -91,26 +97,34 @@ func runCommand(args []string) error {
 	logger.Debug("Configuration loaded successfully")
 
 	// Get environment configuration
-	logger.Debug("Getting environment configuration", "environment", environment)
-	env, err := kubaConfig.GetEnvironment(environment)
+	envName := kubaConfig.SelectEnvironment(environment, configFile).Name
+	logger.Debug("Getting environment configuration", "environment", envName)
+	env, err := kubaConfig.GetEnvironment(envName)
 	if err != nil {
-		return fmt.Errorf("failed to get environment '%s': %w", environment, err)
+		return fmt.Errorf("failed to get environment '%s': %w", envName, err)
 	}
-	logger.Debug("Environment configuration retrieved", "environment", environment, "provider", env.Provider, "env_count", len(env.Env))
+	logger.Debug("Environment configuration retrieved", "environment", envName, "provider", env.Provider, "env_count", len(env.Env))
 
 	// Create secrets manager factory
 	logger.Debug("Creating secrets manager factory")
 	factory := secrets.NewSecretManagerFactory()
+	factory.Offline = runOffline
 
 	// Get secrets for the environment
 	ctx := context.Background()
 	logger.Debug("Fetching secrets from cloud providers")
-	secrets, err := factory.GetSecretsForEnvironmentWithCache(ctx, env, configFile, environment)
+	resolution, err := factory.ResolveEnvironment(ctx, env, configFile, envName)
 	if err != nil {
 		return fmt.Errorf("failed to get secrets: %w", err)
 	}
+	secrets := resolution.Values
 	logger.Debug("Secrets retrieved successfully", "count", len(secrets))
 
+	if err := checkResolvedValues(os.Stderr, env, secrets, resolution.Expanded()); err != nil {
+		factory.WaitForRefresh()
+		return err
+	}
+
 	// Prepare environment variables (used for both execution modes)
 	var cmdEnv []string
 	if contain {
@@ -170,7 +184,12 @@ func runCommand(args []string) error {
 
 	// Execute command
 	logger.Debug("Executing command")
-	if err := cmd.Run(); err != nil {
+	err = cmd.Run()
+
+	// Let background cache refreshes finish so the next run sees fresh values
+	factory.WaitForRefresh()
+
+	if err != nil {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			logger.Debug("Command exited with non-zero status", "exit_code", exitErr.ExitCode())
 			os.Exit(exitErr.ExitCode())
@@ -0,0 +1,54 @@
+package kuba
+
+import (
+	"io"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var schemaGlobal bool
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Work with the JSON schemas of the configuration files",
+	Long: `Work with the JSON schemas of kuba.yaml and the global configuration file.
+
+The schemas are generated from the configuration types and the provider
+registry, so they always match what this binary accepts.`,
+	Args: cobra.NoArgs,
+}
+
+var schemaPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Print the JSON schema of kuba.yaml",
+	Long: `Print the JSON schema of kuba.yaml, or of the global configuration
+file with --global.
+
+Examples:
+  kuba schema print > ` + config.SchemaFileName + `
+  kuba schema print --global > ` + config.GlobalSchemaFileName,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSchemaPrint(cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	schemaPrintCmd.Flags().BoolVar(&schemaGlobal, "global", false, "Print the schema of the global configuration file")
+	schemaCmd.AddCommand(schemaPrintCmd)
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func runSchemaPrint(w io.Writer) error {
+	generate := config.GenerateSchema
+	if schemaGlobal {
+		generate = config.GenerateGlobalSchema
+	}
+	schema, err := generate()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(schema)
+	return err
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
@@ -19,6 +22,13 @@ var (
 	showConfigFile  string
 	showSensitive   bool
 	showOutput      string
+	showOffline     bool
+	showSecretName  string
+	showNamespace   string
+	showExplain     bool
+	showRecursive   bool
+	showJobs        int
+	showConfirm     bool
 )
 
 const (
@@ -37,12 +47,40 @@ You can filter the output by providing one or more pattern arguments. Patterns
 are case-insensitive and support '*' as a wildcard character.
 
 Examples:
-  kuba show                    # Show all variables from default environment
+  kuba show                    # Show all variables from the selected environment
   kuba show db_password        # Show only DB_PASSWORD
   kuba show --env staging db*  # Show all variables starting with DB from staging
   kuba show db*p*              # Show variables matching DB*P* pattern
   kuba show db_* gcp_*         # Show variables starting with DB_ or GCP_
-  kuba show --sensitive        # Show all variables with redacted values`,
+  kuba show --sensitive        # Show all variables with redacted values
+  kuba show --offline          # Show variables using cached secrets only
+  kuba show --explain db_*     # Show where DB_* variables come from
+  kuba show --explain -o json  # Same, as JSON
+  kuba show -o yaml            # Show variables as YAML
+  kuba show -o k8s --name app --namespace prod | kubectl apply -f -
+  kuba show -o github >> "$GITHUB_ENV"
+  kuba show --recursive -o json  # Show all kuba.yaml files below the current directory
+
+Output formats:
+  dotenv      KEY=value, quoted and escaped when needed (default)
+  shell       export KEY=value for POSIX shells
+  json        JSON object
+  yaml        YAML mapping
+  docker      docker --env-file, one line per variable without quotes
+  systemd     systemd EnvironmentFile
+  k8s         Kubernetes Secret manifest (see --name and --namespace)
+  github      GitHub Actions $GITHUB_ENV, multiline values as heredocs
+  fish        set -gx KEY value for fish
+  powershell  $env:KEY = 'value' for PowerShell
+  tfvars      Terraform .tfvars
+
+With --recursive, every kuba.yaml in the current directory and below it is
+shown, e.g. all services of a monorepo. Files ignored by git are skipped.
+This requires --output json, which then maps each file to its environment
+and values, or to the error it failed with.
+
+Policy rules in kuba.yaml or the global configuration can require --confirm
+(or its alias --i-know) or --sensitive to show an environment.`,
 	Args: cobra.ArbitraryArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		envFlag := cmd.Flags().Lookup("env")
@@ -61,10 +99,18 @@ Examples:
 }
 
 func init() {
-	showCmd.Flags().StringVarP(&showEnvironment, "env", "e", "default", "Environment to use (default: default). Provide without value to list available environments.")
+	showCmd.Flags().StringVarP(&showEnvironment, "env", "e", "", "Environment to use (default: $KUBA_ENV, a matching select rule, or default). Provide without value to list available environments.")
 	showCmd.Flags().StringVarP(&showConfigFile, "config", "c", "", "Path to kuba.yaml configuration file")
 	showCmd.Flags().BoolVar(&showSensitive, "sensitive", false, "Redact sensitive values")
-	showCmd.Flags().StringVarP(&showOutput, "output", "o", "dotenv", "Output format: dotenv (default), json, shell")
+	showCmd.Flags().StringVarP(&showOutput, "output", "o", "dotenv", "Output format: "+strings.Join(showOutputFormats, ", "))
+	showCmd.Flags().StringVar(&showSecretName, "name", "", "Secret name for k8s output (default: environment name)")
+	showCmd.Flags().StringVar(&showNamespace, "namespace", "", "Secret namespace for k8s output")
+	showCmd.Flags().BoolVar(&showExplain, "explain", false, "Explain where each variable comes from (values are masked)")
+	showCmd.Flags().BoolVar(&showOffline, "offline", false, "Only use cached secrets, do not contact cloud providers")
+	showCmd.Flags().BoolVar(&showConfirm, "confirm", false, "Confirm showing an environment whose policy requires it")
+	showCmd.Flags().BoolVar(&showConfirm, "i-know", false, "Alias of --confirm")
+	showCmd.Flags().BoolVarP(&showRecursive, "recursive", "r", false, "Show all kuba.yaml files in the current directory and below (requires --output json)")
+	showCmd.Flags().IntVarP(&showJobs, "jobs", "j", defaultRecursiveJobs, "Number of configurations to resolve concurrently with --recursive")
 	envFlag := showCmd.Flags().Lookup("env")
 	if envFlag != nil {
 		envFlag.NoOptDefVal = showListEnvironmentsValue
@@ -81,6 +127,16 @@ func runShowCommand(patterns []string, listEnvironments bool) error {
 		listEnvironments = false
 	}
 
+	if showRecursive {
+		if listEnvironments || showExplain {
+			return fmt.Errorf("--recursive cannot be combined with --explain or listing environments")
+		}
+		if showOutput != "json" {
+			return fmt.Errorf("--recursive requires --output json")
+		}
+		return runShowRecursive(os.Stdout, patterns)
+	}
+
 	// Find configuration file if not specified
 	if showConfigFile == "" {
 		var err error
@@ -110,30 +166,93 @@ func runShowCommand(patterns []string, listEnvironments bool) error {
 		return nil
 	}
 
+	// Create secrets manager factory
+	logger.Debug("Creating secrets manager factory")
+	factory := secrets.NewSecretManagerFactory()
+	factory.Offline = showOffline
+	defer factory.WaitForRefresh()
+
+	envName, env, resolution, err := resolveShowEnvironment(factory, kubaConfig, showConfigFile)
+	if err != nil {
+		return err
+	}
+
+	if showExplain {
+		explanations := filterExplanations(explainEnvironment(env, envName, resolution), patterns)
+		return writeExplanations(os.Stdout, showOutput, explanations)
+	}
+
+	displaySecrets, err := showDisplayValues(env, resolution, patterns)
+	if err != nil {
+		return err
+	}
+
+	secretName := showSecretName
+	if secretName == "" {
+		secretName = envName
+	}
+	return writeShowOutput(os.Stdout, showOutput, displaySecrets, k8sSecretOptions{
+		Name:      secretName,
+		Namespace: showNamespace,
+	})
+}
+
+// resolveShowEnvironment selects the environment of the configuration and
+// resolves its values
+func resolveShowEnvironment(factory *secrets.SecretManagerFactory, kubaConfig *config.KubaConfig, cfgPath string) (string, *config.Environment, *secrets.Resolution, error) {
+	logger := log.NewLogger()
+
 	// Get environment configuration
-	logger.Debug("Getting environment configuration", "environment", showEnvironment)
-	env, err := kubaConfig.GetEnvironment(showEnvironment)
+	envName := kubaConfig.SelectEnvironment(showEnvironment, cfgPath).Name
+	logger.Debug("Getting environment configuration", "environment", envName)
+	env, err := kubaConfig.GetEnvironment(envName)
 	if err != nil {
-		return fmt.Errorf("failed to get environment '%s': %w", showEnvironment, err)
+		return envName, nil, nil, fmt.Errorf("failed to get environment '%s': %w", envName, err)
 	}
-	logger.Debug("Environment configuration retrieved", "environment", showEnvironment, "provider", env.Provider, "env_count", len(env.Env))
+	logger.Debug("Environment configuration retrieved", "environment", envName, "provider", env.Provider, "env_count", len(env.Env))
 
-	// Create secrets manager factory
-	logger.Debug("Creating secrets manager factory")
-	factory := secrets.NewSecretManagerFactory()
+	// Explanations mask the values, so the policy only applies to the values
+	if !showExplain {
+		if err := checkShowPolicy(kubaConfig.PolicyFor(envName), envName); err != nil {
+			return envName, nil, nil, err
+		}
+	}
 
 	// Get secrets for the environment
 	ctx := context.Background()
 	logger.Debug("Fetching secrets from cloud providers")
-	secrets, err := factory.GetSecretsForEnvironmentWithCache(ctx, env, showConfigFile, showEnvironment)
+	resolution, err := factory.ResolveEnvironment(ctx, env, cfgPath, envName)
 	if err != nil {
-		return fmt.Errorf("failed to get secrets: %w", err)
+		return envName, nil, nil, fmt.Errorf("failed to get secrets: %w", err)
 	}
-	logger.Debug("Secrets retrieved successfully", "count", len(secrets))
+	logger.Debug("Secrets retrieved successfully", "count", len(resolution.Values))
+	return envName, env, resolution, nil
+}
+
+// checkShowPolicy returns an error when the policy rules of the environment
+// deny showing its values with the given flags
+func checkShowPolicy(policy config.EnvironmentPolicy, envName string) error {
+	if policy.RequireConfirm && !showConfirm {
+		return fmt.Errorf("policy requires --confirm to show environment '%s'", envName)
+	}
+	if policy.RequireSensitive && !showSensitive {
+		return fmt.Errorf("policy requires --sensitive to show environment '%s'", envName)
+	}
+	return nil
+}
+
+// showDisplayValues filters the resolved values by the patterns, checks them
+// against their validate rules and redacts them with --sensitive
+func showDisplayValues(env *config.Environment, resolution *secrets.Resolution, patterns []string) (map[string]string, error) {
+	logger := log.NewLogger()
 
 	// Filter secrets based on patterns
-	filteredSecrets := filterSecrets(secrets, patterns)
-	logger.Debug("Filtered secrets", "original_count", len(secrets), "filtered_count", len(filteredSecrets))
+	filteredSecrets := filterSecrets(resolution.Values, patterns)
+	logger.Debug("Filtered secrets", "original_count", len(resolution.Values), "filtered_count", len(filteredSecrets))
+
+	if err := checkResolvedValues(os.Stderr, env, filteredSecrets, resolution.Expanded()); err != nil {
+		return nil, err
+	}
 
 	// Prepare secrets for output
 	displaySecrets := make(map[string]string, len(filteredSecrets))
@@ -144,26 +263,61 @@ func runShowCommand(patterns []string, listEnvironments bool) error {
 		}
 		displaySecrets[key] = displayValue
 	}
+	return displaySecrets, nil
+}
 
-	switch showOutput {
-	case "dotenv":
-		for _, key := range getSortedKeys(displaySecrets) {
-			fmt.Printf("%s=%s\n", key, displaySecrets[key])
+// showResult is the JSON output of one configuration with --recursive
+type showResult struct {
+	Environment string            `json:"environment,omitempty"`
+	Values      map[string]string `json:"values,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// runShowRecursive writes the values of all configurations below the
+// working directory as a JSON object keyed by the configuration file
+func runShowRecursive(w io.Writer, patterns []string) error {
+	paths, err := findRecursiveConfigs(showConfigFile)
+	if err != nil {
+		return err
+	}
+
+	factory := secrets.NewSecretManagerFactory()
+	factory.Offline = showOffline
+	defer factory.WaitForRefresh()
+
+	results := forEachConfig(paths, showJobs, func(cfgPath string) showResult {
+		kubaConfig, err := config.LoadKubaConfig(cfgPath)
+		if err != nil {
+			return showResult{Error: fmt.Sprintf("failed to load configuration: %v", err)}
 		}
-	case "shell":
-		for _, key := range getSortedKeys(displaySecrets) {
-			fmt.Printf("export %s=%s\n", key, displaySecrets[key])
+		envName, env, resolution, err := resolveShowEnvironment(factory, kubaConfig, cfgPath)
+		if err != nil {
+			return showResult{Environment: envName, Error: err.Error()}
 		}
-	case "json":
-		payload, err := json.MarshalIndent(displaySecrets, "", "  ")
+		values, err := showDisplayValues(env, resolution, patterns)
 		if err != nil {
-			return fmt.Errorf("failed to format secrets as json: %w", err)
+			return showResult{Environment: envName, Error: err.Error()}
+		}
+		return showResult{Environment: envName, Values: values}
+	})
+
+	output := make(map[string]showResult, len(paths))
+	failed := 0
+	for i, result := range results {
+		output[filepath.ToSlash(paths[i])] = result
+		if result.Error != "" {
+			failed++
 		}
-		fmt.Println(string(payload))
-	default:
-		return fmt.Errorf("invalid output format '%s': must be one of: dotenv, json, shell", showOutput)
 	}
+	payload, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to format secrets as json: %w", err)
+	}
+	fmt.Fprintln(w, string(payload))
 
+	if failed > 0 {
+		return fmt.Errorf("%d of %d configurations failed", failed, len(paths))
+	}
 	return nil
 }
 
@@ -0,0 +1,195 @@
+package kuba
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mistweaverco/kuba/internal/lib/dotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// showOutputFormats lists the output formats supported by 'kuba show'
+var showOutputFormats = []string{
+	"dotenv", "shell", "json", "yaml", "docker", "systemd",
+	"k8s", "github", "fish", "powershell", "tfvars",
+}
+
+// githubEnvDelimiter returns the heredoc delimiter for multiline values in the
+// GitHub Actions format. It's a variable so tests can make it deterministic.
+var githubEnvDelimiter = func() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return "ghadelimiter_" + hex.EncodeToString(b)
+}
+
+// k8sSecret is a Kubernetes Secret manifest
+type k8sSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	Data       map[string]string `yaml:"data"`
+}
+
+type k8sMetadata struct {
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace,omitempty"`
+}
+
+// k8sSecretOptions configures the k8s output format
+type k8sSecretOptions struct {
+	Name      string
+	Namespace string
+}
+
+// writeShowOutput writes the secrets in the given format, sorted by key
+func writeShowOutput(w io.Writer, format string, values map[string]string, k8s k8sSecretOptions) error {
+	keys := getSortedKeys(values)
+
+	switch format {
+	case "dotenv":
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s=%s\n", key, dotenv.Quote(values[key]))
+		}
+	case "shell":
+		for _, key := range keys {
+			fmt.Fprintf(w, "export %s=%s\n", key, shellQuote(values[key]))
+		}
+	case "json":
+		payload, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to format secrets as json: %w", err)
+		}
+		fmt.Fprintln(w, string(payload))
+	case "yaml":
+		if err := encodeYAML(w, values); err != nil {
+			return fmt.Errorf("failed to format secrets as yaml: %w", err)
+		}
+	case "docker":
+		// Docker reads env files literally: no quotes, no escapes, no
+		// multiline values.
+		for _, key := range keys {
+			if strings.ContainsAny(values[key], "\r\n") {
+				return fmt.Errorf("%s contains a newline, which docker env files can't represent", key)
+			}
+		}
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s=%s\n", key, values[key])
+		}
+	case "systemd":
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s=%s\n", key, systemdQuote(values[key]))
+		}
+	case "k8s":
+		if k8s.Name == "" {
+			return fmt.Errorf("k8s output requires a secret name")
+		}
+		manifest := k8sSecret{
+			APIVersion: "v1",
+			Kind:       "Secret",
+			Metadata:   k8sMetadata{Name: k8s.Name, Namespace: k8s.Namespace},
+			Type:       "Opaque",
+			Data:       make(map[string]string, len(values)),
+		}
+		for key, value := range values {
+			manifest.Data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+		if err := encodeYAML(w, manifest); err != nil {
+			return fmt.Errorf("failed to format secrets as k8s secret: %w", err)
+		}
+	case "github":
+		for _, key := range keys {
+			value := values[key]
+			if !strings.ContainsAny(value, "\r\n") {
+				fmt.Fprintf(w, "%s=%s\n", key, value)
+				continue
+			}
+			delimiter := githubEnvDelimiter()
+			if strings.Contains(value, delimiter) {
+				return fmt.Errorf("%s contains the heredoc delimiter", key)
+			}
+			fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", key, delimiter, value, delimiter)
+		}
+	case "fish":
+		for _, key := range keys {
+			fmt.Fprintf(w, "set -gx %s %s\n", key, fishQuote(values[key]))
+		}
+	case "powershell":
+		for _, key := range keys {
+			fmt.Fprintf(w, "$env:%s = %s\n", key, powershellQuote(values[key]))
+		}
+	case "tfvars":
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s = %s\n", key, hclQuote(values[key]))
+		}
+	default:
+		return fmt.Errorf("invalid output format '%s': must be one of: %s", format, strings.Join(showOutputFormats, ", "))
+	}
+
+	return nil
+}
+
+func encodeYAML(w io.Writer, v any) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// shellQuote single-quotes values for POSIX shells
+func shellQuote(value string) string {
+	if value != "" && dotenv.SafeUnquotedValue.MatchString(value) {
+		return value
+	}
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// systemdQuote double-quotes values for systemd EnvironmentFile, where newlines
+// inside double quotes are kept literally
+func systemdQuote(value string) string {
+	if dotenv.SafeUnquotedValue.MatchString(value) {
+		return value
+	}
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"$", `\$`,
+		"`", "\\`",
+	)
+	return `"` + r.Replace(value) + `"`
+}
+
+// fishQuote single-quotes values for fish, where only \ and ' are escaped
+func fishQuote(value string) string {
+	if value != "" && dotenv.SafeUnquotedValue.MatchString(value) {
+		return value
+	}
+	r := strings.NewReplacer(`\`, `\\`, "'", `\'`)
+	return "'" + r.Replace(value) + "'"
+}
+
+// powershellQuote single-quotes values for PowerShell, where ' is doubled
+func powershellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// hclQuote double-quotes values for Terraform, escaping template sequences
+func hclQuote(value string) string {
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+		"${", "$${",
+		"%{", "%%{",
+	)
+	return `"` + r.Replace(value) + `"`
+}
@@ -0,0 +1,115 @@
+package kuba
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteShowOutputEscaping(t *testing.T) {
+	original := githubEnvDelimiter
+	githubEnvDelimiter = func() string { return "EOF_DELIM" }
+	t.Cleanup(func() { githubEnvDelimiter = original })
+
+	tests := []struct {
+		name     string
+		format   string
+		value    string
+		expected string
+	}{
+		{"dotenv plain", "dotenv", "abc-123", "KEY=abc-123\n"},
+		{"dotenv empty", "dotenv", "", "KEY=\n"},
+		{"dotenv spaces", "dotenv", "hello world", "KEY=\"hello world\"\n"},
+		{"dotenv special", "dotenv", "a\"b\\c$d#e", "KEY=\"a\\\"b\\\\c\\$d#e\"\n"},
+		{"dotenv multiline", "dotenv", "line1\nline2", "KEY=\"line1\\nline2\"\n"},
+
+		{"shell plain", "shell", "abc", "export KEY=abc\n"},
+		{"shell empty", "shell", "", "export KEY=''\n"},
+		{"shell single quote", "shell", "it's $HOME", "export KEY='it'\\''s $HOME'\n"},
+		{"shell multiline", "shell", "a\nb", "export KEY='a\nb'\n"},
+
+		{"yaml plain", "yaml", "abc", "KEY: abc\n"},
+		{"yaml ambiguous", "yaml", "yes", "KEY: \"yes\"\n"},
+		{"yaml multiline", "yaml", "a\nb", "KEY: |-\n  a\n  b\n"},
+
+		{"docker plain", "docker", "a b \"c\"", "KEY=a b \"c\"\n"},
+
+		{"systemd plain", "systemd", "abc", "KEY=abc\n"},
+		{"systemd special", "systemd", "a \"b\" $c `d` \\e", "KEY=\"a \\\"b\\\" \\$c \\`d\\` \\\\e\"\n"},
+		{"systemd multiline", "systemd", "a\nb", "KEY=\"a\nb\"\n"},
+
+		{"github plain", "github", "a b", "KEY=a b\n"},
+		{"github multiline", "github", "a\nb", "KEY<<EOF_DELIM\na\nb\nEOF_DELIM\n"},
+
+		{"fish plain", "fish", "abc", "set -gx KEY abc\n"},
+		{"fish special", "fish", "it's \\ $x", "set -gx KEY 'it\\'s \\\\ $x'\n"},
+		{"fish empty", "fish", "", "set -gx KEY ''\n"},
+
+		{"powershell plain", "powershell", "abc", "$env:KEY = 'abc'\n"},
+		{"powershell special", "powershell", "it's $x", "$env:KEY = 'it''s $x'\n"},
+
+		{"tfvars plain", "tfvars", "abc", "KEY = \"abc\"\n"},
+		{"tfvars special", "tfvars", "a\"b\\c\nd", "KEY = \"a\\\"b\\\\c\\nd\"\n"},
+		{"tfvars templates", "tfvars", "${x} %{y} $z", "KEY = \"$${x} %%{y} $z\"\n"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := writeShowOutput(&buf, test.format, map[string]string{"KEY": test.value}, k8sSecretOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, buf.String())
+		})
+	}
+}
+
+func TestWriteShowOutputSortsKeys(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeShowOutput(&buf, "fish", map[string]string{"B": "2", "A": "1"}, k8sSecretOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "set -gx A 1\nset -gx B 2\n", buf.String())
+}
+
+func TestWriteShowOutputDockerRejectsMultiline(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeShowOutput(&buf, "docker", map[string]string{"A": "ok", "KEY": "a\nb"}, k8sSecretOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "KEY contains a newline")
+	assert.Empty(t, buf.String())
+}
+
+func TestWriteShowOutputK8sSecret(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeShowOutput(&buf, "k8s", map[string]string{
+		"DB_PASSWORD": "s3cret",
+		"API_KEY":     "a\nb",
+	}, k8sSecretOptions{Name: "app", Namespace: "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  namespace: prod
+type: Opaque
+data:
+  API_KEY: YQpi
+  DB_PASSWORD: czNjcmV0
+`, buf.String())
+
+	buf.Reset()
+	err = writeShowOutput(&buf, "k8s", map[string]string{}, k8sSecretOptions{Name: "app"})
+	require.NoError(t, err)
+	assert.NotContains(t, buf.String(), "namespace")
+
+	err = writeShowOutput(&buf, "k8s", map[string]string{}, k8sSecretOptions{})
+	require.Error(t, err)
+}
+
+func TestWriteShowOutputInvalidFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeShowOutput(&buf, "xml", map[string]string{}, k8sSecretOptions{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid output format 'xml'")
+}
@@ -13,7 +13,7 @@ import (
 
 func TestRunShowCommandListsEnvironments(t *testing.T) {
 	t.Cleanup(func() {
-		showEnvironment = "default"
+		showEnvironment = ""
 		showConfigFile = ""
 		showSensitive = false
 		showOutput = "dotenv"
@@ -65,7 +65,7 @@ staging:
 
 func TestRunShowCommandUsesProvidedEnvironment(t *testing.T) {
 	t.Cleanup(func() {
-		showEnvironment = "default"
+		showEnvironment = ""
 		showConfigFile = ""
 		showSensitive = false
 		showOutput = "dotenv"
@@ -115,9 +115,66 @@ staging:
 	assert.Equal(t, "BAR=bar", output)
 }
 
+func TestRunShowCommandSelectsEnvironmentFromKubaEnv(t *testing.T) {
+	t.Cleanup(func() {
+		showEnvironment = ""
+		showConfigFile = ""
+	})
+
+	tmpFile, err := os.CreateTemp("", "kuba-show-*.yaml")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Remove(tmpFile.Name()) })
+
+	configContent := `
+select:
+  - path: "*"
+    env: default
+default:
+  provider: local
+  env:
+    FOO:
+      value: foo
+staging:
+  provider: local
+  env:
+    BAR:
+      value: bar
+`
+	_, err = tmpFile.WriteString(configContent)
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+	showConfigFile = tmpFile.Name()
+	t.Setenv("KUBA_ENV", "staging")
+
+	capture := func() string {
+		originalStdout := os.Stdout
+		r, w, err := os.Pipe()
+		require.NoError(t, err)
+		os.Stdout = w
+
+		runErr := runShowCommand(nil, false)
+
+		require.NoError(t, w.Close())
+		os.Stdout = originalStdout
+		require.NoError(t, runErr)
+
+		outputBytes, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		return strings.TrimSpace(string(outputBytes))
+	}
+
+	// KUBA_ENV wins over the select rules
+	assert.Equal(t, "BAR=bar", capture())
+
+	// The flag wins over KUBA_ENV
+	showEnvironment = "default"
+	assert.Equal(t, "FOO=foo", capture())
+}
+
 func TestRunShowCommandConsumesArgWhenEnvFlagNoOptSet(t *testing.T) {
 	t.Cleanup(func() {
-		showEnvironment = "default"
+		showEnvironment = ""
 		showConfigFile = ""
 		showSensitive = false
 		showOutput = "dotenv"
@@ -169,7 +226,7 @@ staging:
 
 func TestRunShowCommandOutputsJSON(t *testing.T) {
 	t.Cleanup(func() {
-		showEnvironment = "default"
+		showEnvironment = ""
 		showConfigFile = ""
 		showSensitive = false
 		showOutput = "dotenv"
@@ -222,7 +279,7 @@ default:
 
 func TestRunShowCommandOutputsShell(t *testing.T) {
 	t.Cleanup(func() {
-		showEnvironment = "default"
+		showEnvironment = ""
 		showConfigFile = ""
 		showSensitive = false
 		showOutput = "dotenv"
@@ -270,3 +327,63 @@ default:
 		"export FOO=foo",
 	}, output)
 }
+
+func TestRunShowCommandEnforcesPolicy(t *testing.T) {
+	t.Cleanup(func() {
+		showEnvironment = ""
+		showConfigFile = ""
+		showSensitive = false
+		showConfirm = false
+		showOutput = "dotenv"
+	})
+	t.Setenv("HOME", t.TempDir())
+
+	tmpFile, err := os.CreateTemp("", "kuba-show-*.yaml")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Remove(tmpFile.Name()) })
+
+	configContent := `
+policy:
+  - env: prod*
+    require-confirm: true
+    require-sensitive: true
+production:
+  provider: local
+  env:
+    FOO:
+      value: foobar
+`
+	_, err = tmpFile.WriteString(configContent)
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+
+	showEnvironment = "production"
+	showConfigFile = tmpFile.Name()
+
+	err = runShowCommand(nil, false)
+	require.EqualError(t, err, "policy requires --confirm to show environment 'production'")
+
+	// --i-know is an alias of --confirm
+	require.NoError(t, showCmd.Flags().Set("i-know", "true"))
+	require.True(t, showConfirm)
+	err = runShowCommand(nil, false)
+	require.EqualError(t, err, "policy requires --sensitive to show environment 'production'")
+
+	showSensitive = true
+	originalStdout := os.Stdout
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	os.Stdout = w
+
+	runErr := runShowCommand(nil, false)
+
+	require.NoError(t, w.Close())
+	os.Stdout = originalStdout
+	outputBytes, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+
+	require.NoError(t, runErr)
+	assert.NotContains(t, string(outputBytes), "foobar")
+	assert.True(t, strings.HasPrefix(string(outputBytes), "FOO="))
+}
@@ -1,8 +1,10 @@
 package kuba
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/mistweaverco/kuba/internal/config"
 	"github.com/mistweaverco/kuba/internal/lib/log"
@@ -13,6 +15,8 @@ import (
 var (
 	testEnvironment string
 	testConfigFile  string
+	testRecursive   bool
+	testJobs        int
 )
 
 var testCmd = &cobra.Command{
@@ -25,21 +29,32 @@ This command will:
 2. Resolve the selected environment
 3. Test authorization for each provider used in the environment
 4. Attempt to fetch all mapped values (secrets, paths, and literals)
+5. Check the fetched values against the validate rules of kuba.yaml
 
-It provides clear feedback about authentication status and permissions for each provider.`,
+It provides clear feedback about authentication status and permissions for each provider.
+
+Use --recursive to test every kuba.yaml in the current directory and below
+it, e.g. all services of a monorepo. Files ignored by git are skipped. The
+output of each configuration is followed by a summary, and the command fails
+when any configuration fails.`,
 	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return runTest()
+		if testRecursive {
+			return runTestRecursive(cmd.OutOrStdout())
+		}
+		return runTest(cmd.OutOrStdout())
 	},
 }
 
 func init() {
-	testCmd.Flags().StringVarP(&testEnvironment, "env", "e", "default", "Environment to use (default: default)")
+	testCmd.Flags().StringVarP(&testEnvironment, "env", "e", "", "Environment to use (default: $KUBA_ENV, a matching select rule, or default)")
 	testCmd.Flags().StringVarP(&testConfigFile, "config", "c", "", "Path to kuba.yaml configuration file")
+	testCmd.Flags().BoolVarP(&testRecursive, "recursive", "r", false, "Test all kuba.yaml files in the current directory and below")
+	testCmd.Flags().IntVarP(&testJobs, "jobs", "j", defaultRecursiveJobs, "Number of configurations to test concurrently with --recursive")
 	rootCmd.AddCommand(testCmd)
 }
 
-func runTest() error {
+func runTest(w io.Writer) error {
 	logger := log.NewLogger()
 
 	// Find configuration file if not specified
@@ -56,6 +71,62 @@ func runTest() error {
 		logger.Debug("Using specified configuration file", "path", cfgPath)
 	}
 
+	return testConfig(w, cfgPath)
+}
+
+// testResult is the outcome of testing one configuration with --recursive
+type testResult struct {
+	output bytes.Buffer
+	err    error
+}
+
+// runTestRecursive tests all configurations below the working directory and
+// writes the output of each of them, followed by a summary
+func runTestRecursive(w io.Writer) error {
+	paths, err := findRecursiveConfigs(testConfigFile)
+	if err != nil {
+		return err
+	}
+
+	// Output is buffered per configuration to keep concurrent tests readable
+	results := forEachConfig(paths, testJobs, func(cfgPath string) *testResult {
+		result := &testResult{}
+		result.err = testConfig(&result.output, cfgPath)
+		return result
+	})
+
+	failed := 0
+	for i, result := range results {
+		fmt.Fprintf(w, "\n##### %s #####\n", paths[i])
+		w.Write(result.output.Bytes())
+		if result.err != nil {
+			fmt.Fprintf(w, "\n❌ %v\n", result.err)
+			failed++
+		}
+	}
+
+	fmt.Fprintf(w, "\n=== Summary ===\n\n")
+	for i, result := range results {
+		if result.err != nil {
+			fmt.Fprintf(w, "  ❌ %s\n", paths[i])
+		} else {
+			fmt.Fprintf(w, "  ✅ %s\n", paths[i])
+		}
+	}
+	fmt.Fprintln(w)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d configurations failed", failed, len(paths))
+	}
+	fmt.Fprintf(w, "All %d configurations passed\n", len(paths))
+	return nil
+}
+
+// testConfig runs the tests for a single configuration and writes the
+// results to w
+func testConfig(w io.Writer, cfgPath string) error {
+	logger := log.NewLogger()
+
 	// Load configuration
 	logger.Debug("Loading configuration from file")
 	kubaConfig, err := config.LoadKubaConfig(cfgPath)
@@ -65,19 +136,21 @@ func runTest() error {
 	logger.Debug("Configuration loaded successfully")
 
 	// Get environment configuration
-	logger.Debug("Getting environment configuration", "environment", testEnvironment)
-	env, err := kubaConfig.GetEnvironment(testEnvironment)
+	envName := kubaConfig.SelectEnvironment(testEnvironment, cfgPath).Name
+	logger.Debug("Getting environment configuration", "environment", envName)
+	env, err := kubaConfig.GetEnvironment(envName)
 	if err != nil {
-		return fmt.Errorf("failed to get environment '%s': %w", testEnvironment, err)
+		return fmt.Errorf("failed to get environment '%s': %w", envName, err)
 	}
 
 	// Create secrets manager factory
 	logger.Debug("Creating secrets manager factory")
 	factory := secrets.NewSecretManagerFactory()
+	defer factory.WaitForRefresh()
 	ctx := context.Background()
 
 	// Step 1: Test authorization for all providers used in this environment
-	fmt.Printf("\n=== Testing Authorization ===\n\n")
+	fmt.Fprintf(w, "\n=== Testing Authorization ===\n\n")
 
 	// Collect unique providers from the environment
 	providers := make(map[string]string) // provider -> projectID
@@ -100,15 +173,15 @@ func runTest() error {
 	allAuthPassed := true
 
 	for provider, projectID := range providers {
-		fmt.Printf("Testing %s provider", provider)
+		fmt.Fprintf(w, "Testing %s provider", provider)
 		if projectID != "" {
-			fmt.Printf(" (project: %s)", projectID)
+			fmt.Fprintf(w, " (project: %s)", projectID)
 		}
-		fmt.Printf("...\n")
+		fmt.Fprintf(w, "...\n")
 
 		result, err := factory.TestAuthorization(ctx, provider, projectID)
 		if err != nil {
-			fmt.Printf("  ❌ Error testing authorization: %v\n\n", err)
+			fmt.Fprintf(w, "  ❌ Error testing authorization: %v\n\n", err)
 			allAuthPassed = false
 			continue
 		}
@@ -117,46 +190,76 @@ func runTest() error {
 
 		// Print results
 		if !result.Authenticated {
-			fmt.Printf("  ❌ Authentication failed\n")
-			fmt.Printf("     %s\n", result.CredentialsInfo)
+			fmt.Fprintf(w, "  ❌ Authentication failed\n")
+			fmt.Fprintf(w, "     %s\n", result.CredentialsInfo)
 			if result.ErrorMessage != "" {
-				fmt.Printf("     Error: %s\n", result.ErrorMessage)
+				fmt.Fprintf(w, "     Error: %s\n", result.ErrorMessage)
 			}
 			allAuthPassed = false
 		} else if !result.HasPermissions {
-			fmt.Printf("  ⚠️  Authenticated but lacks permissions\n")
-			fmt.Printf("     %s\n", result.CredentialsInfo)
+			fmt.Fprintf(w, "  ⚠️  Authenticated but lacks permissions\n")
+			fmt.Fprintf(w, "     %s\n", result.CredentialsInfo)
 			if result.ErrorMessage != "" {
-				fmt.Printf("     Error: %s\n", result.ErrorMessage)
+				fmt.Fprintf(w, "     Error: %s\n", result.ErrorMessage)
 			}
 			allAuthPassed = false
 		} else {
-			fmt.Printf("  ✅ Successfully authenticated and authorized\n")
-			fmt.Printf("     %s\n", result.CredentialsInfo)
+			fmt.Fprintf(w, "  ✅ Successfully authenticated and authorized\n")
+			fmt.Fprintf(w, "     %s\n", result.CredentialsInfo)
 		}
-		fmt.Printf("\n")
+		fmt.Fprintf(w, "\n")
 	}
 
 	// If authorization failed, provide helpful message but continue to test retrieval
 	if !allAuthPassed {
-		fmt.Printf("⚠️  Some authorization tests failed. Attempting secret retrieval anyway...\n\n")
+		fmt.Fprintf(w, "⚠️  Some authorization tests failed. Attempting secret retrieval anyway...\n\n")
 	}
 
 	// Step 2: Attempt to retrieve secrets
-	fmt.Printf("=== Testing Secret Retrieval ===\n\n")
+	fmt.Fprintf(w, "=== Testing Secret Retrieval ===\n\n")
 	logger.Debug("Fetching secrets and values for environment")
-	values, err := factory.GetSecretsForEnvironmentWithCache(ctx, env, cfgPath, testEnvironment)
+	resolution, err := factory.ResolveEnvironment(ctx, env, cfgPath, envName)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve values: %w", err)
 	}
+	values := resolution.Values
 
 	// Success summary
-	fmt.Printf("✅ Successfully retrieved %d values for environment '%s'\n", len(values), testEnvironment)
+	fmt.Fprintf(w, "✅ Successfully retrieved %d values for environment '%s'\n", len(values), envName)
+
+	// Step 3: Check the values against their validate rules
+	fmt.Fprintf(w, "\n=== Value Validation ===\n\n")
+	failed := printValueChecks(w, env.CheckValues(values, resolution.Expanded()))
 
 	// If authorization failed, remind user
 	if !allAuthPassed {
-		fmt.Printf("\n⚠️  Note: Some authorization tests failed. Please check your credentials and permissions.\n")
+		fmt.Fprintf(w, "\n⚠️  Note: Some authorization tests failed. Please check your credentials and permissions.\n")
 	}
 
+	if failed > 0 {
+		return fmt.Errorf("value validation failed for %d variable(s)", failed)
+	}
 	return nil
 }
+
+// printValueChecks writes the result of each value check and returns the
+// number of strict checks that failed
+func printValueChecks(w io.Writer, checks []config.ValueCheck) int {
+	if len(checks) == 0 {
+		fmt.Fprintf(w, "No validate rules defined\n")
+		return 0
+	}
+	failed := 0
+	for _, check := range checks {
+		switch {
+		case check.Passed():
+			fmt.Fprintf(w, "  ✅ %s\n", check.Variable)
+		case check.Strict:
+			fmt.Fprintf(w, "  ❌ %s\n", describeValueCheck(check))
+			failed++
+		default:
+			fmt.Fprintf(w, "  ⚠️  %s\n", describeValueCheck(check))
+		}
+	}
+	return failed
+}
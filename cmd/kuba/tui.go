@@ -10,13 +10,20 @@ import (
 )
 
 var (
-	tuiConfigFile string
+	tuiConfigFile  string
+	tuiEnvironment string
+	tuiConfirm     bool
 )
 
 var tuiCmd = &cobra.Command{
 	Use:   "tui",
 	Short: "Interactive TUI for environments and secrets",
-	Args:  cobra.NoArgs,
+	Long: `Interactive TUI for environments and secrets.
+
+Policy rules in kuba.yaml or the global configuration can require --confirm
+(or its alias --i-know) to edit an environment, e.g. to create, update or
+delete its secrets.`,
+	Args: cobra.NoArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if tuiConfigFile == "" {
 			var err error
@@ -25,11 +32,14 @@ var tuiCmd = &cobra.Command{
 				return fmt.Errorf("failed to find configuration file: %w", err)
 			}
 		}
-		return tui.Run(context.Background(), tuiConfigFile)
+		return tui.Run(context.Background(), tuiConfigFile, tuiEnvironment, tuiConfirm)
 	},
 }
 
 func init() {
 	tuiCmd.Flags().StringVarP(&tuiConfigFile, "config", "c", "", "Path to kuba.yaml configuration file")
+	tuiCmd.Flags().StringVarP(&tuiEnvironment, "env", "e", "", "Environment to preselect (default: $KUBA_ENV, a matching select rule, or default)")
+	tuiCmd.Flags().BoolVar(&tuiConfirm, "confirm", false, "Allow edits of environments whose policy requires confirmation")
+	tuiCmd.Flags().BoolVar(&tuiConfirm, "i-know", false, "Alias of --confirm")
 	rootCmd.AddCommand(tuiCmd)
 }
@@ -0,0 +1,49 @@
+package kuba
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+)
+
+// checkResolvedValues validates the resolved values against the validate
+// rules of the environment. Violations of warn rules are written to w,
+// violations of strict rules are returned as an error. Values are never
+// printed. expanded maps the variables expanded from a secret-path to the
+// path's item, see secrets.Resolution.Expanded.
+func checkResolvedValues(w io.Writer, env *config.Environment, values, expanded map[string]string) error {
+	checks := env.CheckValues(values, expanded)
+	log.NewLogger().Debug("Validated resolved values", "checked", len(checks))
+
+	var failures []string
+	for _, check := range checks {
+		if check.Passed() {
+			continue
+		}
+		if !check.Strict {
+			fmt.Fprintf(w, "warning: %s\n", describeValueCheck(check))
+			continue
+		}
+		failures = append(failures, describeValueCheck(check))
+	}
+
+	switch len(failures) {
+	case 0:
+		return nil
+	case 1:
+		return fmt.Errorf("value validation failed: %s", failures[0])
+	default:
+		return fmt.Errorf("value validation failed for %d variables:\n  %s", len(failures), strings.Join(failures, "\n  "))
+	}
+}
+
+// describeValueCheck returns the check with the position of its rules
+func describeValueCheck(check config.ValueCheck) string {
+	if pos := check.Source.String(); pos != "" {
+		return fmt.Sprintf("%s (%s)", check, pos)
+	}
+	return check.String()
+}
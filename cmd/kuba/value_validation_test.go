@@ -0,0 +1,41 @@
+package kuba
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckResolvedValues(t *testing.T) {
+	env := &config.Environment{Env: map[string]config.EnvItem{
+		"PORT":      {Validate: &config.ValueValidation{Type: config.ValueTypeInt}},
+		"LOG_LEVEL": {Validate: &config.ValueValidation{Enum: []string{"debug", "info"}, Mode: config.ValidationWarn}},
+	}}
+
+	var out bytes.Buffer
+	err := checkResolvedValues(&out, env, map[string]string{"PORT": "8080", "LOG_LEVEL": "verbose"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "warning: LOG_LEVEL: is not one of: debug, info\n", out.String())
+
+	out.Reset()
+	err = checkResolvedValues(&out, env, map[string]string{"PORT": "http-port", "LOG_LEVEL": "info"}, nil)
+	require.Error(t, err)
+	assert.Equal(t, "value validation failed: PORT: is not an integer", err.Error())
+	assert.NotContains(t, err.Error(), "http-port")
+	assert.Empty(t, out.String())
+}
+
+func TestPrintValueChecks(t *testing.T) {
+	var out bytes.Buffer
+	failed := printValueChecks(&out, []config.ValueCheck{
+		{Variable: "API_URL", Strict: true},
+		{Variable: "LOG_LEVEL", Problems: []string{"is not one of: info"}},
+		{Variable: "PORT", Strict: true, Problems: []string{"is not an integer"}},
+	})
+
+	assert.Equal(t, 1, failed)
+	assert.Equal(t, "  ✅ API_URL\n  ⚠️  LOG_LEVEL: is not one of: info\n  ❌ PORT: is not an integer\n", out.String())
+}
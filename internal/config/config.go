@@ -3,6 +3,8 @@ package config
 type ConfigFlags struct {
 	Version bool
 	Debug   bool
+	NoLocal bool
+	NoOSEnv bool
 }
 
 type Config struct {
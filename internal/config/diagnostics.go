@@ -0,0 +1,258 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity is the severity of a diagnostic
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Rules identify the kind of problem a diagnostic reports
+const (
+	RuleSyntax          = "syntax"
+	RuleUnknownKey      = "unknown-key"
+	RuleNoEnvironments  = "no-environments"
+	RuleProvider        = "provider"
+	RuleProject         = "project"
+	RuleEmptyEnv        = "empty-env"
+	RuleSecretReference = "secret-reference"
+	RuleLocalProvider   = "local-provider"
+	RuleSelect          = "select"
+	RuleValidate        = "validate"
+	RuleInterpolation   = "interpolation"
+	RulePolicy          = "policy"
+)
+
+// topLevelKeys are the keys of kuba.yaml that are not environments
+var topLevelKeys = schemaKeys(reflect.TypeOf(KubaConfig{}))
+
+// selectRuleKeys are the keys allowed in a select rule
+var selectRuleKeys = schemaKeys(reflect.TypeOf(SelectRule{}))
+
+// policyRuleKeys are the keys allowed in a policy rule
+var policyRuleKeys = schemaKeys(reflect.TypeOf(PolicyRule{}))
+
+// ruleListKeys are the allowed keys of the rules in the top-level rule lists
+var ruleListKeys = map[string][]string{
+	"select": selectRuleKeys,
+	"policy": policyRuleKeys,
+}
+
+// environmentKeys are the keys allowed in an environment
+var environmentKeys = schemaKeys(reflect.TypeOf(Environment{}))
+
+// envItemKeys are the keys allowed in an env item
+var envItemKeys = schemaKeys(reflect.TypeOf(EnvItem{}))
+
+// validateKeys are the keys allowed in the validate rules of an env item
+var validateKeys = schemaKeys(reflect.TypeOf(ValueValidation{}))
+
+// Diagnostic is a single problem found in a configuration
+type Diagnostic struct {
+	Severity    Severity
+	Rule        string
+	Position    SourcePosition
+	Environment string
+	Variable    string
+	Message     string
+	// Suggestion is a replacement for a misspelled key, if one was found
+	Suggestion string
+}
+
+// Text returns the diagnostic without its position
+func (d Diagnostic) Text() string {
+	var b strings.Builder
+	if d.Environment != "" {
+		fmt.Fprintf(&b, "environment '%s': ", d.Environment)
+	}
+	if d.Variable != "" {
+		fmt.Fprintf(&b, "env item '%s': ", d.Variable)
+	}
+	b.WriteString(d.Message)
+	if d.Suggestion != "" {
+		fmt.Fprintf(&b, " (did you mean `%s`?)", d.Suggestion)
+	}
+	return b.String()
+}
+
+// String returns the diagnostic prefixed with its position, if it is known
+func (d Diagnostic) String() string {
+	if pos := d.Position.String(); pos != "" {
+		return pos + ": " + d.Text()
+	}
+	return d.Text()
+}
+
+// ValidationError holds all problems found while validating a configuration
+type ValidationError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Diagnostics) == 1 {
+		return e.Diagnostics[0].String()
+	}
+	lines := make([]string, 0, len(e.Diagnostics)+1)
+	lines = append(lines, fmt.Sprintf("%d problems found:", len(e.Diagnostics)))
+	for _, d := range e.Diagnostics {
+		lines = append(lines, "  "+d.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DiagnosticsFromError returns the diagnostics of a ValidationError in err,
+// or a single diagnostic for any other error
+func DiagnosticsFromError(err error, file string) []Diagnostic {
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Diagnostics
+	}
+	return []Diagnostic{{
+		Severity: SeverityError,
+		Rule:     RuleSyntax,
+		Position: SourcePosition{File: file},
+		Message:  err.Error(),
+	}}
+}
+
+// sortDiagnostics orders diagnostics by position and message
+func sortDiagnostics(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.Position.File != b.Position.File {
+			return a.Position.File < b.Position.File
+		}
+		if a.Position.Line != b.Position.Line {
+			return a.Position.Line < b.Position.Line
+		}
+		if a.Position.Column != b.Position.Column {
+			return a.Position.Column < b.Position.Column
+		}
+		return a.Text() < b.Text()
+	})
+}
+
+// checkUnknownKeys reports keys of environments and env items that kuba does
+// not know about
+func checkUnknownKeys(root *yaml.Node, file string) []Diagnostic {
+	var diagnostics []Diagnostic
+	if root == nil || root.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		envName, envNode := root.Content[i].Value, root.Content[i+1]
+		if ruleKeys, ok := ruleListKeys[envName]; ok && envNode.Kind == yaml.SequenceNode {
+			for _, rule := range envNode.Content {
+				if rule.Kind != yaml.MappingNode {
+					continue
+				}
+				for j := 0; j+1 < len(rule.Content); j += 2 {
+					if key := rule.Content[j]; !slices.Contains(ruleKeys, key.Value) {
+						diagnostics = append(diagnostics, unknownKey(file, key, "", "", ruleKeys))
+					}
+				}
+			}
+			continue
+		}
+		if slices.Contains(topLevelKeys, envName) || envNode.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(envNode.Content); j += 2 {
+			key, value := envNode.Content[j], envNode.Content[j+1]
+			if !slices.Contains(environmentKeys, key.Value) {
+				diagnostics = append(diagnostics, unknownKey(file, key, envName, "", environmentKeys))
+				continue
+			}
+			if key.Value != "env" || value.Kind != yaml.MappingNode {
+				continue
+			}
+			for k := 0; k+1 < len(value.Content); k += 2 {
+				itemName, itemNode := value.Content[k].Value, value.Content[k+1]
+				if itemNode.Kind != yaml.MappingNode {
+					continue
+				}
+				for l := 0; l+1 < len(itemNode.Content); l += 2 {
+					field, fieldValue := itemNode.Content[l], itemNode.Content[l+1]
+					if !slices.Contains(envItemKeys, field.Value) {
+						diagnostics = append(diagnostics, unknownKey(file, field, envName, itemName, envItemKeys))
+						continue
+					}
+					if field.Value != "validate" || fieldValue.Kind != yaml.MappingNode {
+						continue
+					}
+					for m := 0; m+1 < len(fieldValue.Content); m += 2 {
+						if rule := fieldValue.Content[m]; !slices.Contains(validateKeys, rule.Value) {
+							diagnostics = append(diagnostics, unknownKey(file, rule, envName, itemName, validateKeys))
+						}
+					}
+				}
+			}
+		}
+	}
+	return diagnostics
+}
+
+func unknownKey(file string, key *yaml.Node, envName, variable string, known []string) Diagnostic {
+	return Diagnostic{
+		Severity:    SeverityError,
+		Rule:        RuleUnknownKey,
+		Position:    positionOf(file, key),
+		Environment: envName,
+		Variable:    variable,
+		Message:     fmt.Sprintf("unknown key '%s'", key.Value),
+		Suggestion:  suggestKey(key.Value, known),
+	}
+}
+
+// suggestKey returns the known key closest to key, if it is close enough to
+// be a likely typo
+func suggestKey(key string, known []string) string {
+	best, bestDistance := "", -1
+	for _, candidate := range known {
+		d := editDistance(strings.ToLower(key), candidate)
+		if bestDistance == -1 || d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	maxDistance := len(best) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+	if bestDistance == -1 || bestDistance > maxDistance {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
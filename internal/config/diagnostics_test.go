@@ -0,0 +1,133 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfigReportsAllProblems(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kuba.yaml")
+	writeConfigFile(t, path, `default:
+  provider: gcp
+  projcet: p
+  env:
+    DB_PASSWORD:
+      secret_key: db-password
+    API_KEY:
+      secret-key: api-key
+      value: "x"
+staging:
+  provider: nope
+  env:
+    LOG_LEVEL:
+      value: debug
+`)
+
+	_, err := LoadKubaConfig(path)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+	}
+
+	got := make([]string, 0, len(validationErr.Diagnostics))
+	for _, d := range validationErr.Diagnostics {
+		got = append(got, strings.TrimPrefix(d.String(), path+":"))
+	}
+	want := []string{
+		"1:1: environment 'default': project is required for provider 'gcp'",
+		"3:3: environment 'default': unknown key 'projcet' (did you mean `project`?)",
+		"5:5: environment 'default': env item 'DB_PASSWORD': either secret-key, secret-path, or value is required",
+		"6:7: environment 'default': env item 'DB_PASSWORD': unknown key 'secret_key' (did you mean `secret-key`?)",
+		"7:5: environment 'default': env item 'API_KEY': cannot specify multiple of secret-key, secret-path, or value",
+		"10:1: environment 'staging': invalid provider 'nope'",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateConfigReportsInheritedItemsOnce(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kuba.yaml")
+	writeConfigFile(t, path, `base:
+  provider: gcp
+  project: p
+  env:
+    BROKEN:
+      provider: gcp
+child:
+  inherits: base
+  env:
+    OK:
+      value: "1"
+`)
+
+	_, err := LoadKubaConfig(path)
+	diagnostics := DiagnosticsFromError(err, path)
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d: %v", len(diagnostics), err)
+	}
+	if diagnostics[0].Environment != "base" || diagnostics[0].Variable != "BROKEN" {
+		t.Fatalf("unexpected diagnostic: %s", diagnostics[0])
+	}
+}
+
+func TestUnknownKeysInIncludedFiles(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.yaml")
+	writeConfigFile(t, shared, `default:
+  provider: gcp
+  project: p
+  env:
+    A:
+      valeu: "1"
+      value: "1"
+`)
+	path := filepath.Join(dir, "kuba.yaml")
+	writeConfigFile(t, path, `include:
+  - shared.yaml
+default:
+  env:
+    B:
+      value: "2"
+`)
+
+	_, err := LoadKubaConfig(path)
+	diagnostics := DiagnosticsFromError(err, path)
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d: %v", len(diagnostics), err)
+	}
+	d := diagnostics[0]
+	if d.Rule != RuleUnknownKey || d.Position.File != shared || d.Position.Line != 6 || d.Suggestion != "value" {
+		t.Fatalf("unexpected diagnostic: %+v", d)
+	}
+}
+
+func TestSuggestKey(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"secret_key", "secret-key"},
+		{"secretpath", "secret-path"},
+		{"Provider", "provider"},
+		{"inherit", "inherits"},
+		{"description", ""},
+		{"x", ""},
+	}
+	for _, tt := range tests {
+		keys := envItemKeys
+		if tt.key == "inherit" {
+			keys = environmentKeys
+		}
+		if got := suggestKey(tt.key, keys); got != tt.want {
+			t.Errorf("suggestKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/kuba/internal/lib/log"
+)
+
+// ConfigFileName is the name of the project configuration file
+const ConfigFileName = "kuba.yaml"
+
+// FindConfigFiles returns the kuba.yaml files in dir and all directories
+// below it, e.g. the services of a monorepo. Files and directories ignored
+// through .gitignore files or .git/info/exclude of the surrounding git
+// repository are skipped.
+func FindConfigFiles(dir string) ([]string, error) {
+	logger := log.NewLogger()
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve directory %s: %w", dir, err)
+	}
+
+	// Ignore files between the repository root and dir apply as well
+	root := findRepositoryRoot(absDir)
+	var rules ignoreRules
+	if root != "" {
+		rules = append(rules, readIgnoreFile(filepath.Join(root, ".git", "info", "exclude"), "")...)
+		rel, _ := filepath.Rel(root, absDir)
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		for i := 0; i < len(parts) && rel != "."; i++ {
+			base := strings.Join(parts[:i], "/")
+			rules = append(rules, readIgnoreFile(filepath.Join(root, filepath.FromSlash(base), ".gitignore"), base)...)
+		}
+	} else {
+		root = absDir
+	}
+
+	rulesByDir := make(map[string]ignoreRules)
+	var files []string
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		parentRules := rules
+		if rel != "." && abs != absDir {
+			parentRules = rulesByDir[path.Dir(rel)]
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" || (abs != absDir && parentRules.ignored(rel, true)) {
+				return filepath.SkipDir
+			}
+			base := rel
+			if base == "." {
+				base = ""
+			}
+			own := readIgnoreFile(filepath.Join(p, ".gitignore"), base)
+			rulesByDir[rel] = append(parentRules[:len(parentRules):len(parentRules)], own...)
+			return nil
+		}
+
+		if d.Name() == ConfigFileName && !parentRules.ignored(rel, false) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for configuration files: %w", err)
+	}
+
+	sort.Strings(files)
+	logger.Debug("Found configuration files", "dir", dir, "count", len(files))
+	return files, nil
+}
+
+// findRepositoryRoot returns the closest directory containing .git, or an
+// empty string when dir is not inside a git repository
+func findRepositoryRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ignorePattern is a single pattern of a .gitignore file
+type ignorePattern struct {
+	// base is the directory of the .gitignore file, relative to the
+	// repository root
+	base     string
+	pattern  string
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// ignoreRules are the patterns that apply to a directory, in the order they
+// are read; later patterns win
+type ignoreRules []ignorePattern
+
+// readIgnoreFile reads the patterns of a .gitignore file. A missing file has
+// no patterns.
+func readIgnoreFile(file, base string) ignoreRules {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var rules ignoreRules
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p := ignorePattern{base: base}
+		if strings.HasPrefix(line, "!") {
+			p.negate = true
+			line = line[1:]
+		}
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			p.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			p.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		p.pattern = line
+		rules = append(rules, p)
+	}
+	return rules
+}
+
+// ignored reports whether the slash separated path rel, relative to the
+// repository root, is ignored
+func (r ignoreRules) ignored(rel string, isDir bool) bool {
+	ignored := false
+	for _, p := range r {
+		if p.matches(rel, isDir) {
+			ignored = !p.negate
+		}
+	}
+	return ignored
+}
+
+func (p ignorePattern) matches(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if p.base != "" {
+		if !strings.HasPrefix(rel, p.base+"/") {
+			return false
+		}
+		rel = strings.TrimPrefix(rel, p.base+"/")
+	}
+	if !p.anchored {
+		rel = path.Base(rel)
+	}
+	return matchGlob(strings.Split(p.pattern, "/"), strings.Split(rel, "/"))
+}
+
+// matchGlob matches path segments against pattern segments, where "**"
+// matches any number of segments
+func matchGlob(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchGlob(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+		return false
+	}
+	return matchGlob(pattern[1:], segments[1:])
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestFindConfigFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".git/HEAD":                               "ref: refs/heads/main\n",
+		".git/info/exclude":                       "scratch/\n",
+		".gitignore":                              "# dependencies\nnode_modules/\n/build\n**/fixtures/**/kuba.yaml\n",
+		"kuba.yaml":                               "",
+		"services/api/kuba.yaml":                  "",
+		"services/api/kuba.local.yaml":            "",
+		"services/web/kuba.yaml":                  "",
+		"services/web/node_modules/x/kuba.yaml":   "",
+		"services/web/.gitignore":                 "generated\n!generated/keep\n",
+		"services/web/generated/kuba.yaml":        "",
+		"services/billing/.gitignore":             "*.yaml\n!kuba.yaml\n",
+		"services/billing/kuba.yaml":              "",
+		"services/billing/build/kuba.yaml":        "",
+		"build/kuba.yaml":                         "",
+		"scratch/kuba.yaml":                       "",
+		"test/fixtures/broken/kuba.yaml":          "",
+		"tools/kuba.yaml/not-a-config-file/x.txt": "",
+	})
+
+	t.Chdir(root)
+	files, err := FindConfigFiles(".")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"kuba.yaml",
+		filepath.FromSlash("services/api/kuba.yaml"),
+		filepath.FromSlash("services/billing/build/kuba.yaml"),
+		filepath.FromSlash("services/billing/kuba.yaml"),
+		filepath.FromSlash("services/web/kuba.yaml"),
+	}, files)
+
+	// Ignore files above the directory still apply
+	t.Chdir(filepath.Join(root, "services", "web"))
+	files, err = FindConfigFiles(".")
+	require.NoError(t, err)
+	require.Equal(t, []string{"kuba.yaml"}, files)
+}
+
+func TestFindConfigFilesOutsideOfGitRepository(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":        "ignored/\n",
+		"a/kuba.yaml":       "",
+		"ignored/kuba.yaml": "",
+	})
+
+	files, err := FindConfigFiles(root)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(root, "a", "kuba.yaml")}, files)
+}
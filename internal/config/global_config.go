@@ -13,16 +13,17 @@ import (
 
 // GlobalConfig represents the global kuba configuration
 type GlobalConfig struct {
-	Cache    cache.CacheConfig `yaml:"cache"`
-	Defaults *DefaultsConfig   `yaml:"defaults,omitempty"`
+	Cache    cache.CacheConfig `yaml:"cache" schema:"cache" doc:"Cache configuration. Can be a boolean, number (seconds), or duration string (e.g., '1d', '2w', '72h', '2y')."`
+	Defaults *DefaultsConfig   `yaml:"defaults,omitempty" doc:"Optional defaults used to pre-fill CLI/TUI prompts."`
+	Policy   []PolicyRule      `yaml:"policy,omitempty" doc:"Rules restricting the environments whose name matches, in every project. Projects can add rules in kuba.yaml but not lift these."`
 }
 
 type DefaultsConfig struct {
-	Providers map[string]ProviderDefaults `yaml:"providers,omitempty"`
+	Providers map[string]ProviderDefaults `yaml:"providers,omitempty" doc:"Provider-specific default settings. Keys must match provider names used in kuba.yaml (e.g. aws, azure, gcp)."`
 }
 
 type ProviderDefaults struct {
-	Regions []string `yaml:"regions,omitempty"`
+	Regions []string `yaml:"regions,omitempty" doc:"Default regions/locations for this provider."`
 }
 
 // UnmarshalYAML implements custom YAML unmarshaling for GlobalConfig
@@ -31,6 +32,7 @@ func (g *GlobalConfig) UnmarshalYAML(value *yaml.Node) error {
 	type rawGlobalConfig struct {
 		Cache    interface{}     `yaml:"cache"`
 		Defaults *DefaultsConfig `yaml:"defaults"`
+		Policy   []PolicyRule    `yaml:"policy"`
 	}
 
 	var raw rawGlobalConfig
@@ -54,6 +56,26 @@ func (g *GlobalConfig) UnmarshalYAML(value *yaml.Node) error {
 				}
 				g.Cache.TTL = duration
 			}
+			if staleValue, ok := cacheValue["stale-if-error"]; ok {
+				duration, _, err := cache.ParseDuration(staleValue)
+				if err != nil {
+					return fmt.Errorf("failed to parse cache stale-if-error: %w", err)
+				}
+				g.Cache.StaleIfError = duration
+			}
+			if staleValue, ok := cacheValue["stale-while-revalidate"]; ok {
+				duration, _, err := cache.ParseDuration(staleValue)
+				if err != nil {
+					return fmt.Errorf("failed to parse cache stale-while-revalidate: %w", err)
+				}
+				g.Cache.StaleWhileRevalidate = duration
+			}
+			if backend, ok := cacheValue["backend"].(string); ok {
+				if !cache.IsValidBackend(backend) {
+					return fmt.Errorf("invalid cache backend '%s'", backend)
+				}
+				g.Cache.Backend = backend
+			}
 		default:
 			// Handle scalar values like "true", "1d", etc.
 			duration, enabled, err := cache.ParseDuration(cacheValue)
@@ -66,6 +88,7 @@ func (g *GlobalConfig) UnmarshalYAML(value *yaml.Node) error {
 	}
 
 	g.Defaults = raw.Defaults
+	g.Policy = raw.Policy
 
 	return nil
 }
@@ -113,6 +136,10 @@ func LoadGlobalConfig() (*GlobalConfig, error) {
 		return nil, fmt.Errorf("failed to parse global configuration: %w", err)
 	}
 
+	for i := range config.Policy {
+		config.Policy[i].Source = SourcePosition{File: configPath}
+	}
+
 	// Validate and set defaults
 	if config.Cache.TTL == 0 {
 		config.Cache.TTL = 12 * time.Hour
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadGlobalConfigParsesStaleCacheSettings(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	cfgDir := filepath.Join(home, ".config", "kuba")
+	if err := os.MkdirAll(cfgDir, 0755); err != nil {
+		t.Fatalf("mkdir config dir: %v", err)
+	}
+	content := []byte(`
+cache:
+  enabled: true
+  ttl: 1d
+  stale-if-error: 7d
+  stale-while-revalidate: 1h
+`)
+	if err := os.WriteFile(filepath.Join(cfgDir, "config.yaml"), content, 0644); err != nil {
+		t.Fatalf("write global config: %v", err)
+	}
+
+	gc, err := LoadGlobalConfig()
+	if err != nil {
+		t.Fatalf("LoadGlobalConfig() error: %v", err)
+	}
+	if gc.Cache.StaleIfError != 7*24*time.Hour {
+		t.Fatalf("unexpected stale-if-error: %v", gc.Cache.StaleIfError)
+	}
+	if gc.Cache.StaleWhileRevalidate != time.Hour {
+		t.Fatalf("unexpected stale-while-revalidate: %v", gc.Cache.StaleWhileRevalidate)
+	}
+	if gc.Cache.Retention() != 7*24*time.Hour {
+		t.Fatalf("unexpected retention: %v", gc.Cache.Retention())
+	}
+}
+
+func TestSaveGlobalConfigRoundTripsCacheDurations(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	gc := DefaultGlobalConfig()
+	gc.Cache.Enabled = true
+	gc.Cache.TTL = 24 * time.Hour
+	gc.Cache.StaleIfError = 7 * 24 * time.Hour
+	if err := SaveGlobalConfig(gc); err != nil {
+		t.Fatalf("SaveGlobalConfig() error: %v", err)
+	}
+
+	loaded, err := LoadGlobalConfig()
+	if err != nil {
+		t.Fatalf("LoadGlobalConfig() error: %v", err)
+	}
+	if !loaded.Cache.Enabled || loaded.Cache.TTL != 24*time.Hour {
+		t.Fatalf("unexpected cache config after save+load: %#v", loaded.Cache)
+	}
+	if loaded.Cache.StaleIfError != 7*24*time.Hour || loaded.Cache.StaleWhileRevalidate != 0 {
+		t.Fatalf("unexpected stale settings after save+load: %#v", loaded.Cache)
+	}
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/kuba/internal/lib/log"
+	"gopkg.in/yaml.v3"
+)
+
+// loadConfigFile parses a configuration file and merges the environments of
+// the files it includes. Environments and env items defined in the file
+// override included ones, later includes override earlier ones. Select rules
+// of included files are evaluated after the file's own rules. stack holds
+// the files that are currently being loaded and is used to detect cycles.
+func loadConfigFile(path string, stack []string) (*KubaConfig, error) {
+	logger := log.NewLogger()
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve path %s: %w", path, err)
+	}
+
+	logger.Debug("Reading configuration file", "path", path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+	logger.Debug("Configuration file read successfully", "size_bytes", len(data))
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file: %s: %w", path, err)
+	}
+
+	config := &KubaConfig{}
+	if len(doc.Content) == 0 {
+		return config, nil
+	}
+	root := doc.Content[0]
+	if err := root.Decode(config); err != nil {
+		return nil, fmt.Errorf("failed to parse configuration file: %s: %w", path, err)
+	}
+	recordSourcePositions(config, root, path)
+	config.diagnostics = checkUnknownKeys(root, path)
+
+	if len(config.Include) == 0 {
+		return config, nil
+	}
+
+	stack = append(stack, absPath)
+	merged := make(map[string]Environment)
+	for i, pattern := range config.Include {
+		pos := SourcePosition{File: path}
+		if node := includeNode(root, i); node != nil {
+			pos = positionOf(path, node)
+		}
+
+		files, err := resolveInclude(path, pattern)
+		if err != nil {
+			return nil, withPosition(pos, err)
+		}
+		for _, file := range files {
+			absFile, err := filepath.Abs(file)
+			if err != nil {
+				return nil, withPosition(pos, fmt.Errorf("failed to resolve include %s: %w", file, err))
+			}
+			for j, loading := range stack {
+				if loading == absFile {
+					cycle := append(append([]string{}, stack[j:]...), absFile)
+					return nil, withPosition(pos, fmt.Errorf("include cycle detected: %s", strings.Join(cycle, " -> ")))
+				}
+			}
+
+			logger.Debug("Including configuration file", "path", file, "included_from", path)
+			included, err := loadConfigFile(file, stack)
+			if err != nil {
+				return nil, err
+			}
+			mergeEnvironments(merged, included.Environments)
+			config.Select = append(config.Select, included.Select...)
+			config.Policy = append(config.Policy, included.Policy...)
+			config.diagnostics = append(config.diagnostics, included.diagnostics...)
+		}
+	}
+	mergeEnvironments(merged, config.Environments)
+	config.Environments = merged
+
+	return config, nil
+}
+
+// includeNode returns the node of the i-th entry of the include list
+func includeNode(root *yaml.Node, i int) *yaml.Node {
+	for k := 0; k+1 < len(root.Content); k += 2 {
+		if root.Content[k].Value != "include" {
+			continue
+		}
+		list := root.Content[k+1]
+		if list.Kind == yaml.SequenceNode && i < len(list.Content) {
+			return list.Content[i]
+		}
+	}
+	return nil
+}
+
+// resolveInclude returns the files matched by an include pattern. Relative
+// patterns are resolved against the directory of the including file, ~/ is
+// expanded to the home directory. Patterns without glob characters have to
+// match an existing file.
+func resolveInclude(from, pattern string) ([]string, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("include must not be empty")
+	}
+
+	if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand %s: %w", pattern, err)
+		}
+		pattern = filepath.Join(home, strings.TrimPrefix(pattern, "~"))
+	} else if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(filepath.Dir(from), pattern)
+	}
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, fmt.Errorf("included file not found: %s", pattern)
+		}
+		return []string{pattern}, nil
+	}
+
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include pattern %s: %w", pattern, err)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// mergeEnvironments merges the environments of src into dst. Settings and env
+// items of src take precedence over those already in dst.
+func mergeEnvironments(dst, src map[string]Environment) {
+	for name, env := range src {
+		base, exists := dst[name]
+		if !exists {
+			dst[name] = env
+			continue
+		}
+
+		if env.Provider != "" {
+			base.Provider = env.Provider
+		}
+		if env.Project != "" {
+			base.Project = env.Project
+		}
+		if env.Inherits != nil {
+			base.Inherits = env.Inherits
+		}
+		if env.Cache != nil {
+			base.Cache = env.Cache
+		}
+		items := make(map[string]EnvItem, len(base.Env)+len(env.Env))
+		for k, v := range base.Env {
+			items[k] = v
+		}
+		for k, v := range env.Env {
+			if v.Validate == nil {
+				v.Validate = items[k].Validate
+			}
+			items[k] = v
+		}
+		base.Env = items
+		base.Source = env.Source
+		dst[name] = base
+	}
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestLoadKubaConfigIncludes(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFile(t, filepath.Join(dir, "shared", "base.yaml"), `
+default:
+  provider: gcp
+  project: shared-project
+  env:
+    DB_PASSWORD:
+      secret-key: db-password
+    LOG_LEVEL:
+      value: info
+staging:
+  provider: local
+  env:
+    LOG_LEVEL:
+      value: debug
+`)
+	writeConfigFile(t, filepath.Join(dir, "shared", "fragments", "a.yaml"), `
+default:
+  provider: gcp
+  env:
+    FEATURE_A:
+      value: "on"
+`)
+	writeConfigFile(t, filepath.Join(dir, "shared", "fragments", "b.yaml"), `
+default:
+  provider: gcp
+  env:
+    FEATURE_A:
+      value: "off"
+`)
+	configPath := filepath.Join(dir, "service", "kuba.yaml")
+	writeConfigFile(t, configPath, `
+include:
+  - ../shared/base.yaml
+  - ../shared/fragments/*.yaml
+default:
+  provider: gcp
+  project: service-project
+  env:
+    LOG_LEVEL:
+      value: warn
+`)
+
+	cfg, err := LoadKubaConfig(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	if _, ok := cfg.Environments["include"]; ok {
+		t.Fatalf("include must not be treated as an environment")
+	}
+	if _, ok := cfg.Environments["staging"]; !ok {
+		t.Errorf("expected staging to be included")
+	}
+
+	env := cfg.Environments["default"]
+	if env.Project != "service-project" {
+		t.Errorf("expected local project to win, got %q", env.Project)
+	}
+	if got := env.Env["LOG_LEVEL"].Value; got != "warn" {
+		t.Errorf("expected local LOG_LEVEL to win, got %v", got)
+	}
+	if got := env.Env["FEATURE_A"].Value; got != "off" {
+		t.Errorf("expected later include to win, got %v", got)
+	}
+
+	item := env.Env["DB_PASSWORD"]
+	if item.SecretKey != "db-password" {
+		t.Errorf("expected DB_PASSWORD from the include, got %+v", item)
+	}
+	if item.Source.File != filepath.Join(dir, "shared", "base.yaml") || item.Source.Line != 6 {
+		t.Errorf("expected DB_PASSWORD to be sourced from base.yaml:6, got %s", item.Source)
+	}
+	if got := env.Env["LOG_LEVEL"].Source.File; got != configPath {
+		t.Errorf("expected LOG_LEVEL to be sourced from %s, got %s", configPath, got)
+	}
+}
+
+func TestLoadKubaConfigIncludeHomeDirectory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	writeConfigFile(t, filepath.Join(home, ".config", "kuba", "team.yaml"), `
+default:
+  provider: local
+  env:
+    TEAM:
+      value: platform
+`)
+	configPath := filepath.Join(t.TempDir(), "kuba.yaml")
+	writeConfigFile(t, configPath, `
+include:
+  - ~/.config/kuba/team.yaml
+`)
+
+	cfg, err := LoadKubaConfig(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if got := cfg.Environments["default"].Env["TEAM"].Value; got != "platform" {
+		t.Errorf("expected TEAM from the home directory include, got %v", got)
+	}
+}
+
+func TestLoadKubaConfigIncludeErrors(t *testing.T) {
+	t.Run("cycle", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfigFile(t, filepath.Join(dir, "a.yaml"), "include:\n  - b.yaml\n")
+		writeConfigFile(t, filepath.Join(dir, "b.yaml"), "include:\n  - a.yaml\n")
+
+		_, err := LoadKubaConfig(filepath.Join(dir, "a.yaml"))
+		if err == nil || !strings.Contains(err.Error(), "include cycle detected") {
+			t.Fatalf("expected cycle error, got %v", err)
+		}
+		if !strings.Contains(err.Error(), filepath.Join(dir, "b.yaml")+":2:5") {
+			t.Errorf("expected error to name the including file and line, got %v", err)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "kuba.yaml")
+		writeConfigFile(t, configPath, "include:\n  - shared.yaml\n  - missing.yaml\n")
+		writeConfigFile(t, filepath.Join(dir, "shared.yaml"), "default:\n  provider: local\n  env:\n    A:\n      value: a\n")
+
+		_, err := LoadKubaConfig(configPath)
+		if err == nil || !strings.Contains(err.Error(), configPath+":3:5: included file not found") {
+			t.Fatalf("expected missing include error with position, got %v", err)
+		}
+	})
+
+	t.Run("parse error in include", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "kuba.yaml")
+		writeConfigFile(t, configPath, "include:\n  - broken.yaml\n")
+		writeConfigFile(t, filepath.Join(dir, "broken.yaml"), "default:\n  provider: [\n")
+
+		_, err := LoadKubaConfig(configPath)
+		if err == nil || !strings.Contains(err.Error(), filepath.Join(dir, "broken.yaml")) {
+			t.Fatalf("expected parse error naming the included file, got %v", err)
+		}
+	})
+
+	t.Run("validation names the included file", func(t *testing.T) {
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, "kuba.yaml")
+		writeConfigFile(t, configPath, "include:\n  - shared.yaml\ndefault:\n  provider: local\n  env:\n    A:\n      value: a\n")
+		writeConfigFile(t, filepath.Join(dir, "shared.yaml"), "default:\n  provider: local\n  env:\n    B:\n      secret-key: b\n")
+
+		_, err := LoadKubaConfig(configPath)
+		if err == nil || !strings.Contains(err.Error(), filepath.Join(dir, "shared.yaml")+":4:5:") {
+			t.Fatalf("expected validation error naming shared.yaml:4:5, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,458 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Interpolation sources reported by TraceInterpolations
+const (
+	InterpolationConfig     = "config"     // another variable of the environment
+	InterpolationOS         = "os"         // the OS environment
+	InterpolationDefault    = "default"    // the default of ${VAR:-default} or ${VAR-default}
+	InterpolationEmpty      = "empty"      // ${VAR:+alt} of an unset variable
+	InterpolationUnresolved = "unresolved" // left as is, or an error for ${VAR:?error}
+)
+
+// Interpolation describes a ${VAR} reference in a value and where it was
+// resolved from
+type Interpolation struct {
+	Expression string `json:"expression"`
+	Variable   string `json:"variable"`
+	Source     string `json:"source"`
+}
+
+var osEnvInterpolation = true
+
+// SetOSEnvInterpolation enables or disables reading the OS environment when
+// interpolating values
+func SetOSEnvInterpolation(enabled bool) {
+	osEnvInterpolation = enabled
+}
+
+// InterpolationError is a reference that could not be interpolated
+type InterpolationError struct {
+	// Variable is the variable whose value contains the reference, if known
+	Variable string
+	Message  string
+}
+
+func (e *InterpolationError) Error() string {
+	if e.Variable == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("variable '%s': %s", e.Variable, e.Message)
+}
+
+// reference is a $VAR or ${VAR...} expression in a value. Operators follow
+// the shell: ":-" and "-" give a default, ":+" and "+" an alternative and
+// ":?" and "?" an error. With a colon an empty variable counts as unset.
+type reference struct {
+	expr string
+	name string
+	op   string
+	word string
+	// plain is the whole content of ${...} when it can be read as a name
+	// with dashes and dots. A variable of that name wins over the operator,
+	// so that ${VAR-WITH-DASHES} keeps working.
+	plain string
+}
+
+// referenceOps are the operators of ${VAR<op>word}, longest first
+var referenceOps = []string{":-", ":+", ":?", "-", "+", "?"}
+
+// segment is a literal piece of a value or a reference
+type segment struct {
+	literal string
+	ref     *reference
+}
+
+// parseInterpolation splits value into literals and references. "$$" is an
+// escaped "$". Anything that does not form a valid reference is kept as a
+// literal.
+func parseInterpolation(value string) []segment {
+	var segments []segment
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			segments = append(segments, segment{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(value); {
+		if value[i] != '$' || i+1 == len(value) {
+			literal.WriteByte(value[i])
+			i++
+			continue
+		}
+		next := value[i+1]
+		switch {
+		case next == '$':
+			literal.WriteByte('$')
+			i += 2
+		case next == '{':
+			ref, n := parseBracedReference(value[i:])
+			if ref == nil {
+				literal.WriteString(value[i : i+2])
+				i += 2
+				continue
+			}
+			flush()
+			segments = append(segments, segment{ref: ref})
+			i += n
+		case isNameStart(next):
+			n := 1 + nameLength(value[i+1:])
+			flush()
+			segments = append(segments, segment{ref: &reference{expr: value[i : i+n], name: value[i+1 : i+n]}})
+			i += n
+		default:
+			literal.WriteByte('$')
+			i++
+		}
+	}
+	flush()
+	return segments
+}
+
+// parseBracedReference parses the ${...} reference at the start of s and
+// returns it with its length, or nil when s does not start with one
+func parseBracedReference(s string) (*reference, int) {
+	end := closingBrace(s[2:])
+	if end < 0 {
+		return nil, 0
+	}
+	content := s[2 : 2+end]
+	ref := &reference{expr: s[:2+end+1]}
+	if isPlainName(content) {
+		ref.plain = content
+	}
+	if n := nameLength(content); n > 0 {
+		rest := content[n:]
+		for _, op := range referenceOps {
+			if strings.HasPrefix(rest, op) {
+				ref.name, ref.op, ref.word = content[:n], op, rest[len(op):]
+				break
+			}
+		}
+		if rest == "" {
+			ref.name = content
+		}
+	}
+	if ref.name == "" && ref.plain == "" {
+		return nil, 0
+	}
+	return ref, len(ref.expr)
+}
+
+// closingBrace returns the index of the brace closing a reference in s,
+// skipping nested references and escapes, or -1 if there is none
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '$':
+			i++
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}' && depth > 0:
+			depth--
+		case s[i] == '}':
+			return i
+		}
+	}
+	return -1
+}
+
+// isPlainName reports whether s is a variable name that may contain dashes
+// and dots
+func isPlainName(s string) bool {
+	if s == "" || !isNameStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if c := s[i]; !isNameStart(c) && !(c >= '0' && c <= '9') && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// nameLength returns the length of the variable name at the start of s
+func nameLength(s string) int {
+	if s == "" || !isNameStart(s[0]) {
+		return 0
+	}
+	n := 1
+	for n < len(s) && (isNameStart(s[n]) || (s[n] >= '0' && s[n] <= '9')) {
+		n++
+	}
+	return n
+}
+
+// interpolator expands references against variables of the configuration,
+// which may be templates referencing each other, and the OS environment.
+//
+// In partial mode references that cannot be resolved yet are kept, as are
+// escapes, so that the result can be interpolated again once secrets have
+// been fetched. A result that still has such references is pending.
+type interpolator struct {
+	// templates are variables whose values are interpolated themselves
+	templates map[string]string
+	// values are variables used as they are
+	values map[string]string
+	// deferred are variables only known later, e.g. secrets, used in partial mode
+	deferred map[string]bool
+	partial  bool
+
+	results   map[string]result
+	resolving []string
+}
+
+type result struct {
+	text    string
+	pending bool
+	err     error
+}
+
+func newInterpolator(templates, values map[string]string) *interpolator {
+	return &interpolator{templates: templates, values: values, results: make(map[string]result)}
+}
+
+// variable returns the value of a variable, whether it is set and whether it
+// is still pending. A template referencing itself, as in PATH=/bin:${PATH},
+// gets the value from outside of the configuration.
+func (in *interpolator) variable(name string) (string, bool, bool, error) {
+	self := len(in.resolving) > 0 && in.resolving[len(in.resolving)-1] == name
+	if _, ok := in.templates[name]; ok && !self {
+		r := in.template(name)
+		return r.text, true, r.pending, r.err
+	}
+	if value, ok := in.values[name]; ok {
+		return in.escape(value), true, false, nil
+	}
+	if in.partial && in.deferred[name] {
+		return "", false, true, nil
+	}
+	if osEnvInterpolation {
+		if value, ok := os.LookupEnv(name); ok {
+			return in.escape(value), true, false, nil
+		}
+	}
+	return "", false, false, nil
+}
+
+// template interpolates the template of a variable once
+func (in *interpolator) template(name string) result {
+	if r, ok := in.results[name]; ok {
+		return r
+	}
+	for i, resolving := range in.resolving {
+		if resolving == name {
+			cycle := append(append([]string{}, in.resolving[i:]...), name)
+			return result{err: &InterpolationError{
+				Variable: in.resolving[0],
+				Message:  "interpolation cycle: " + strings.Join(cycle, " -> "),
+			}}
+		}
+	}
+
+	in.resolving = append(in.resolving, name)
+	text, pending, err := in.expand(in.templates[name])
+	in.resolving = in.resolving[:len(in.resolving)-1]
+
+	var interpolationErr *InterpolationError
+	if err != nil && !errors.As(err, &interpolationErr) {
+		err = &InterpolationError{Variable: name, Message: err.Error()}
+	}
+	r := result{text: text, pending: pending, err: err}
+	in.results[name] = r
+	return r
+}
+
+// escape makes a value safe to be inserted into a partial result
+func (in *interpolator) escape(value string) string {
+	if in.partial {
+		return strings.ReplaceAll(value, "$", "$$")
+	}
+	return value
+}
+
+// expand interpolates all references in s
+func (in *interpolator) expand(s string) (string, bool, error) {
+	var out strings.Builder
+	pending := false
+	for _, seg := range parseInterpolation(s) {
+		if seg.ref == nil {
+			out.WriteString(in.escape(seg.literal))
+			continue
+		}
+		text, p, err := in.reference(seg.ref)
+		if err != nil {
+			return "", false, err
+		}
+		out.WriteString(text)
+		pending = pending || p
+	}
+	return out.String(), pending, nil
+}
+
+// reference interpolates a single reference. Unset variables without an
+// operator are left as they are.
+func (in *interpolator) reference(ref *reference) (string, bool, error) {
+	if ref.plain != "" && ref.plain != ref.name {
+		value, set, pending, err := in.variable(ref.plain)
+		switch {
+		case err != nil:
+			return "", false, err
+		case pending:
+			return ref.expr, true, nil
+		case set:
+			return value, false, nil
+		case ref.name == "":
+			return ref.expr, in.partial, nil
+		}
+	}
+
+	value, set, pending, err := in.variable(ref.name)
+	if err != nil {
+		return "", false, err
+	}
+	if pending {
+		return ref.expr, true, nil
+	}
+	if strings.HasPrefix(ref.op, ":") && value == "" {
+		set = false
+	}
+
+	switch strings.TrimPrefix(ref.op, ":") {
+	case "":
+		if !set {
+			return ref.expr, in.partial, nil
+		}
+		return value, false, nil
+	case "-":
+		if !set {
+			return in.expand(ref.word)
+		}
+		return value, false, nil
+	case "+":
+		if !set {
+			return "", false, nil
+		}
+		return in.expand(ref.word)
+	default: // "?"
+		if set {
+			return value, false, nil
+		}
+		if in.partial {
+			return ref.expr, true, nil
+		}
+		message, _, err := in.expand(ref.word)
+		if err != nil {
+			return "", false, err
+		}
+		if message == "" {
+			message = "is not set"
+			if ref.op == ":?" {
+				message = "is not set or empty"
+			}
+		}
+		return "", false, fmt.Errorf("%s: %s", ref.name, message)
+	}
+}
+
+// InterpolateEnvVars replaces $VAR and ${VAR} references in value with
+// resolvedVars, falling back to the OS environment unless it is disabled
+// with SetOSEnvInterpolation. It supports ${VAR:-default}, ${VAR-default},
+// ${VAR:+alt}, ${VAR+alt}, ${VAR:?error} and ${VAR?error}; "$$" is a literal
+// "$". References to unset variables are left as they are.
+func InterpolateEnvVars(value string, resolvedVars map[string]string) (string, error) {
+	text, _, err := newInterpolator(nil, resolvedVars).expand(value)
+	return text, err
+}
+
+// InterpolateValues interpolates templates that may reference each other as
+// well as vars and the OS environment, like InterpolateEnvVars. Cycles are
+// reported as an InterpolationError.
+func InterpolateValues(templates, vars map[string]string) (map[string]string, error) {
+	in := newInterpolator(templates, vars)
+	interpolated := make(map[string]string, len(templates))
+	for _, name := range sortedKeys(templates) {
+		r := in.template(name)
+		if r.err != nil {
+			return nil, r.err
+		}
+		interpolated[name] = r.text
+	}
+	return interpolated, nil
+}
+
+// unescapeInterpolation turns a partial result without references into
+// its final text
+func unescapeInterpolation(s string) string {
+	return strings.ReplaceAll(s, "$$", "$")
+}
+
+// TraceInterpolations reports the references in value, following the
+// lookup order of InterpolateEnvVars: resolved variables first, then the OS
+// environment, then the operator of the reference
+func TraceInterpolations(value string, resolvedVars map[string]string) []Interpolation {
+	var traces []Interpolation
+	for _, seg := range parseInterpolation(value) {
+		ref := seg.ref
+		if ref == nil {
+			continue
+		}
+
+		// A variable named like the whole reference wins, see reference.plain
+		if ref.plain != "" && ref.plain != ref.name {
+			_, source := lookupVariable(ref.plain, resolvedVars)
+			if source != "" || ref.name == "" {
+				if source == "" {
+					source = InterpolationUnresolved
+				}
+				traces = append(traces, Interpolation{Expression: ref.expr, Variable: ref.plain, Source: source})
+				continue
+			}
+		}
+
+		trace := Interpolation{Expression: ref.expr, Variable: ref.name, Source: InterpolationUnresolved}
+		value, source := lookupVariable(ref.name, resolvedVars)
+		if strings.HasPrefix(ref.op, ":") && value == "" {
+			source = ""
+		}
+		switch {
+		case source != "":
+			trace.Source = source
+		case strings.HasSuffix(ref.op, "-"):
+			trace.Source = InterpolationDefault
+		case strings.HasSuffix(ref.op, "+"):
+			trace.Source = InterpolationEmpty
+		}
+		traces = append(traces, trace)
+	}
+	return traces
+}
+
+// lookupVariable returns the value of a variable and where it is set, or an
+// empty source if it is not set
+func lookupVariable(name string, resolvedVars map[string]string) (string, string) {
+	if value, ok := resolvedVars[name]; ok {
+		return value, InterpolationConfig
+	}
+	if !osEnvInterpolation {
+		return "", ""
+	}
+	if value, ok := os.LookupEnv(name); ok {
+		return value, InterpolationOS
+	}
+	return "", ""
+}
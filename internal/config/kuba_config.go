@@ -1,29 +1,52 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
-	"strings"
+	"sort"
 
 	"github.com/mistweaverco/kuba/internal/lib/cache"
 	"github.com/mistweaverco/kuba/internal/lib/log"
 	"gopkg.in/yaml.v3"
 )
 
+// DefaultProject is the project key used for providers that don't use
+// projects in the same way as GCP, when no project is configured
+const DefaultProject = "default"
+
 // KubaConfig represents the structure of a kuba.yaml file
 type KubaConfig struct {
-	Environments map[string]Environment `yaml:",inline"`
+	// Include lists configuration files whose environments are merged into
+	// this one; see loadConfigFile
+	Include []string `yaml:"include,omitempty" doc:"Configuration files whose environments are merged into this file. Paths are relative to this file, may contain globs and may start with ~/. Definitions in this file win."`
+	// Select picks the environment when none is given explicitly; see
+	// SelectEnvironment
+	Select []SelectRule `yaml:"select,omitempty" doc:"Rules selecting the environment by git branch or directory when neither --env nor KUBA_ENV is given. The first matching rule wins."`
+	// Policy restricts matching environments; see checkPolicy and PolicyFor
+	Policy       []PolicyRule           `yaml:"policy,omitempty" doc:"Rules restricting the environments whose name matches. They apply together with the policy rules of the global configuration."`
+	Environments map[string]Environment `yaml:",inline" keys:"^[a-zA-Z0-9_-]+$"`
+
+	// globalPolicy are the policy rules of the global configuration
+	globalPolicy []PolicyRule
+
+	// diagnostics are problems found while parsing, such as unknown keys,
+	// that are reported together with the validation errors
+	diagnostics []Diagnostic
 }
 
 // Environment represents a single environment configuration
 type Environment struct {
-	Provider string             `yaml:"provider"`
-	Project  string             `yaml:"project"`
-	Env      map[string]EnvItem `yaml:"env"`
-	Inherits []string           `yaml:"inherits,omitempty"`
-	Cache    *cache.CacheConfig `yaml:"cache,omitempty"`
+	Provider string             `yaml:"provider" schema:"provider" doc:"The default cloud provider for this environment."`
+	Project  string             `yaml:"project" schema:"string-or-integer" doc:"The default cloud project for this environment. This can be a string or an integer."`
+	Env      map[string]EnvItem `yaml:"env" keys:"^[A-Z0-9_]+$" doc:"Mapping of environment variable name to configuration object."`
+	Inherits []string           `yaml:"inherits,omitempty" schema:"string-or-list" doc:"One or more environment names to inherit variables, provider, project and cache settings from (in order)."`
+	Cache    *cache.CacheConfig `yaml:"cache,omitempty" schema:"duration" doc:"Cache configuration for this environment. Can be a boolean, number (seconds), or duration string (e.g., '1d', '2w', '72h', '2y')."`
+
+	// Source is where the environment is defined
+	Source SourcePosition `yaml:"-"`
 }
 
 // UnmarshalYAML implements custom YAML unmarshaling for Environment to support
@@ -87,12 +110,28 @@ func (e *Environment) UnmarshalYAML(value *yaml.Node) error {
 // It can be either a string (just the env var name) or a full mapping object
 type EnvItem struct {
 	// For string format: just the environment variable name
-	EnvironmentVariable string `yaml:"environment-variable,omitempty"`
-	SecretKey           string `yaml:"secret-key,omitempty"`
-	SecretPath          string `yaml:"secret-path,omitempty"`
-	Value               any    `yaml:"value,omitempty"`
-	Provider            string `yaml:"provider,omitempty"`
-	Project             string `yaml:"project,omitempty"`
+	EnvironmentVariable string           `yaml:"environment-variable,omitempty" schema:"-"`
+	SecretKey           string           `yaml:"secret-key,omitempty" doc:"Name of the secret to fetch from the provider."`
+	SecretPath          string           `yaml:"secret-path,omitempty" doc:"Path under which all secrets are fetched from the provider."`
+	Value               any              `yaml:"value,omitempty" schema:"string-or-integer" doc:"Literal value, may reference other variables with ${VAR}."`
+	Provider            string           `yaml:"provider,omitempty" schema:"provider" doc:"Provider for this variable, overriding the environment's provider."`
+	Project             string           `yaml:"project,omitempty" schema:"string-or-integer" doc:"Project for this variable, overriding the environment's project."`
+	Validate            *ValueValidation `yaml:"validate,omitempty" doc:"Rules the resolved value has to satisfy."`
+
+	// DefinedIn is the environment that defines the item, which differs from
+	// the environment it belongs to when it was inherited
+	DefinedIn string `yaml:"-"`
+	// RawValue is the value as written in kuba.yaml, set when interpolation
+	// changed it while loading the configuration
+	RawValue string `yaml:"-"`
+	// Source is where the item is defined, which may be an included file
+	Source SourcePosition `yaml:"-"`
+	// Override is set for items from the local override file
+	Override bool `yaml:"-"`
+	// Interpolated is set when Value was fully interpolated while loading the
+	// configuration. Otherwise it still references secrets and is
+	// interpolated once they have been fetched.
+	Interpolated bool `yaml:"-"`
 }
 
 // UnmarshalYAML implements custom YAML unmarshaling for EnvItem
@@ -100,11 +139,12 @@ type EnvItem struct {
 func (e *EnvItem) UnmarshalYAML(value *yaml.Node) error {
 	// For map syntax, the env var name is the map key; object holds fields only
 	var temp struct {
-		SecretKey  string `yaml:"secret-key,omitempty"`
-		SecretPath string `yaml:"secret-path,omitempty"`
-		Value      any    `yaml:"value,omitempty"`
-		Provider   string `yaml:"provider,omitempty"`
-		Project    string `yaml:"project,omitempty"`
+		SecretKey  string           `yaml:"secret-key,omitempty"`
+		SecretPath string           `yaml:"secret-path,omitempty"`
+		Value      any              `yaml:"value,omitempty"`
+		Provider   string           `yaml:"provider,omitempty"`
+		Project    string           `yaml:"project,omitempty"`
+		Validate   *ValueValidation `yaml:"validate,omitempty"`
 	}
 	if err := value.Decode(&temp); err != nil {
 		return err
@@ -114,6 +154,7 @@ func (e *EnvItem) UnmarshalYAML(value *yaml.Node) error {
 	e.Value = temp.Value
 	e.Provider = temp.Provider
 	e.Project = temp.Project
+	e.Validate = temp.Validate
 	return nil
 }
 
@@ -127,275 +168,217 @@ func (e *Environment) GetEnvItems() []EnvItem {
 	return items
 }
 
-// InterpolateEnvVars replaces ${VAR_NAME} patterns with actual environment variable values
-// It also supports previously resolved variables from the same configuration
-// Supports both ${VAR_NAME} and ${VAR_NAME:-default} syntax
-func InterpolateEnvVars(value string, resolvedVars map[string]string) string {
-	// Regex to match ${VAR_NAME} and ${VAR_NAME:-default} patterns
-	re := regexp.MustCompile(`\$\{([^}]+)\}`)
-
-	// Keep interpolating until no more changes are made to handle nested variables
-	prevValue := ""
-	for prevValue != value {
-		prevValue = value
-		value = re.ReplaceAllStringFunc(value, func(match string) string {
-			// Extract the variable name and optional default from ${VAR_NAME} or ${VAR_NAME:-default}
-			content := match[2 : len(match)-1]
-
-			// Check if there's a default value specified
-			if strings.Contains(content, ":-") {
-				parts := strings.SplitN(content, ":-", 2)
-				varName := parts[0]
-				defaultValue := parts[1]
-
-				// First check if we have this variable from previously resolved mappings
-				if resolvedValue, exists := resolvedVars[varName]; exists {
-					return resolvedValue
-				}
-
-				// Then check if it's an environment variable
-				if envValue := os.Getenv(varName); envValue != "" {
-					return envValue
-				}
+// processValueInterpolations interpolates the values of the env items, which
+// may reference each other and the OS environment, and then the secret-key,
+// secret-path and project fields, which may reference the values. References
+// to secrets are kept until the secrets have been fetched. Problems are
+// collected as diagnostics.
+func processValueInterpolations(config *KubaConfig) error {
+	for _, envName := range sortedKeys(config.Environments) {
+		env := config.Environments[envName]
 
-				// If not found, return the default value
-				return defaultValue
+		reported := make(map[string]bool)
+		report := func(variable string, err error) {
+			var interpolationErr *InterpolationError
+			if errors.As(err, &interpolationErr) && interpolationErr.Variable != "" {
+				variable = interpolationErr.Variable
 			}
-
-			// No default value specified, use original logic
-			varName := content
-
-			// First check if we have this variable from previously resolved mappings
-			if resolvedValue, exists := resolvedVars[varName]; exists {
-				return resolvedValue
+			if reported[err.Error()] {
+				return
 			}
-
-			// Then check if it's an environment variable
-			if envValue := os.Getenv(varName); envValue != "" {
-				return envValue
+			reported[err.Error()] = true
+			message := err.Error()
+			if interpolationErr != nil {
+				message = interpolationErr.Message
 			}
+			config.diagnostics = append(config.diagnostics, Diagnostic{
+				Severity:    SeverityError,
+				Rule:        RuleInterpolation,
+				Position:    env.Env[variable].Source,
+				Environment: envName,
+				Variable:    variable,
+				Message:     message,
+			})
+		}
 
-			// If not found, return the original pattern (could be useful for debugging)
-			return match
-		})
-	}
-
-	return value
-}
-
-// interpolateConfigVars replaces ${VAR_NAME} patterns with resolved variables from configuration only
-// This version does not check system environment variables, only the resolvedVars map
-func interpolateConfigVars(value string, resolvedVars map[string]string) string {
-	// Regex to match ${VAR_NAME} and ${VAR_NAME:-default} patterns
-	re := regexp.MustCompile(`\$\{([^}]+)\}`)
-
-	// Keep interpolating until no more changes are made to handle nested variables
-	prevValue := ""
-	for prevValue != value {
-		prevValue = value
-		value = re.ReplaceAllStringFunc(value, func(match string) string {
-			// Extract the variable name and optional default from ${VAR_NAME} or ${VAR_NAME:-default}
-			content := match[2 : len(match)-1]
-
-			// Check if there's a default value specified
-			if strings.Contains(content, ":-") {
-				parts := strings.SplitN(content, ":-", 2)
-				varName := parts[0]
-				defaultValue := parts[1]
-
-				// Check if we have this variable from previously resolved mappings
-				if resolvedValue, exists := resolvedVars[varName]; exists {
-					return resolvedValue
-				}
-
-				// If not found, return the default value
-				return defaultValue
+		templates := make(map[string]string)
+		deferred := make(map[string]bool)
+		for name, envItem := range env.Env {
+			if envItem.Value == nil {
+				deferred[name] = true
+				continue
 			}
+			templates[name] = valueString(envItem.Value)
+		}
 
-			// No default value specified, use original logic
-			varName := content
-
-			// Check if we have this variable from previously resolved mappings
-			if resolvedValue, exists := resolvedVars[varName]; exists {
-				return resolvedValue
+		values := newInterpolator(templates, nil)
+		values.partial = true
+		values.deferred = deferred
+		final := make(map[string]string)
+		for _, name := range sortedKeys(templates) {
+			r := values.template(name)
+			if r.err != nil {
+				report(name, r.err)
+				continue
 			}
-
-			// If not found, return the original pattern (could be useful for debugging)
-			return match
-		})
-	}
-
-	return value
-}
-
-// processValueInterpolations processes all value fields in env items to resolve environment variable interpolations
-func processValueInterpolations(config *KubaConfig) error {
-	// Process environments in order to handle dependencies correctly
-	// We'll process each environment multiple times until no more interpolations are possible
-	// or until we detect a circular dependency
-
-	for envName, env := range config.Environments {
-		// Track resolved variables for this environment
-		resolvedVars := make(map[string]string)
-
-		// Process env items multiple times to handle dependencies
-		maxIterations := len(env.Env) * 2 // Allow for some dependency depth
-		for iteration := 0; iteration < maxIterations; iteration++ {
-			changed := false
-
-			// Process env items
-			for name, envItem := range env.Env {
-				if envItem.Value != nil {
-					// Convert value to string for processing
-					var strValue string
-					switch v := envItem.Value.(type) {
-					case string:
-						strValue = v
-					case int, int32, int64:
-						strValue = fmt.Sprintf("%d", v)
-					case float32, float64:
-						strValue = fmt.Sprintf("%g", v)
-					default:
-						strValue = fmt.Sprintf("%v", v)
-					}
-
-					// Check if this value contains interpolation patterns
-					if strings.Contains(strValue, "${") {
-						// Interpolate the value
-						interpolatedValue := InterpolateEnvVars(strValue, resolvedVars)
-
-						// If the value changed, update it
-						if interpolatedValue != strValue {
-							// Update the env item value
-							tmp := env.Env[name]
-							tmp.Value = interpolatedValue
-							env.Env[name] = tmp
-							// Update our resolved vars map
-							resolvedVars[name] = interpolatedValue
-							changed = true
-						}
-					} else {
-						// No interpolation needed, but convert numeric values to strings for consistency
-						if envItem.Value != strValue {
-							tmp := env.Env[name]
-							tmp.Value = strValue
-							env.Env[name] = tmp
-							changed = true
-						}
-						// Store the value in resolved vars and trigger another iteration
-						// only if this introduces a new mapping or changes an existing one.
-						prev, exists := resolvedVars[name]
-						resolvedVars[name] = strValue
-						if !exists || prev != strValue {
-							changed = true
-						}
-					}
-				}
+			envItem := env.Env[name]
+			envItem.Value = r.text
+			if !r.pending {
+				envItem.Value = unescapeInterpolation(r.text)
+				envItem.Interpolated = true
+				final[name] = envItem.Value.(string)
 			}
-
-			// If no changes were made in this iteration, we're done
-			if !changed {
-				break
+			if envItem.Value != templates[name] && envItem.RawValue == "" {
+				envItem.RawValue = templates[name]
 			}
+			env.Env[name] = envItem
 		}
 
-		// After env item values are processed, interpolate other string fields that may reference them
-		// Build a map of resolved variables from the now-updated env values
-		resolvedVars = make(map[string]string)
-		for name, envItem := range env.Env {
-			if envItem.Value != nil {
-				resolvedVars[name] = fmt.Sprintf("%v", envItem.Value)
+		// Values still waiting for secrets are left as they are
+		fields := newInterpolator(nil, final)
+		interpolate := func(variable, field string) string {
+			if field == "" {
+				return field
 			}
-		}
-
-		// Interpolate environment-level project field
-		if env.Project != "" && strings.Contains(env.Project, "${") {
-			interpolated := InterpolateEnvVars(env.Project, resolvedVars)
-			if interpolated != env.Project {
-				env.Project = interpolated
+			text, _, err := fields.expand(field)
+			if err != nil {
+				report(variable, err)
+				return field
 			}
+			return text
 		}
 
-		// Interpolate item-level fields that can be strings
+		env.Project = interpolate("", env.Project)
 		for name, envItem := range env.Env {
-			// secret-key
-			if envItem.SecretKey != "" && strings.Contains(envItem.SecretKey, "${") {
-				envItem.SecretKey = InterpolateEnvVars(envItem.SecretKey, resolvedVars)
-			}
-			// secret-path
-			if envItem.SecretPath != "" && strings.Contains(envItem.SecretPath, "${") {
-				envItem.SecretPath = InterpolateEnvVars(envItem.SecretPath, resolvedVars)
-			}
-			// project (item-level)
-			if envItem.Project != "" && strings.Contains(envItem.Project, "${") {
-				envItem.Project = InterpolateEnvVars(envItem.Project, resolvedVars)
-			}
+			envItem.SecretKey = interpolate(name, envItem.SecretKey)
+			envItem.SecretPath = interpolate(name, envItem.SecretPath)
+			envItem.Project = interpolate(name, envItem.Project)
 			env.Env[name] = envItem
 		}
 
-		// Update the environment in the config
 		config.Environments[envName] = env
 	}
 
 	return nil
 }
 
-// resolveInheritance merges env variables from inherited environments into each environment.
-// Inheritance is processed in order; later entries in "inherits" override earlier ones only
-// if the current environment does not provide an explicit override. Current environment values
-// always take precedence over inherited ones. Cycles are detected and reported.
+// valueString returns a value of kuba.yaml as a string
+func valueString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int, int32, int64:
+		return fmt.Sprintf("%d", v)
+	case float32, float64:
+		return fmt.Sprintf("%g", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// resolveInheritance merges inherited environments into each environment.
+// Provider, project and cache settings are inherited from the first parent in
+// "inherits" that sets them, unless the environment sets them itself; the
+// project is only taken from parents with the same provider. Env
+// items are merged in order; earlier entries in "inherits" win over later ones
+// and the environment's own items always take precedence. Inherited items keep
+// the effective provider and project of the environment that defines them.
+// Cycles are detected and reported.
 func resolveInheritance(config *KubaConfig) error {
-	// Memoize resolved env maps to avoid re-computation
-	resolved := make(map[string]map[string]EnvItem)
+	// Memoize resolved environments to avoid re-computation
+	resolved := make(map[string]Environment)
 	resolving := make(map[string]bool)
 
-	var resolveEnv func(name string) (map[string]EnvItem, error)
-	resolveEnv = func(name string) (map[string]EnvItem, error) {
+	var resolveEnv func(name string) (Environment, error)
+	resolveEnv = func(name string) (Environment, error) {
 		if env, ok := resolved[name]; ok {
 			return env, nil
 		}
 		if resolving[name] {
-			return nil, fmt.Errorf("inheritance cycle detected involving environment '%s'", name)
+			return Environment{}, fmt.Errorf("inheritance cycle detected involving environment '%s'", name)
 		}
 		base, ok := config.Environments[name]
 		if !ok {
-			return nil, fmt.Errorf("inherits references unknown environment '%s'", name)
+			return Environment{}, fmt.Errorf("inherits references unknown environment '%s'", name)
 		}
 		resolving[name] = true
 
-		// Start with an empty map, merge inherited in order
-		merged := make(map[string]EnvItem)
+		parents := make([]Environment, 0, len(base.Inherits))
 		for _, parentName := range base.Inherits {
-			parentEnv, err := resolveEnv(parentName)
+			parent, err := resolveEnv(parentName)
 			if err != nil {
-				return nil, err
+				return Environment{}, err
+			}
+			parents = append(parents, parent)
+		}
+
+		// Environment-level settings of the environment itself win. A project
+		// is only inherited from parents using the same provider.
+		for _, parent := range parents {
+			if base.Provider == "" {
+				base.Provider = parent.Provider
 			}
-			// Merge from parent; do not overwrite existing keys
-			for k, v := range parentEnv {
-				if _, exists := merged[k]; !exists {
-					merged[k] = v
+		}
+		for _, parent := range parents {
+			if base.Project == "" && parent.Provider == base.Provider {
+				base.Project = parent.Project
+			}
+			if base.Cache == nil && parent.Cache != nil {
+				cacheConfig := *parent.Cache
+				base.Cache = &cacheConfig
+			}
+		}
+
+		// Merge items from the parents; do not overwrite existing keys
+		merged := make(map[string]EnvItem)
+		for _, parent := range parents {
+			for k, v := range parent.Env {
+				if _, exists := merged[k]; exists {
+					continue
+				}
+				provider := v.Provider
+				if provider == "" {
+					provider = parent.Provider
+				}
+				if provider != base.Provider {
+					v.Provider = provider
 				}
+				if v.Project == "" {
+					switch {
+					case parent.Project != base.Project && parent.Project != "":
+						v.Project = parent.Project
+					case parent.Project == "" && base.Project != "" && provider != base.Provider:
+						// The item must not pick up the project of another
+						// provider, it keeps using no project
+						v.Project = DefaultProject
+					}
+				}
+				merged[k] = v
 			}
 		}
 
-		// Finally, overlay current environment's own variables (override parents)
+		// Finally, overlay current environment's own variables (override parents).
+		// Validation rules are kept unless the variable defines its own.
 		for k, v := range base.Env {
+			v.DefinedIn = name
+			if v.Validate == nil {
+				v.Validate = merged[k].Validate
+			}
 			merged[k] = v
 		}
+		base.Env = merged
 
 		resolving[name] = false
-		resolved[name] = merged
-		return merged, nil
+		resolved[name] = base
+		return base, nil
 	}
 
-	// Resolve for all environments and write back the merged maps
+	// Resolve for all environments and write back the merged environments
 	for envName := range config.Environments {
-		merged, err := resolveEnv(envName)
+		env, err := resolveEnv(envName)
 		if err != nil {
 			return err
 		}
-		env := config.Environments[envName]
-		env.Env = merged
 		config.Environments[envName] = env
 	}
 	return nil
@@ -417,29 +400,23 @@ func LoadKubaConfig(configPath string) (*KubaConfig, error) {
 		return nil, fmt.Errorf("configuration file not found: %s", configPath)
 	}
 
-	// Read file
-	logger.Debug("Reading configuration file")
-	data, err := os.ReadFile(configPath)
+	// Read and parse the file and everything it includes
+	config, err := loadConfigFile(configPath, nil)
 	if err != nil {
-		logger.Debug("Failed to read configuration file", "path", configPath, "error", err)
-		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+		logger.Debug("Failed to load configuration file", "path", configPath, "error", err)
+		return nil, err
 	}
 
-	logger.Debug("Configuration file read successfully", "size_bytes", len(data))
-
-	// Parse YAML
-	logger.Debug("Parsing YAML configuration")
-	var config KubaConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		logger.Debug("Failed to parse YAML configuration", "error", err)
-		return nil, fmt.Errorf("failed to parse configuration file: %w", err)
+	if err := applyLocalOverride(config, configPath); err != nil {
+		logger.Debug("Failed to apply local override file", "error", err)
+		return nil, err
 	}
 
 	logger.Debug("YAML parsed successfully", "environments_count", len(config.Environments))
 
 	// Resolve inheritance before any interpolations or validation
 	logger.Debug("Resolving environment inheritance")
-	if err := resolveInheritance(&config); err != nil {
+	if err := resolveInheritance(config); err != nil {
 		logger.Debug("Failed to resolve environment inheritance", "error", err)
 		return nil, fmt.Errorf("failed to resolve inheritance: %w", err)
 	}
@@ -447,21 +424,27 @@ func LoadKubaConfig(configPath string) (*KubaConfig, error) {
 
 	// Process environment variable interpolations
 	logger.Debug("Processing environment variable interpolations")
-	if err := processValueInterpolations(&config); err != nil {
+	if err := processValueInterpolations(config); err != nil {
 		logger.Debug("Failed to process environment variable interpolations", "error", err)
 		return nil, fmt.Errorf("failed to process environment variable interpolations: %w", err)
 	}
 	logger.Debug("Environment variable interpolations processed successfully")
 
+	globalPolicy, err := loadGlobalPolicy()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the global policy: %w", err)
+	}
+	config.globalPolicy = globalPolicy
+
 	// Validate configuration
 	logger.Debug("Validating configuration")
-	if err := validateConfig(&config); err != nil {
+	if err := validateConfig(config); err != nil {
 		logger.Debug("Configuration validation failed", "error", err)
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
 	logger.Debug("Configuration validation passed")
-	return &config, nil
+	return config, nil
 }
 
 // GetEnvironment returns the configuration for a specific environment
@@ -494,32 +477,67 @@ func getEnvironmentNames(environments map[string]Environment) []string {
 	return names
 }
 
-// validateConfig validates the configuration structure
+// validateConfig validates the configuration structure. It reports all
+// problems at once as a *ValidationError.
 func validateConfig(config *KubaConfig) error {
+	diagnostics := append([]Diagnostic{}, config.diagnostics...)
+	report := func(rule string, pos SourcePosition, envName, variable, format string, args ...any) {
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity:    SeverityError,
+			Rule:        rule,
+			Position:    pos,
+			Environment: envName,
+			Variable:    variable,
+			Message:     fmt.Sprintf(format, args...),
+		})
+	}
+
 	if len(config.Environments) == 0 {
-		return fmt.Errorf("no environments defined in configuration")
+		report(RuleNoEnvironments, SourcePosition{}, "", "", "no environments defined in configuration")
+	}
+
+	for _, rule := range config.Select {
+		if rule.Env == "" {
+			report(RuleSelect, rule.Source, "", "", "select rule: env is required")
+		} else if _, ok := config.Environments[rule.Env]; !ok {
+			report(RuleSelect, rule.Source, "", "", "select rule: unknown environment '%s'", rule.Env)
+		}
+		if rule.Branch == "" && rule.Path == "" {
+			report(RuleSelect, rule.Source, "", "", "select rule: branch or path is required")
+		}
+		if _, err := path.Match(rule.Branch, ""); err != nil {
+			report(RuleSelect, rule.Source, "", "", "select rule: invalid branch pattern '%s'", rule.Branch)
+		}
+		if _, err := path.Match(rule.Path, ""); err != nil {
+			report(RuleSelect, rule.Source, "", "", "select rule: invalid path pattern '%s'", rule.Path)
+		}
 	}
 
-	for envName, env := range config.Environments {
+	for _, envName := range sortedKeys(config.Environments) {
+		env := config.Environments[envName]
 		if env.Provider == "" {
-			return fmt.Errorf("environment '%s': provider is required", envName)
+			report(RuleProvider, env.Source, envName, "", "provider is required")
+		} else if !isValidProvider(env.Provider) {
+			report(RuleProvider, env.Source, envName, "", "invalid provider '%s'", env.Provider)
 		}
 
-		// Project is required for all providers except AWS, Azure, OpenBao, Bitwarden, and local
-		if env.Project == "" && env.Provider != "aws" && env.Provider != "azure" && env.Provider != "openbao" && env.Provider != "bitwarden" && env.Provider != "local" {
-			return fmt.Errorf("environment '%s': project is required for provider '%s'", envName, env.Provider)
+		// Project is required for providers that look up secrets per project
+		if info, ok := LookupProvider(env.Provider); ok && info.RequiresProject && env.Project == "" {
+			report(RuleProject, env.Source, envName, "", "project is required for provider '%s'", env.Provider)
 		}
 
 		// At least one env item must be provided, possibly via inheritance
 		if len(env.Env) == 0 {
-			return fmt.Errorf("environment '%s': at least one env item is required (directly or via inherits)", envName)
+			report(RuleEmptyEnv, env.Source, envName, "", "at least one env item is required (directly or via inherits)")
 		}
 
 		// Validate env items
-		idx := 0
-		for _, envItem := range env.Env {
-			idx++
-			// name is the environment variable
+		for _, name := range sortedKeys(env.Env) {
+			envItem := env.Env[name]
+			// Inherited items are reported for the environment defining them
+			if envItem.DefinedIn != "" && envItem.DefinedIn != envName {
+				continue
+			}
 
 			// Either secret-key, secret-path, or value must be provided (no bare items)
 			// Special case: for local provider (env-level or item-level), only value is allowed
@@ -534,54 +552,66 @@ func validateConfig(config *KubaConfig) error {
 				secretFields++
 			}
 
-			if secretFields == 0 {
-				return fmt.Errorf("environment '%s': env item %d: either secret-key, secret-path, or value is required", envName, idx)
-			}
-
-			if secretFields > 1 {
-				return fmt.Errorf("environment '%s': env item %d: cannot specify multiple of secret-key, secret-path, or value", envName, idx)
-			}
-
 			// Determine effective provider for this item
 			effectiveProvider := env.Provider
 			if envItem.Provider != "" {
 				effectiveProvider = envItem.Provider
 			}
+			info, _ := LookupProvider(effectiveProvider)
+
+			if secretFields == 0 {
+				if info.ValueOnly {
+					report(RuleLocalProvider, envItem.Source, envName, name, "provider '%s' requires 'value'", info.Name)
+				} else {
+					report(RuleSecretReference, envItem.Source, envName, name, "either secret-key, secret-path, or value is required")
+				}
+			}
+
+			if secretFields > 1 {
+				report(RuleSecretReference, envItem.Source, envName, name, "cannot specify multiple of secret-key, secret-path, or value")
+			}
 
 			// Validate provider value if set on item
 			if envItem.Provider != "" && !isValidProvider(envItem.Provider) {
-				return fmt.Errorf("environment '%s': env item %d: invalid provider '%s'", envName, idx, envItem.Provider)
+				report(RuleProvider, envItem.Source, envName, name, "invalid provider '%s'", envItem.Provider)
 			}
 
 			// Local provider rules: only value is allowed
-			if effectiveProvider == "local" {
-				if envItem.Value == nil {
-					return fmt.Errorf("environment '%s': env item %d: provider 'local' requires 'value'", envName, idx)
-				}
-				if envItem.SecretKey != "" || envItem.SecretPath != "" {
-					return fmt.Errorf("environment '%s': env item %d: provider 'local' does not support 'secret-key' or 'secret-path'", envName, idx)
+			if info.ValueOnly && (envItem.SecretKey != "" || envItem.SecretPath != "") {
+				report(RuleLocalProvider, envItem.Source, envName, name, "provider '%s' does not support 'secret-key' or 'secret-path'", info.Name)
+			}
+
+			if envItem.Validate != nil {
+				for _, problem := range envItem.Validate.ruleProblems() {
+					report(RuleValidate, envItem.Source, envName, name, "validate: %s", problem)
 				}
 			}
 		}
+	}
 
-		// Validate main provider
-		if !isValidProvider(env.Provider) {
-			return fmt.Errorf("environment '%s': invalid provider '%s'", envName, env.Provider)
-		}
+	checkPolicy(config, report)
+
+	if len(diagnostics) == 0 {
+		return nil
 	}
+	sortDiagnostics(diagnostics)
+	return &ValidationError{Diagnostics: diagnostics}
+}
 
-	return nil
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // isValidProvider checks if the provider is supported
 func isValidProvider(provider string) bool {
-	validProviders := []string{"gcp", "aws", "azure", "openbao", "bitwarden", "local"}
-	for _, p := range validProviders {
-		if p == provider {
-			return true
-		}
-	}
-	return false
+	_, ok := LookupProvider(provider)
+	return ok
 }
 
 // FindConfigFile searches for a kuba.yaml file in the current directory and parent directories
@@ -593,7 +623,7 @@ func FindConfigFile() (string, error) {
 
 	// Search up the directory tree for kuba.yaml
 	for {
-		configPath := filepath.Join(currentDir, "kuba.yaml")
+		configPath := filepath.Join(currentDir, ConfigFileName)
 		if _, err := os.Stat(configPath); err == nil {
 			return configPath, nil
 		}
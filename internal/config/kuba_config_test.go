@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -758,6 +759,160 @@ child:
 		require.Equal(t, "Z", env.Env["COMMON"].Value)
 	})
 
+	t.Run("inherits provider project and cache across levels", func(t *testing.T) {
+		content := `base:
+  provider: gcp
+  project: p
+  cache: 1h
+  env:
+    A:
+      value: "1"
+
+middle:
+  inherits: base
+  env:
+    B:
+      value: "2"
+
+child:
+  inherits: middle
+  project: q
+  env:
+    C:
+      value: "3"
+`
+		var cfg KubaConfig
+		err := yaml.Unmarshal([]byte(content), &cfg)
+		require.NoError(t, err)
+		err = resolveInheritance(&cfg)
+		require.NoError(t, err)
+		err = processValueInterpolations(&cfg)
+		require.NoError(t, err)
+		err = validateConfig(&cfg)
+		require.NoError(t, err)
+
+		middle := cfg.Environments["middle"]
+		require.Equal(t, "gcp", middle.Provider)
+		require.Equal(t, "p", middle.Project)
+		require.NotNil(t, middle.Cache)
+		require.True(t, middle.Cache.Enabled)
+		require.Equal(t, time.Hour, middle.Cache.TTL)
+		require.Empty(t, middle.Env["A"].Project)
+
+		child := cfg.Environments["child"]
+		require.Equal(t, "gcp", child.Provider)
+		require.Equal(t, "q", child.Project)
+		require.NotNil(t, child.Cache)
+		require.Len(t, child.Env, 3)
+		// Inherited items keep the project of the environment defining them
+		require.Equal(t, "p", child.Env["A"].Project)
+		require.Equal(t, "p", child.Env["B"].Project)
+		require.Empty(t, child.Env["C"].Project)
+	})
+
+	t.Run("inherited items keep their provider", func(t *testing.T) {
+		content := `shared:
+  provider: gcp
+  project: p
+  env:
+    DB_PASSWORD:
+      secret-key: db-password
+    OVERRIDDEN:
+      secret-key: overridden
+      provider: azure
+      project: vault
+
+app:
+  provider: aws
+  inherits: shared
+  env:
+    API_KEY:
+      secret-key: api-key
+`
+		var cfg KubaConfig
+		err := yaml.Unmarshal([]byte(content), &cfg)
+		require.NoError(t, err)
+		err = resolveInheritance(&cfg)
+		require.NoError(t, err)
+		err = validateConfig(&cfg)
+		require.NoError(t, err)
+
+		env := cfg.Environments["app"]
+		require.Equal(t, "aws", env.Provider)
+		require.Empty(t, env.Project)
+		require.Equal(t, "gcp", env.Env["DB_PASSWORD"].Provider)
+		require.Equal(t, "p", env.Env["DB_PASSWORD"].Project)
+		require.Equal(t, "azure", env.Env["OVERRIDDEN"].Provider)
+		require.Equal(t, "vault", env.Env["OVERRIDDEN"].Project)
+		require.Empty(t, env.Env["API_KEY"].Provider)
+	})
+
+	t.Run("diamond inheritance", func(t *testing.T) {
+		content := `root:
+  provider: gcp
+  project: root-project
+  env:
+    ROOT:
+      value: "root"
+    COMMON:
+      value: "root"
+
+left:
+  inherits: root
+  env:
+    COMMON:
+      value: "left"
+
+right:
+  inherits: root
+  provider: aws
+  env:
+    COMMON:
+      value: "right"
+    RIGHT:
+      secret-key: right
+
+child:
+  inherits: [left, right]
+`
+		var cfg KubaConfig
+		err := yaml.Unmarshal([]byte(content), &cfg)
+		require.NoError(t, err)
+		err = resolveInheritance(&cfg)
+		require.NoError(t, err)
+		err = validateConfig(&cfg)
+		require.NoError(t, err)
+
+		env := cfg.Environments["child"]
+		// The first parent in inherits wins for settings and items
+		require.Equal(t, "gcp", env.Provider)
+		require.Equal(t, "root-project", env.Project)
+		require.Equal(t, "left", env.Env["COMMON"].Value)
+		require.Equal(t, "left", env.Env["COMMON"].DefinedIn)
+		require.Equal(t, "root", env.Env["ROOT"].Value)
+		require.Equal(t, "root", env.Env["ROOT"].DefinedIn)
+		require.Empty(t, env.Env["ROOT"].Provider)
+		require.Equal(t, "aws", env.Env["RIGHT"].Provider)
+		// RIGHT must not use the GCP project of the child
+		require.Equal(t, DefaultProject, env.Env["RIGHT"].Project)
+	})
+
+	t.Run("inheritance cycle", func(t *testing.T) {
+		content := `a:
+  provider: gcp
+  project: p
+  inherits: b
+
+b:
+  inherits: a
+`
+		var cfg KubaConfig
+		err := yaml.Unmarshal([]byte(content), &cfg)
+		require.NoError(t, err)
+		err = resolveInheritance(&cfg)
+		require.ErrorContains(t, err, "inheritance cycle detected")
+	})
+
 	t.Run("inherits references unknown environment", func(t *testing.T) {
 		content := `base:
   provider: gcp
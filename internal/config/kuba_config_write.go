@@ -12,8 +12,18 @@ import (
 const kubaSchemaHeader = "# yaml-language-server: $schema=https://kuba.mwco.app/kuba.schema.json\n---\n"
 
 // AddOrUpdateEnvSecretKeyMapping adds/updates an env mapping in kuba.yaml for the
-// given environment and environment variable name.
+// given environment and environment variable name. An existing mapping keeps
+// its other settings like provider and project, only the source of the value
+// is replaced.
 func AddOrUpdateEnvSecretKeyMapping(configPath, envName, envVar, secretKey string) error {
+	return AddOrUpdateEnvSecretKeyMappingWithStore(configPath, envName, envVar, secretKey, "", "")
+}
+
+// AddOrUpdateEnvSecretKeyMappingWithStore is AddOrUpdateEnvSecretKeyMapping
+// for secrets stored outside the environment's provider or project. A new
+// mapping gets the provider and project unless they are empty; an existing
+// mapping keeps its own.
+func AddOrUpdateEnvSecretKeyMappingWithStore(configPath, envName, envVar, secretKey, provider, project string) error {
 	if configPath == "" {
 		return fmt.Errorf("configPath is required")
 	}
@@ -37,15 +47,33 @@ func AddOrUpdateEnvSecretKeyMapping(configPath, envName, envVar, secretKey strin
 			return err
 		}
 
+		secretKeyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: secretKey}
+		existing, err := getMapValue(envMap, envVar)
+		if err != nil {
+			return err
+		}
+		if existing != nil && existing.Kind == yaml.MappingNode {
+			deleteMapKey(existing, "secret-path")
+			deleteMapKey(existing, "value")
+			setMapKey(existing, "secret-key", secretKeyNode)
+			return nil
+		}
+
 		// Replace env var mapping with: {secret-key: "<secretKey>"}
 		valueNode := &yaml.Node{
 			Kind: yaml.MappingNode,
 			Tag:  "!!map",
 			Content: []*yaml.Node{
 				{Kind: yaml.ScalarNode, Tag: "!!str", Value: "secret-key"},
-				{Kind: yaml.ScalarNode, Tag: "!!str", Value: secretKey},
+				secretKeyNode,
 			},
 		}
+		if provider != "" {
+			setMapKey(valueNode, "provider", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: provider})
+		}
+		if project != "" {
+			setMapKey(valueNode, "project", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: project})
+		}
 		setMapKey(envMap, envVar, valueNode)
 		return nil
 	})
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mistweaverco/kuba/internal/lib/log"
+)
+
+// LocalOverrideFile is the personal override file that is merged on top of a
+// sibling kuba.yaml. It is meant to be kept out of version control.
+const LocalOverrideFile = "kuba.local.yaml"
+
+// OverrideFileEnvVar selects an override file instead of kuba.local.yaml
+const OverrideFileEnvVar = "KUBA_OVERRIDE_FILE"
+
+var localOverridesEnabled = true
+
+// SetLocalOverrides enables or disables merging of the local override file
+func SetLocalOverrides(enabled bool) {
+	localOverridesEnabled = enabled
+}
+
+// localOverridePath returns the override file for the configuration file and
+// whether it was selected explicitly through KUBA_OVERRIDE_FILE
+func localOverridePath(configPath string) (string, bool) {
+	if path := os.Getenv(OverrideFileEnvVar); path != "" {
+		return path, true
+	}
+	return filepath.Join(filepath.Dir(configPath), LocalOverrideFile), false
+}
+
+// applyLocalOverride merges the environments and env items of the local
+// override file into config, if there is one
+func applyLocalOverride(config *KubaConfig, configPath string) error {
+	logger := log.NewLogger()
+
+	if !localOverridesEnabled {
+		logger.Debug("Local override file disabled")
+		return nil
+	}
+
+	path, explicit := localOverridePath(configPath)
+	if _, err := os.Stat(path); err != nil {
+		if explicit {
+			return fmt.Errorf("override file from %s not found: %s", OverrideFileEnvVar, path)
+		}
+		return nil
+	}
+	if same, err := sameFile(path, configPath); err == nil && same {
+		return nil
+	}
+
+	logger.Debug("Merging local override file", "path", path)
+	override, err := loadConfigFile(path, nil)
+	if err != nil {
+		return err
+	}
+	for name, env := range override.Environments {
+		for key, item := range env.Env {
+			item.Override = true
+			env.Env[key] = item
+		}
+		override.Environments[name] = env
+	}
+
+	if config.Environments == nil {
+		config.Environments = make(map[string]Environment)
+	}
+	mergeEnvironments(config.Environments, override.Environments)
+	config.Select = append(override.Select, config.Select...)
+	config.Policy = append(config.Policy, override.Policy...)
+	config.diagnostics = append(config.diagnostics, override.diagnostics...)
+	return nil
+}
+
+func sameFile(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(infoA, infoB), nil
+}
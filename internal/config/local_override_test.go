@@ -0,0 +1,92 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const overrideBaseConfig = `
+default:
+  provider: gcp
+  project: shared
+  env:
+    DATABASE_URL:
+      secret-key: database-url
+    LOG_LEVEL:
+      value: info
+`
+
+func TestLoadKubaConfigMergesLocalOverride(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "kuba.yaml")
+	writeConfigFile(t, configPath, overrideBaseConfig)
+	writeConfigFile(t, filepath.Join(dir, LocalOverrideFile), `
+default:
+  env:
+    DATABASE_URL:
+      value: postgres://localhost/dev
+      provider: local
+`)
+
+	cfg, err := LoadKubaConfig(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	env := cfg.Environments["default"]
+	if env.Provider != "gcp" || env.Project != "shared" {
+		t.Errorf("expected environment settings of kuba.yaml to be kept, got %s/%s", env.Provider, env.Project)
+	}
+	item := env.Env["DATABASE_URL"]
+	if item.Value != "postgres://localhost/dev" || item.SecretKey != "" {
+		t.Errorf("expected DATABASE_URL to be replaced by the override, got %+v", item)
+	}
+	if !item.Override {
+		t.Errorf("expected DATABASE_URL to be marked as override")
+	}
+	if env.Env["LOG_LEVEL"].Override {
+		t.Errorf("expected LOG_LEVEL not to be marked as override")
+	}
+}
+
+func TestLoadKubaConfigOverrideFileFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "kuba.yaml")
+	writeConfigFile(t, configPath, overrideBaseConfig)
+	writeConfigFile(t, filepath.Join(dir, LocalOverrideFile), "default:\n  env:\n    LOG_LEVEL:\n      value: sibling\n")
+	custom := filepath.Join(t.TempDir(), "override.yaml")
+	writeConfigFile(t, custom, "default:\n  env:\n    LOG_LEVEL:\n      value: custom\n")
+	t.Setenv(OverrideFileEnvVar, custom)
+
+	cfg, err := LoadKubaConfig(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if got := cfg.Environments["default"].Env["LOG_LEVEL"].Value; got != "custom" {
+		t.Errorf("expected override from %s, got %v", OverrideFileEnvVar, got)
+	}
+
+	t.Setenv(OverrideFileEnvVar, filepath.Join(dir, "missing.yaml"))
+	if _, err := LoadKubaConfig(configPath); err == nil || !strings.Contains(err.Error(), "override file") {
+		t.Errorf("expected missing explicit override file to fail, got %v", err)
+	}
+}
+
+func TestLoadKubaConfigLocalOverrideDisabled(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "kuba.yaml")
+	writeConfigFile(t, configPath, overrideBaseConfig)
+	writeConfigFile(t, filepath.Join(dir, LocalOverrideFile), "default:\n  env:\n    LOG_LEVEL:\n      value: debug\n")
+
+	SetLocalOverrides(false)
+	t.Cleanup(func() { SetLocalOverrides(true) })
+
+	cfg, err := LoadKubaConfig(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if got := cfg.Environments["default"].Env["LOG_LEVEL"].Value; got != "info" {
+		t.Errorf("expected override to be ignored, got %v", got)
+	}
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/mistweaverco/kuba/internal/lib/log"
+)
+
+// PolicyRule restricts the environments whose name matches Env. Rules come
+// from the global configuration and from kuba.yaml, and every rule matching
+// an environment applies, so a project can add restrictions but not lift
+// them.
+type PolicyRule struct {
+	Env              string   `yaml:"env" doc:"Glob matched against the environment name, e.g. prod*."`
+	Providers        []string `yaml:"providers,omitempty" schema:"provider-list" doc:"Providers the environment and its secrets may use."`
+	Projects         []string `yaml:"projects,omitempty" schema:"project-list" doc:"Projects the environment and its secrets may use."`
+	ForbidValues     []string `yaml:"forbid-values,omitempty" doc:"Globs of variable names that must not have a literal value, e.g. *_PASSWORD."`
+	RequireConfirm   bool     `yaml:"require-confirm,omitempty" doc:"Require --confirm (or --i-know) for kuba show, kuba diff --reveal, kuba render and for edits in kuba tui."`
+	RequireSensitive bool     `yaml:"require-sensitive,omitempty" doc:"Deny plain text values: kuba show requires --sensitive, kuba diff --reveal and kuba render are denied."`
+
+	// Source is where the rule is defined
+	Source SourcePosition `yaml:"-"`
+}
+
+// matches reports whether the rule applies to the environment
+func (r PolicyRule) matches(envName string) bool {
+	matched, _ := path.Match(r.Env, envName)
+	return matched
+}
+
+// EnvironmentPolicy is the combined effect of the policy rules matching an
+// environment on the commands
+type EnvironmentPolicy struct {
+	RequireConfirm   bool
+	RequireSensitive bool
+}
+
+// policyRules returns the rules of the global configuration followed by the
+// rules of kuba.yaml
+func (c *KubaConfig) policyRules() []PolicyRule {
+	return append(append([]PolicyRule{}, c.globalPolicy...), c.Policy...)
+}
+
+// PolicyFor returns what the policy rules require from commands using the
+// environment
+func (c *KubaConfig) PolicyFor(envName string) EnvironmentPolicy {
+	var policy EnvironmentPolicy
+	for _, rule := range c.policyRules() {
+		if !rule.matches(envName) {
+			continue
+		}
+		policy.RequireConfirm = policy.RequireConfirm || rule.RequireConfirm
+		policy.RequireSensitive = policy.RequireSensitive || rule.RequireSensitive
+	}
+	return policy
+}
+
+// loadGlobalPolicy returns the policy rules of the global configuration.
+// Unlike the cache settings, the rules are not skipped when the global
+// configuration cannot be loaded, so a broken file can't lift them. A missing
+// file has no rules.
+func loadGlobalPolicy() ([]PolicyRule, error) {
+	globalConfig, err := LoadGlobalConfig()
+	if err != nil {
+		log.NewLogger().Debug("Failed to load global config for its policy", "error", err)
+		return nil, err
+	}
+	return globalConfig.Policy, nil
+}
+
+// checkPolicy reports the policy rules that are invalid and the environments
+// that violate a rule. Unlike the other checks, inherited env items are
+// checked for every environment, as the rules depend on the environment.
+func checkPolicy(config *KubaConfig, report func(rule string, pos SourcePosition, envName, variable, format string, args ...any)) {
+	rules := config.policyRules()
+	for _, rule := range rules {
+		if rule.Env == "" {
+			report(RulePolicy, rule.Source, "", "", "policy rule: env is required")
+		} else if _, err := path.Match(rule.Env, ""); err != nil {
+			report(RulePolicy, rule.Source, "", "", "policy rule: invalid env pattern '%s'", rule.Env)
+		}
+		for _, provider := range rule.Providers {
+			if !isValidProvider(provider) {
+				report(RulePolicy, rule.Source, "", "", "policy rule: invalid provider '%s'", provider)
+			}
+		}
+		for _, pattern := range rule.ForbidValues {
+			if _, err := path.Match(pattern, ""); err != nil {
+				report(RulePolicy, rule.Source, "", "", "policy rule: invalid forbid-values pattern '%s'", pattern)
+			}
+		}
+	}
+
+	for _, envName := range sortedKeys(config.Environments) {
+		env := config.Environments[envName]
+		for _, rule := range rules {
+			if !rule.matches(envName) {
+				continue
+			}
+			if problem := rule.providerProblem(env.Provider); problem != "" {
+				report(RulePolicy, env.Source, envName, "", "policy: %s", problem)
+			}
+			if problem := rule.projectProblem(env.Project); problem != "" {
+				report(RulePolicy, env.Source, envName, "", "policy: %s", problem)
+			}
+
+			for _, name := range sortedKeys(env.Env) {
+				envItem := env.Env[name]
+
+				// Items without their own provider or project use the
+				// environment's, which is checked above
+				if problem := rule.providerProblem(envItem.Provider); problem != "" {
+					report(RulePolicy, envItem.Source, envName, name, "policy: %s", problem)
+				}
+				if problem := rule.projectProblem(envItem.Project); problem != "" {
+					report(RulePolicy, envItem.Source, envName, name, "policy: %s", problem)
+				}
+
+				if envItem.Value == nil {
+					continue
+				}
+				for _, pattern := range rule.ForbidValues {
+					if matched, _ := path.Match(pattern, name); matched {
+						report(RulePolicy, envItem.Source, envName, name, "policy: literal values are not allowed for variables matching '%s'", pattern)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// providerProblem describes why the provider is not allowed by the rule, or
+// returns an empty string when it is allowed or not set
+func (r PolicyRule) providerProblem(provider string) string {
+	if provider == "" || len(r.Providers) == 0 || slices.Contains(r.Providers, provider) {
+		return ""
+	}
+	return fmt.Sprintf("provider '%s' is not allowed (allowed: %s)", provider, strings.Join(r.Providers, ", "))
+}
+
+// projectProblem describes why the project is not allowed by the rule, or
+// returns an empty string when it is allowed or not set. DefaultProject
+// stands for no project, see resolveInheritance.
+func (r PolicyRule) projectProblem(project string) string {
+	if project == "" || project == DefaultProject || len(r.Projects) == 0 || slices.Contains(r.Projects, project) {
+		return ""
+	}
+	return fmt.Sprintf("project '%s' is not allowed (allowed: %s)", project, strings.Join(r.Projects, ", "))
+}
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// withGlobalConfig points HOME to a directory with the given global
+// configuration
+func withGlobalConfig(t *testing.T, content string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, ".config", "kuba", "config.yaml")
+	if content != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create config directory: %v", err)
+		}
+		writeConfigFile(t, path, content)
+	}
+	return path
+}
+
+func TestPolicyViolations(t *testing.T) {
+	withGlobalConfig(t, "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kuba.yaml")
+	writeConfigFile(t, path, `policy:
+  - env: prod*
+    providers: [gcp]
+    projects: [acme-prod]
+    forbid-values: ["*_PASSWORD"]
+  - env: "*"
+    forbid-values: ["*_TOKEN"]
+base:
+  provider: local
+  env:
+    DB_PASSWORD:
+      value: hunter2
+prod-eu:
+  provider: gcp
+  project: acme-dev
+  inherits: base
+  env:
+    API_KEY:
+      secret-key: api-key
+      provider: aws
+    GITHUB_TOKEN:
+      secret-key: github-token
+dev:
+  provider: local
+  env:
+    DB_PASSWORD:
+      value: dev
+`)
+
+	_, err := LoadKubaConfig(path)
+	diagnostics := DiagnosticsFromError(err, path)
+	var got []string
+	for _, d := range diagnostics {
+		got = append(got, strings.TrimPrefix(d.String(), path+":"))
+	}
+	want := []string{
+		"11:5: environment 'prod-eu': env item 'DB_PASSWORD': policy: literal values are not allowed for variables matching '*_PASSWORD'",
+		"11:5: environment 'prod-eu': env item 'DB_PASSWORD': policy: provider 'local' is not allowed (allowed: gcp)",
+		"13:1: environment 'prod-eu': policy: project 'acme-dev' is not allowed (allowed: acme-prod)",
+		"18:5: environment 'prod-eu': env item 'API_KEY': policy: provider 'aws' is not allowed (allowed: gcp)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestPolicyRulesValidation(t *testing.T) {
+	withGlobalConfig(t, "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kuba.yaml")
+	writeConfigFile(t, path, `policy:
+  - providers: [gpc]
+  - env: "prod["
+    forbid-values: ["["]
+  - env: prod
+    require-sensitve: true
+default:
+  provider: local
+  env:
+    A:
+      value: "1"
+`)
+
+	_, err := LoadKubaConfig(path)
+	diagnostics := DiagnosticsFromError(err, path)
+	var got []string
+	for _, d := range diagnostics {
+		got = append(got, strings.TrimPrefix(d.String(), path+":"))
+	}
+	want := []string{
+		"2:5: policy rule: env is required",
+		"2:5: policy rule: invalid provider 'gpc'",
+		"3:5: policy rule: invalid env pattern 'prod['",
+		"3:5: policy rule: invalid forbid-values pattern '['",
+		"6:5: unknown key 'require-sensitve' (did you mean `require-sensitive`?)",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestGlobalPolicy(t *testing.T) {
+	globalPath := withGlobalConfig(t, `policy:
+  - env: production
+    providers: [gcp]
+    require-confirm: true
+`)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kuba.yaml")
+	writeConfigFile(t, path, `policy:
+  - env: prod*
+    require-sensitive: true
+production:
+  provider: local
+  env:
+    A:
+      value: "1"
+`)
+
+	_, err := LoadKubaConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "environment 'production': policy: provider 'local' is not allowed (allowed: gcp)") {
+		t.Fatalf("expected the global policy to be enforced, got %v", err)
+	}
+
+	writeConfigFile(t, globalPath, `policy:
+  - env: production
+    require-confirm: true
+  - env: "["
+`)
+	_, err = LoadKubaConfig(path)
+	if err == nil || !strings.Contains(err.Error(), globalPath+": policy rule: invalid env pattern '['") {
+		t.Fatalf("expected the invalid global rule to be reported, got %v", err)
+	}
+
+	writeConfigFile(t, globalPath, `policy:
+  - env: production
+    require-confirm: true
+`)
+	cfg, err := LoadKubaConfig(path)
+	if err != nil {
+		t.Fatalf("LoadKubaConfig() error: %v", err)
+	}
+	if got := cfg.PolicyFor("production"); got != (EnvironmentPolicy{RequireConfirm: true, RequireSensitive: true}) {
+		t.Fatalf("unexpected policy for production: %+v", got)
+	}
+	if got := cfg.PolicyFor("staging"); got != (EnvironmentPolicy{}) {
+		t.Fatalf("unexpected policy for staging: %+v", got)
+	}
+
+	// A broken global configuration must not lift its rules
+	writeConfigFile(t, globalPath, "policy: [\n")
+	_, err = LoadKubaConfig(path)
+	if err == nil || !strings.Contains(err.Error(), "failed to load the global policy") {
+		t.Fatalf("expected the broken global config to be reported, got %v", err)
+	}
+
+	if err := os.Remove(globalPath); err != nil {
+		t.Fatalf("failed to remove global config: %v", err)
+	}
+	if _, err := LoadKubaConfig(path); err != nil {
+		t.Fatalf("LoadKubaConfig() without global config error: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FilterRegions returns the configured default regions that match the given
+// available regions. Regions may be exact strings (e.g. "us-central1") or
+// regex patterns (e.g. "us-(east1|central1)"); a pattern that fails to
+// compile falls back to exact matching.
+//
+// When no available regions are known, the configured values are returned
+// as-is (best effort).
+func (p ProviderDefaults) FilterRegions(available []string) []string {
+	if len(available) == 0 {
+		var regions []string
+		for _, r := range p.Regions {
+			r = strings.TrimSpace(r)
+			if r != "" {
+				regions = append(regions, r)
+			}
+		}
+		return uniqueSortedStrings(regions)
+	}
+
+	matchers := make([]func(string) bool, 0, len(p.Regions))
+	for _, raw := range p.Regions {
+		pattern := strings.TrimSpace(raw)
+		if pattern == "" {
+			continue
+		}
+		// Only pay regex cost if it looks like a regex.
+		if strings.ContainsAny(pattern, `|.*+?()[]{}^$\`) {
+			if re, err := regexp.Compile(pattern); err == nil {
+				matchers = append(matchers, re.MatchString)
+				continue
+			}
+		}
+		matchers = append(matchers, func(s string) bool { return s == pattern })
+	}
+
+	var regions []string
+	for _, region := range available {
+		for _, matches := range matchers {
+			if matches(region) {
+				regions = append(regions, region)
+				break
+			}
+		}
+	}
+	return uniqueSortedStrings(regions)
+}
+
+// HasRegionPatterns reports whether any configured region is a regex pattern
+// that needs the list of available regions to be resolved.
+func (p ProviderDefaults) HasRegionPatterns() bool {
+	for _, r := range p.Regions {
+		if strings.ContainsAny(strings.TrimSpace(r), `|.*+?()[]{}^$\`) {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueSortedStrings(ss []string) []string {
+	if len(ss) == 0 {
+		return nil
+	}
+	sort.Strings(ss)
+	out := ss[:0]
+	var prev string
+	for i, s := range ss {
+		if i == 0 || s != prev {
+			out = append(out, s)
+			prev = s
+		}
+	}
+	return out
+}
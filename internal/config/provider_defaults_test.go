@@ -0,0 +1,33 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProviderDefaultsFilterRegions(t *testing.T) {
+	pd := ProviderDefaults{Regions: []string{"us-(east1|central1)", "europe-west1", " "}}
+	available := []string{"asia-east1", "europe-west1", "us-central1", "us-east1", "us-west1"}
+
+	got := pd.FilterRegions(available)
+	want := []string{"europe-west1", "us-central1", "us-east1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FilterRegions() = %v, want %v", got, want)
+	}
+	if !pd.HasRegionPatterns() {
+		t.Fatalf("expected HasRegionPatterns() to be true")
+	}
+}
+
+func TestProviderDefaultsFilterRegionsWithoutAvailable(t *testing.T) {
+	pd := ProviderDefaults{Regions: []string{"us-east1", "europe-west1", "us-east1"}}
+
+	got := pd.FilterRegions(nil)
+	want := []string{"europe-west1", "us-east1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("FilterRegions() = %v, want %v", got, want)
+	}
+	if pd.HasRegionPatterns() {
+		t.Fatalf("expected HasRegionPatterns() to be false")
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+// ProviderInfo describes a secret provider that can be used in kuba.yaml
+type ProviderInfo struct {
+	Name string
+	// RequiresProject is set for providers that need a project to look up
+	// secrets
+	RequiresProject bool
+	// ValueOnly is set for providers that only hold literal values and do
+	// not support secret-key or secret-path
+	ValueOnly bool
+}
+
+// Providers is the registry of supported providers. Validation and the
+// generated JSON schemas are derived from it.
+var Providers = []ProviderInfo{
+	{Name: "gcp", RequiresProject: true},
+	{Name: "azure"},
+	{Name: "aws"},
+	{Name: "openbao"},
+	{Name: "bitwarden"},
+	{Name: "local", ValueOnly: true},
+}
+
+// ProviderNames returns the names of all supported providers
+func ProviderNames() []string {
+	names := make([]string, 0, len(Providers))
+	for _, p := range Providers {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// LookupProvider returns the registry entry of a provider
+func LookupProvider(name string) (ProviderInfo, bool) {
+	for _, p := range Providers {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ProviderInfo{}, false
+}
@@ -0,0 +1,370 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/lib/cache"
+)
+
+// The JSON schemas for kuba.yaml and the global configuration are generated
+// from the yaml tags of the configuration types. Fields can carry a "doc" tag
+// with their description, a "schema" tag selecting one of the shapes below
+// when the Go type does not describe what the custom unmarshalers accept
+// ("-" skips the field), and maps a "keys" tag with a pattern for their keys.
+const (
+	schemaKindProvider        = "provider"
+	schemaKindStringOrInteger = "string-or-integer"
+	schemaKindStringOrList    = "string-or-list"
+	schemaKindDuration        = "duration"
+	schemaKindCache           = "cache"
+	schemaKindCacheBackend    = "cache-backend"
+	schemaKindValueType       = "value-type"
+	schemaKindValidationMode  = "validation-mode"
+	schemaKindProviderList    = "provider-list"
+	schemaKindProjectList     = "project-list"
+)
+
+const (
+	schemaDraft   = "http://json-schema.org/draft-07/schema#"
+	schemaBaseURL = "https://kuba.mwco.app/"
+)
+
+// Files the schemas are published as
+const (
+	SchemaFileName       = "kuba.schema.json"
+	GlobalSchemaFileName = "kuba-global.schema.json"
+)
+
+type jsonSchema struct {
+	Schema               string           `json:"$schema,omitempty"`
+	ID                   string           `json:"$id,omitempty"`
+	Title                string           `json:"title,omitempty"`
+	Description          string           `json:"description,omitempty"`
+	Type                 any              `json:"type,omitempty"`
+	Enum                 []string         `json:"enum,omitempty"`
+	Pattern              string           `json:"pattern,omitempty"`
+	Items                *jsonSchema      `json:"items,omitempty"`
+	Properties           schemaProperties `json:"properties,omitempty"`
+	PatternProperties    schemaProperties `json:"patternProperties,omitempty"`
+	Required             []string         `json:"required,omitempty"`
+	OneOf                []*jsonSchema    `json:"oneOf,omitempty"`
+	AnyOf                []*jsonSchema    `json:"anyOf,omitempty"`
+	AllOf                []*jsonSchema    `json:"allOf,omitempty"`
+	If                   *jsonSchema      `json:"if,omitempty"`
+	Then                 *jsonSchema      `json:"then,omitempty"`
+	Else                 *jsonSchema      `json:"else,omitempty"`
+	Not                  *jsonSchema      `json:"not,omitempty"`
+	AdditionalProperties any              `json:"additionalProperties,omitempty"`
+}
+
+type schemaProperty struct {
+	Name   string
+	Schema *jsonSchema
+}
+
+// schemaProperties keeps properties in the order of the struct fields
+type schemaProperties []schemaProperty
+
+func (p schemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, prop := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(prop.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(prop.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (p schemaProperties) get(name string) *jsonSchema {
+	for _, prop := range p {
+		if prop.Name == name {
+			return prop.Schema
+		}
+	}
+	return nil
+}
+
+// schemaDecorators add the rules to a type's schema that cannot be expressed
+// with tags
+var schemaDecorators = map[reflect.Type]func(*jsonSchema){
+	reflect.TypeOf(Environment{}): decorateEnvironmentSchema,
+	reflect.TypeOf(EnvItem{}):     decorateEnvItemSchema,
+	reflect.TypeOf(SelectRule{}):  decorateSelectRuleSchema,
+	reflect.TypeOf(PolicyRule{}):  decoratePolicyRuleSchema,
+}
+
+// GenerateSchema returns the JSON schema of kuba.yaml
+func GenerateSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(KubaConfig{}))
+	schema.Schema = schemaDraft
+	schema.ID = schemaBaseURL + SchemaFileName
+	schema.Title = "Project Configuration File"
+	schema.Description = "Schema for a project configuration file with multiple environments."
+	return encodeSchema(schema)
+}
+
+// GenerateGlobalSchema returns the JSON schema of the global configuration
+func GenerateGlobalSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(GlobalConfig{}))
+	schema.Schema = schemaDraft
+	schema.ID = schemaBaseURL + GlobalSchemaFileName
+	schema.Title = "Global Kuba Configuration File"
+	schema.Description = "Schema for the global kuba configuration file located at ~/.config/kuba/config.yaml"
+	return encodeSchema(schema)
+}
+
+func encodeSchema(schema *jsonSchema) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(schema); err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// typeSchema returns the schema of a Go type
+func typeSchema(t reflect.Type) *jsonSchema {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return kindSchema(schemaKindDuration)
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: typeSchema(t.Elem())}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	default:
+		return &jsonSchema{}
+	}
+}
+
+// structSchema returns the schema of a struct from the tags of its fields
+func structSchema(t reflect.Type) *jsonSchema {
+	schema := &jsonSchema{Type: "object", AdditionalProperties: false}
+	var inline *reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" || field.Tag.Get("schema") == "-" {
+			continue
+		}
+		if name == "" && strings.Contains(opts, "inline") {
+			inline = &field
+			continue
+		}
+		schema.Properties = append(schema.Properties, schemaProperty{Name: name, Schema: fieldSchema(field)})
+	}
+
+	// Keys of an inlined map are everything but the other properties
+	if inline != nil {
+		pattern := inline.Tag.Get("keys")
+		if len(schema.Properties) > 0 {
+			names := make([]string, 0, len(schema.Properties))
+			for _, prop := range schema.Properties {
+				names = append(names, prop.Name+"$")
+			}
+			pattern = "^(?!" + strings.Join(names, "|") + ")" + strings.TrimPrefix(pattern, "^")
+		}
+		schema.PatternProperties = schemaProperties{{Name: pattern, Schema: typeSchema(inline.Type.Elem())}}
+	}
+
+	if decorate, ok := schemaDecorators[t]; ok {
+		decorate(schema)
+	}
+	return schema
+}
+
+// fieldSchema returns the schema of a struct field
+func fieldSchema(field reflect.StructField) *jsonSchema {
+	var schema *jsonSchema
+	if kind := field.Tag.Get("schema"); kind != "" {
+		schema = kindSchema(kind)
+		if kind == schemaKindCache {
+			schema.OneOf = append(schema.OneOf, typeSchema(field.Type))
+		}
+	} else if keys := field.Tag.Get("keys"); keys != "" && field.Type.Kind() == reflect.Map {
+		schema = &jsonSchema{
+			Type:                 "object",
+			PatternProperties:    schemaProperties{{Name: keys, Schema: typeSchema(field.Type.Elem())}},
+			AdditionalProperties: false,
+		}
+	} else {
+		schema = typeSchema(field.Type)
+	}
+	schema.Description = field.Tag.Get("doc")
+	return schema
+}
+
+// kindSchema returns the schema of one of the shapes selected by the schema tag
+func kindSchema(kind string) *jsonSchema {
+	switch kind {
+	case schemaKindProvider:
+		return &jsonSchema{Type: "string", Enum: ProviderNames()}
+	case schemaKindStringOrInteger:
+		return &jsonSchema{Type: []string{"string", "integer"}}
+	case schemaKindStringOrList:
+		return &jsonSchema{OneOf: []*jsonSchema{
+			{Type: "string"},
+			{Type: "array", Items: &jsonSchema{Type: "string"}},
+		}}
+	case schemaKindDuration, schemaKindCache:
+		return &jsonSchema{OneOf: []*jsonSchema{
+			{Type: "boolean"},
+			{Type: "number"},
+			{Type: "string", Pattern: cache.DurationPattern},
+		}}
+	case schemaKindCacheBackend:
+		return &jsonSchema{Type: "string", Enum: cache.Backends}
+	case schemaKindValueType:
+		return &jsonSchema{Type: "string", Enum: ValueTypes}
+	case schemaKindValidationMode:
+		return &jsonSchema{Type: "string", Enum: ValidationModes}
+	case schemaKindProviderList:
+		return &jsonSchema{Type: "array", Items: kindSchema(schemaKindProvider)}
+	case schemaKindProjectList:
+		return &jsonSchema{Type: "array", Items: kindSchema(schemaKindStringOrInteger)}
+	default:
+		panic(fmt.Sprintf("unknown schema kind %q", kind))
+	}
+}
+
+// providersWhere returns the names of the providers matching fn
+func providersWhere(fn func(ProviderInfo) bool) []string {
+	var names []string
+	for _, p := range Providers {
+		if fn(p) {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// valueOnly is satisfied by items with a value and no secret reference, as
+// required by value-only providers
+var valueOnly = &jsonSchema{
+	Required: []string{"value"},
+	Not: &jsonSchema{AnyOf: []*jsonSchema{
+		{Required: []string{"secret-key"}},
+		{Required: []string{"secret-path"}},
+	}},
+}
+
+// decorateEnvironmentSchema requires a provider unless it is inherited, a
+// project for providers that need one, and applies the value-only rules to
+// items of value-only environments that do not set their own provider
+func decorateEnvironmentSchema(schema *jsonSchema) {
+	schema.AllOf = []*jsonSchema{{AnyOf: []*jsonSchema{
+		{Required: []string{"provider"}},
+		{Required: []string{"inherits"}},
+	}}}
+
+	if names := providersWhere(func(p ProviderInfo) bool { return p.RequiresProject }); len(names) > 0 {
+		schema.AllOf = append(schema.AllOf, &jsonSchema{
+			If: &jsonSchema{
+				Properties: schemaProperties{{Name: "provider", Schema: &jsonSchema{Enum: names}}},
+				Required:   []string{"provider"},
+			},
+			Then: &jsonSchema{Required: []string{"project"}},
+		})
+	}
+
+	env := schema.Properties.get("env")
+	if names := providersWhere(func(p ProviderInfo) bool { return p.ValueOnly }); len(names) > 0 && env != nil {
+		itemPattern := env.PatternProperties[0].Name
+		schema.AllOf = append(schema.AllOf, &jsonSchema{
+			If: &jsonSchema{
+				Properties: schemaProperties{{Name: "provider", Schema: &jsonSchema{Enum: names}}},
+				Required:   []string{"provider"},
+			},
+			Then: &jsonSchema{
+				Properties: schemaProperties{{Name: "env", Schema: &jsonSchema{
+					PatternProperties: schemaProperties{{Name: itemPattern, Schema: &jsonSchema{
+						If:   &jsonSchema{Not: &jsonSchema{Required: []string{"provider"}}},
+						Then: valueOnly,
+					}}},
+				}}},
+			},
+		})
+	}
+}
+
+// decorateEnvItemSchema requires exactly one of secret-key, secret-path and
+// value, or only a value for value-only providers
+func decorateEnvItemSchema(schema *jsonSchema) {
+	oneReference := &jsonSchema{OneOf: []*jsonSchema{
+		{Required: []string{"secret-key"}},
+		{Required: []string{"secret-path"}},
+		{Required: []string{"value"}},
+	}}
+
+	names := providersWhere(func(p ProviderInfo) bool { return p.ValueOnly })
+	if len(names) == 0 {
+		schema.AllOf = []*jsonSchema{oneReference}
+		return
+	}
+	schema.AllOf = []*jsonSchema{{
+		If: &jsonSchema{
+			Properties: schemaProperties{{Name: "provider", Schema: &jsonSchema{Enum: names}}},
+			Required:   []string{"provider"},
+		},
+		Then: valueOnly,
+		Else: oneReference,
+	}}
+}
+
+// decorateSelectRuleSchema requires the environment and a branch or path
+func decorateSelectRuleSchema(schema *jsonSchema) {
+	schema.Required = []string{"env"}
+	schema.AnyOf = []*jsonSchema{
+		{Required: []string{"branch"}},
+		{Required: []string{"path"}},
+	}
+}
+
+// decoratePolicyRuleSchema requires the environment pattern
+func decoratePolicyRuleSchema(schema *jsonSchema) {
+	schema.Required = []string{"env"}
+}
+
+// schemaKeys returns the yaml keys of a struct type that are part of its
+// schema
+func schemaKeys(t reflect.Type) []string {
+	var keys []string
+	for _, prop := range structSchema(t).Properties {
+		keys = append(keys, prop.Name)
+	}
+	return keys
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/mistweaverco/kuba/internal/lib/cache"
+)
+
+func TestCommittedSchemasAreUpToDate(t *testing.T) {
+	tests := []struct {
+		file     string
+		generate func() ([]byte, error)
+		command  string
+	}{
+		{SchemaFileName, GenerateSchema, "kuba schema print"},
+		{GlobalSchemaFileName, GenerateGlobalSchema, "kuba schema print --global"},
+	}
+	for _, tt := range tests {
+		want, err := tt.generate()
+		if err != nil {
+			t.Fatalf("failed to generate %s: %v", tt.file, err)
+		}
+		got, err := os.ReadFile(filepath.Join("..", "..", tt.file))
+		if err != nil {
+			t.Fatalf("failed to read %s: %v", tt.file, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s is stale, regenerate it with: %s > %s", tt.file, tt.command, tt.file)
+		}
+	}
+}
+
+func TestGenerateSchema(t *testing.T) {
+	data, err := GenerateSchema()
+	if err != nil {
+		t.Fatalf("GenerateSchema failed: %v", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	var env map[string]any
+	for pattern, value := range schema["patternProperties"].(map[string]any) {
+		for _, key := range topLevelKeys {
+			if !strings.Contains(pattern, key+"$") {
+				t.Errorf("environment pattern %q does not exclude %q", pattern, key)
+			}
+		}
+		env = value.(map[string]any)
+	}
+	props := env["properties"].(map[string]any)
+	for _, key := range environmentKeys {
+		if _, ok := props[key]; !ok {
+			t.Errorf("environment schema is missing %q", key)
+		}
+	}
+	providers := props["provider"].(map[string]any)["enum"].([]any)
+	if len(providers) != len(Providers) {
+		t.Fatalf("expected %d providers, got %v", len(Providers), providers)
+	}
+	for i, p := range Providers {
+		if providers[i] != p.Name {
+			t.Errorf("provider %d: expected %q, got %v", i, p.Name, providers[i])
+		}
+	}
+
+	item := props["env"].(map[string]any)["patternProperties"].(map[string]any)["^[A-Z0-9_]+$"].(map[string]any)
+	itemProps := item["properties"].(map[string]any)
+	if _, ok := itemProps["environment-variable"]; ok {
+		t.Error("env item schema must not contain environment-variable")
+	}
+	if len(itemProps) != len(envItemKeys) {
+		t.Errorf("expected %d env item keys, got %d", len(envItemKeys), len(itemProps))
+	}
+}
+
+func TestDurationPatternMatchesParseDuration(t *testing.T) {
+	re := regexp.MustCompile(cache.DurationPattern)
+	for _, s := range []string{"1d", "2w", "72h", "2y", "1.5h", "3600", "true", "false", "1h30m", "500ms"} {
+		if !re.MatchString(s) {
+			t.Errorf("pattern does not match %q", s)
+		}
+		if _, _, err := cache.ParseDuration(s); err != nil {
+			t.Errorf("ParseDuration(%q) failed: %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "1x", "soon", "-1d"} {
+		if re.MatchString(s) {
+			t.Errorf("pattern matches %q", s)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mistweaverco/kuba/internal/lib/log"
+)
+
+// EnvironmentEnvVar selects the environment when no --env flag is given
+const EnvironmentEnvVar = "KUBA_ENV"
+
+// DefaultEnvironment is used when nothing else selects an environment
+const DefaultEnvironment = "default"
+
+// How an environment was selected
+const (
+	SelectedByFlag    = "flag"
+	SelectedByEnvVar  = EnvironmentEnvVar
+	SelectedByRule    = "select rule"
+	SelectedByDefault = "default"
+)
+
+// branchEnvVars are checked for the branch name when the git checkout is
+// detached, as it is in most CI systems
+var branchEnvVars = []string{"GITHUB_HEAD_REF", "GITHUB_REF_NAME", "CI_COMMIT_REF_NAME"}
+
+// SelectRule selects an environment for a git branch or a directory. When
+// both are set, both have to match.
+type SelectRule struct {
+	Branch string `yaml:"branch,omitempty" doc:"Glob matched against the current git branch, e.g. release/*."`
+	Path   string `yaml:"path,omitempty" doc:"Directory relative to kuba.yaml, may be a glob. Matches when the working directory is inside it."`
+	Env    string `yaml:"env" doc:"Environment selected when the rule matches."`
+
+	// Source is where the rule is defined
+	Source SourcePosition `yaml:"-"`
+}
+
+// String describes the rule for debug output
+func (r SelectRule) String() string {
+	var parts []string
+	if r.Branch != "" {
+		parts = append(parts, fmt.Sprintf("branch %q", r.Branch))
+	}
+	if r.Path != "" {
+		parts = append(parts, fmt.Sprintf("path %q", r.Path))
+	}
+	s := strings.Join(parts, " and ") + " -> " + r.Env
+	if pos := r.Source.String(); pos != "" {
+		s += " (" + pos + ")"
+	}
+	return s
+}
+
+// EnvironmentSelection is the environment a command uses and why
+type EnvironmentSelection struct {
+	Name string
+	// By is one of the SelectedBy constants
+	By string
+	// Rule is the select rule that matched, if any
+	Rule *SelectRule
+}
+
+// currentBranch returns the git branch checked out in dir, or an empty
+// string when it cannot be determined
+var currentBranch = func(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "symbolic-ref", "--short", "HEAD").Output()
+	if branch := strings.TrimSpace(string(out)); err == nil && branch != "" {
+		return branch
+	}
+	for _, name := range branchEnvVars {
+		if branch := os.Getenv(name); branch != "" {
+			return branch
+		}
+	}
+	return ""
+}
+
+// SelectEnvironment returns the environment to use. An explicit environment,
+// e.g. from --env, wins over KUBA_ENV, which wins over the select rules of
+// the configuration. The first matching rule is used. configPath is the
+// configuration file the rule paths are relative to.
+func (c *KubaConfig) SelectEnvironment(explicit, configPath string) EnvironmentSelection {
+	logger := log.NewLogger()
+
+	selection := EnvironmentSelection{Name: DefaultEnvironment, By: SelectedByDefault}
+	switch {
+	case explicit != "":
+		selection = EnvironmentSelection{Name: explicit, By: SelectedByFlag}
+	case os.Getenv(EnvironmentEnvVar) != "":
+		selection = EnvironmentSelection{Name: os.Getenv(EnvironmentEnvVar), By: SelectedByEnvVar}
+	default:
+		if rule := c.matchSelectRule(configPath); rule != nil {
+			selection = EnvironmentSelection{Name: rule.Env, By: SelectedByRule, Rule: rule}
+		}
+	}
+
+	if selection.Rule != nil {
+		logger.Debug("Environment selected", "environment", selection.Name, "by", selection.By, "rule", selection.Rule.String())
+	} else {
+		logger.Debug("Environment selected", "environment", selection.Name, "by", selection.By)
+	}
+	return selection
+}
+
+// matchSelectRule returns the first select rule matching the current branch
+// and working directory
+func (c *KubaConfig) matchSelectRule(configPath string) *SelectRule {
+	if len(c.Select) == 0 {
+		return nil
+	}
+	logger := log.NewLogger()
+
+	configDir := filepath.Dir(configPath)
+	if abs, err := filepath.Abs(configDir); err == nil {
+		configDir = abs
+	}
+
+	branch, branchLoaded := "", false
+	for i := range c.Select {
+		rule := &c.Select[i]
+		if rule.Branch != "" {
+			if !branchLoaded {
+				branch, branchLoaded = currentBranch(configDir), true
+				logger.Debug("Detected git branch", "branch", branch)
+			}
+			if matched, _ := path.Match(rule.Branch, branch); branch == "" || !matched {
+				continue
+			}
+		}
+		if rule.Path != "" && !workingDirMatches(configDir, rule.Path) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// workingDirMatches reports whether the working directory is inside the
+// directory pattern, relative to configDir
+func workingDirMatches(configDir, pattern string) bool {
+	wd, err := os.Getwd()
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(configDir, wd)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := 1; i <= len(parts); i++ {
+		if matched, _ := path.Match(pattern, strings.Join(parts[:i], "/")); matched {
+			return true
+		}
+	}
+	return false
+}
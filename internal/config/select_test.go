@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func withBranch(t *testing.T, branch string) {
+	t.Helper()
+	original := currentBranch
+	currentBranch = func(string) string { return branch }
+	t.Cleanup(func() { currentBranch = original })
+}
+
+func TestSelectEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "kuba.yaml")
+	if err := os.MkdirAll(filepath.Join(dir, "services", "billing", "cmd"), 0755); err != nil {
+		t.Fatalf("failed to create directories: %v", err)
+	}
+	cfg := &KubaConfig{Select: []SelectRule{
+		{Branch: "release/*", Env: "staging"},
+		{Path: "services/billing", Env: "billing"},
+		{Branch: "main", Path: "services/*", Env: "production"},
+	}}
+
+	tests := []struct {
+		name     string
+		explicit string
+		kubaEnv  string
+		branch   string
+		wd       string
+		wantName string
+		wantBy   string
+	}{
+		{"flag wins", "dev", "qa", "release/1.0", dir, "dev", SelectedByFlag},
+		{"KUBA_ENV wins over rules", "", "qa", "release/1.0", dir, "qa", SelectedByEnvVar},
+		{"branch rule", "", "", "release/1.0", dir, "staging", SelectedByRule},
+		{"path rule in subdirectory", "", "", "feature/x", filepath.Join(dir, "services", "billing", "cmd"), "billing", SelectedByRule},
+		{"first matching rule wins", "", "", "main", filepath.Join(dir, "services", "billing"), "billing", SelectedByRule},
+		{"branch and path", "", "", "main", filepath.Join(dir, "services"), "default", SelectedByDefault},
+		{"no rule matches", "", "", "feature/x", dir, "default", SelectedByDefault},
+		{"unknown branch", "", "", "", dir, "default", SelectedByDefault},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvironmentEnvVar, tt.kubaEnv)
+			withBranch(t, tt.branch)
+			t.Chdir(tt.wd)
+
+			got := cfg.SelectEnvironment(tt.explicit, configPath)
+			if got.Name != tt.wantName || got.By != tt.wantBy {
+				t.Fatalf("expected %s by %s, got %s by %s", tt.wantName, tt.wantBy, got.Name, got.By)
+			}
+			if (got.Rule != nil) != (tt.wantBy == SelectedByRule) {
+				t.Fatalf("unexpected rule %v", got.Rule)
+			}
+		})
+	}
+}
+
+func TestSelectRulesValidation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kuba.yaml")
+	writeConfigFile(t, path, `select:
+  - branch: main
+    env: missing
+  - env: default
+  - brnach: main
+    env: default
+  - branch: "release/["
+    env: default
+default:
+  provider: local
+  env:
+    A:
+      value: "1"
+`)
+
+	_, err := LoadKubaConfig(path)
+	diagnostics := DiagnosticsFromError(err, path)
+	var got []string
+	for _, d := range diagnostics {
+		got = append(got, strings.TrimPrefix(d.String(), path+":"))
+	}
+	want := []string{
+		"2:5: select rule: unknown environment 'missing'",
+		"4:5: select rule: branch or path is required",
+		"5:5: select rule: branch or path is required",
+		"5:5: unknown key 'brnach' (did you mean `branch`?)",
+		"7:5: select rule: invalid branch pattern 'release/['",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("unexpected diagnostics:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSelectRuleString(t *testing.T) {
+	rule := SelectRule{Branch: "release/*", Path: "services/billing", Env: "staging", Source: SourcePosition{File: "kuba.yaml", Line: 2, Column: 5}}
+	want := `branch "release/*" and path "services/billing" -> staging (kuba.yaml:2:5)`
+	if got := rule.String(); got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SourcePosition is the location of a definition in a configuration file
+type SourcePosition struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String returns the position as file:line:column, or an empty string when
+// the position is unknown
+func (p SourcePosition) String() string {
+	if p.File == "" {
+		return ""
+	}
+	if p.Line == 0 {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// positionOf returns the position of a yaml node in file
+func positionOf(file string, node *yaml.Node) SourcePosition {
+	return SourcePosition{File: file, Line: node.Line, Column: node.Column}
+}
+
+// withPosition prefixes err with the position, if it is known
+func withPosition(pos SourcePosition, err error) error {
+	if pos.File == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", pos, err)
+}
+
+// recordSourcePositions sets the source position of the environments, env
+// items, select rules and policy rules of cfg from the nodes in root
+func recordSourcePositions(cfg *KubaConfig, root *yaml.Node, file string) {
+	if root == nil || root.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		envKey, envNode := root.Content[i], root.Content[i+1]
+		if envKey.Value == "select" && envNode.Kind == yaml.SequenceNode {
+			for j, rule := range envNode.Content {
+				if j < len(cfg.Select) {
+					cfg.Select[j].Source = positionOf(file, rule)
+				}
+			}
+			continue
+		}
+		if envKey.Value == "policy" && envNode.Kind == yaml.SequenceNode {
+			for j, rule := range envNode.Content {
+				if j < len(cfg.Policy) {
+					cfg.Policy[j].Source = positionOf(file, rule)
+				}
+			}
+			continue
+		}
+		env, ok := cfg.Environments[envKey.Value]
+		if !ok {
+			continue
+		}
+		env.Source = positionOf(file, envKey)
+
+		if envNode.Kind == yaml.MappingNode {
+			for j := 0; j+1 < len(envNode.Content); j += 2 {
+				if envNode.Content[j].Value != "env" || envNode.Content[j+1].Kind != yaml.MappingNode {
+					continue
+				}
+				items := envNode.Content[j+1]
+				for k := 0; k+1 < len(items.Content); k += 2 {
+					itemKey := items.Content[k]
+					if item, ok := env.Env[itemKey.Value]; ok {
+						item.Source = positionOf(file, itemKey)
+						env.Env[itemKey.Value] = item
+					}
+				}
+			}
+		}
+		cfg.Environments[envKey.Value] = env
+	}
+}
@@ -0,0 +1,200 @@
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Types a resolved value can be validated against
+const (
+	ValueTypeInt    = "int"
+	ValueTypeBool   = "bool"
+	ValueTypeURL    = "url"
+	ValueTypeEmail  = "email"
+	ValueTypeJSON   = "json"
+	ValueTypeBase64 = "base64"
+)
+
+// ValueTypes are the supported value types
+var ValueTypes = []string{ValueTypeInt, ValueTypeBool, ValueTypeURL, ValueTypeEmail, ValueTypeJSON, ValueTypeBase64}
+
+// Validation modes
+const (
+	ValidationStrict = "strict"
+	ValidationWarn   = "warn"
+)
+
+// ValidationModes are the supported validation modes
+var ValidationModes = []string{ValidationStrict, ValidationWarn}
+
+// ValueValidation constrains the resolved value of an env item
+type ValueValidation struct {
+	Type      string   `yaml:"type,omitempty" schema:"value-type" doc:"Type the value must have."`
+	Pattern   string   `yaml:"pattern,omitempty" doc:"Regular expression the value must match. Use ^ and $ to match the whole value."`
+	MinLength int      `yaml:"min-length,omitempty" doc:"Minimum length of the value in characters."`
+	MaxLength int      `yaml:"max-length,omitempty" doc:"Maximum length of the value in characters."`
+	Enum      []string `yaml:"enum,omitempty" doc:"Values the value must be one of."`
+	Mode      string   `yaml:"mode,omitempty" schema:"validation-mode" doc:"strict (default) fails the command when the value is invalid, warn only prints a warning."`
+}
+
+// Strict reports whether an invalid value fails the command
+func (v *ValueValidation) Strict() bool {
+	return v.Mode != ValidationWarn
+}
+
+// Check returns the problems of value. The problems never contain the value.
+func (v *ValueValidation) Check(value string) []string {
+	var problems []string
+	if v.Type != "" {
+		if problem := checkValueType(v.Type, value); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+	if v.Pattern != "" {
+		if re, err := regexp.Compile(v.Pattern); err == nil && !re.MatchString(value) {
+			problems = append(problems, fmt.Sprintf("does not match pattern '%s'", v.Pattern))
+		}
+	}
+	length := utf8.RuneCountInString(value)
+	if v.MinLength > 0 && length < v.MinLength {
+		problems = append(problems, fmt.Sprintf("is shorter than %d characters", v.MinLength))
+	}
+	if v.MaxLength > 0 && length > v.MaxLength {
+		problems = append(problems, fmt.Sprintf("is longer than %d characters", v.MaxLength))
+	}
+	if len(v.Enum) > 0 && !slices.Contains(v.Enum, value) {
+		problems = append(problems, fmt.Sprintf("is not one of: %s", strings.Join(v.Enum, ", ")))
+	}
+	return problems
+}
+
+// checkValueType returns why value is not of the given type, or an empty
+// string when it is
+func checkValueType(valueType, value string) string {
+	switch valueType {
+	case ValueTypeInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return "is not an integer"
+		}
+	case ValueTypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "is not a boolean"
+		}
+	case ValueTypeURL:
+		u, err := url.Parse(value)
+		switch {
+		case err != nil:
+			return "is not a valid URL"
+		case u.Scheme == "":
+			return "is not a valid URL: missing scheme"
+		case u.Host == "" && u.Path == "":
+			return "is not a valid URL: missing host"
+		}
+	case ValueTypeEmail:
+		if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+			return "is not a valid email address"
+		}
+	case ValueTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return "is not valid JSON"
+		}
+	case ValueTypeBase64:
+		for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+			if _, err := encoding.DecodeString(value); err == nil {
+				return ""
+			}
+		}
+		return "is not valid base64"
+	}
+	return ""
+}
+
+// ruleProblems returns what is wrong with the validation rules themselves
+func (v *ValueValidation) ruleProblems() []string {
+	var problems []string
+	if v.Type != "" && !slices.Contains(ValueTypes, v.Type) {
+		problems = append(problems, fmt.Sprintf("invalid type '%s': must be one of: %s", v.Type, strings.Join(ValueTypes, ", ")))
+	}
+	if v.Mode != "" && !slices.Contains(ValidationModes, v.Mode) {
+		problems = append(problems, fmt.Sprintf("invalid mode '%s': must be one of: %s", v.Mode, strings.Join(ValidationModes, ", ")))
+	}
+	if v.Pattern != "" {
+		if _, err := regexp.Compile(v.Pattern); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid pattern '%s': %v", v.Pattern, err))
+		}
+	}
+	if v.MinLength < 0 || v.MaxLength < 0 {
+		problems = append(problems, "min-length and max-length must not be negative")
+	} else if v.MaxLength > 0 && v.MinLength > v.MaxLength {
+		problems = append(problems, "min-length is greater than max-length")
+	}
+	return problems
+}
+
+// ValueCheck is the result of validating the resolved value of a variable
+type ValueCheck struct {
+	Variable string
+	Strict   bool
+	// Problems describe why the value is invalid, without the value itself
+	Problems []string
+	// Source is where the env item with the rules is defined
+	Source SourcePosition
+}
+
+// Passed reports whether the value satisfies all rules
+func (c ValueCheck) Passed() bool {
+	return len(c.Problems) == 0
+}
+
+// String describes the result without the value
+func (c ValueCheck) String() string {
+	if c.Passed() {
+		return c.Variable + ": ok"
+	}
+	return c.Variable + ": " + strings.Join(c.Problems, "; ")
+}
+
+// CheckValues validates the resolved values of the environment against the
+// validate rules of its env items. Variables expanded from a secret-path,
+// mapped to the name of the path's item in expanded, are checked against the
+// rules of that item. Variables missing from values are not checked.
+func (e *Environment) CheckValues(values, expanded map[string]string) []ValueCheck {
+	var checks []ValueCheck
+	for name, item := range e.Env {
+		if item.Validate == nil {
+			continue
+		}
+		check := func(variable string) {
+			value, ok := values[variable]
+			if !ok {
+				return
+			}
+			checks = append(checks, ValueCheck{
+				Variable: variable,
+				Strict:   item.Validate.Strict(),
+				Problems: item.Validate.Check(value),
+				Source:   item.Source,
+			})
+		}
+		if item.SecretPath != "" {
+			for variable, pathItem := range expanded {
+				if pathItem == name {
+					check(variable)
+				}
+			}
+			continue
+		}
+		check(name)
+	}
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Variable < checks[j].Variable })
+	return checks
+}
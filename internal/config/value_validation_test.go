@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValueValidationCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    ValueValidation
+		value    string
+		problems []string
+	}{
+		{"int", ValueValidation{Type: ValueTypeInt}, "8080", nil},
+		{"int invalid", ValueValidation{Type: ValueTypeInt}, "80a", []string{"is not an integer"}},
+		{"bool", ValueValidation{Type: ValueTypeBool}, "true", nil},
+		{"bool invalid", ValueValidation{Type: ValueTypeBool}, "yes", []string{"is not a boolean"}},
+		{"url", ValueValidation{Type: ValueTypeURL}, "postgres://db:5432/app", nil},
+		{"url without scheme", ValueValidation{Type: ValueTypeURL}, "db.example.com/app", []string{"is not a valid URL: missing scheme"}},
+		{"url without host", ValueValidation{Type: ValueTypeURL}, "localhost:5432", []string{"is not a valid URL: missing host"}},
+		{"email", ValueValidation{Type: ValueTypeEmail}, "ops@example.com", nil},
+		{"email with name", ValueValidation{Type: ValueTypeEmail}, "Ops <ops@example.com>", []string{"is not a valid email address"}},
+		{"json", ValueValidation{Type: ValueTypeJSON}, `{"a": 1}`, nil},
+		{"json invalid", ValueValidation{Type: ValueTypeJSON}, `{a: 1}`, []string{"is not valid JSON"}},
+		{"base64", ValueValidation{Type: ValueTypeBase64}, "c2VjcmV0", nil},
+		{"base64 unpadded", ValueValidation{Type: ValueTypeBase64}, "c2VjcmV0MQ", nil},
+		{"base64 invalid", ValueValidation{Type: ValueTypeBase64}, "not base64!", []string{"is not valid base64"}},
+		{"pattern", ValueValidation{Pattern: `^sk_[a-z]+$`}, "sk_live", nil},
+		{"pattern mismatch", ValueValidation{Pattern: `^sk_[a-z]+$`}, "pk_live", []string{"does not match pattern '^sk_[a-z]+$'"}},
+		{"length", ValueValidation{MinLength: 2, MaxLength: 4}, "äbc", nil},
+		{"too short", ValueValidation{MinLength: 32}, "short", []string{"is shorter than 32 characters"}},
+		{"too long", ValueValidation{MaxLength: 3}, "long", []string{"is longer than 3 characters"}},
+		{"enum", ValueValidation{Enum: []string{"debug", "info"}}, "info", nil},
+		{"enum mismatch", ValueValidation{Enum: []string{"debug", "info"}}, "trace", []string{"is not one of: debug, info"}},
+		{
+			"several problems",
+			ValueValidation{Type: ValueTypeInt, MaxLength: 2},
+			"abc",
+			[]string{"is not an integer", "is longer than 2 characters"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.problems, tt.rules.Check(tt.value))
+		})
+	}
+}
+
+func TestCheckValues(t *testing.T) {
+	env := Environment{Env: map[string]EnvItem{
+		"PORT":      {Value: "80a", Validate: &ValueValidation{Type: ValueTypeInt}},
+		"LOG":       {Value: "trace", Validate: &ValueValidation{Enum: []string{"info"}, Mode: ValidationWarn}},
+		"APP":       {SecretPath: "app", Validate: &ValueValidation{MinLength: 4}},
+		"MISSING":   {SecretKey: "missing", Validate: &ValueValidation{Type: ValueTypeInt}},
+		"UNCHECKED": {Value: "anything"},
+	}}
+	values := map[string]string{
+		"PORT":      "80a",
+		"LOG":       "trace",
+		"APP_TOKEN": "abc",
+		"APP_KEY":   "abcd",
+		"APP_OTHER": "x",
+		"UNCHECKED": "anything",
+	}
+	// APP_OTHER shares the prefix but doesn't come from the APP secret-path
+	expanded := map[string]string{"APP_TOKEN": "APP", "APP_KEY": "APP"}
+
+	checks := env.CheckValues(values, expanded)
+	require.Equal(t, []ValueCheck{
+		{Variable: "APP_KEY", Strict: true},
+		{Variable: "APP_TOKEN", Strict: true, Problems: []string{"is shorter than 4 characters"}},
+		{Variable: "LOG", Strict: false, Problems: []string{"is not one of: info"}},
+		{Variable: "PORT", Strict: true, Problems: []string{"is not an integer"}},
+	}, checks)
+
+	for _, check := range checks {
+		for _, value := range values {
+			require.NotContains(t, check.String(), value)
+		}
+	}
+}
+
+func TestValidateRulesLoading(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kuba.yaml")
+	content := `
+default:
+  provider: local
+  env:
+    PORT:
+      value: 8080
+      validate:
+        type: int
+    LOG_LEVEL:
+      value: info
+      validate:
+        enum: [debug, info]
+        mode: warn
+staging:
+  inherits: default
+  env:
+    PORT:
+      value: 9090
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	cfg, err := LoadKubaConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, &ValueValidation{Type: ValueTypeInt}, cfg.Environments["default"].Env["PORT"].Validate)
+	require.Equal(t, &ValueValidation{Enum: []string{"debug", "info"}, Mode: ValidationWarn}, cfg.Environments["default"].Env["LOG_LEVEL"].Validate)
+	// Overriding the value keeps the inherited rules
+	require.Equal(t, &ValueValidation{Type: ValueTypeInt}, cfg.Environments["staging"].Env["PORT"].Validate)
+}
+
+func TestValidateRulesValidation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "kuba.yaml")
+	content := `
+default:
+  provider: local
+  env:
+    A:
+      value: a
+      validate:
+        type: integer
+        mode: lenient
+    B:
+      value: b
+      validate:
+        pattern: "("
+        min-length: 4
+        max-length: 2
+    C:
+      value: c
+      validate:
+        max-lenght: 2
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	_, err := LoadKubaConfig(path)
+	require.Error(t, err)
+	diagnostics := DiagnosticsFromError(err, path)
+
+	var texts []string
+	for _, d := range diagnostics {
+		texts = append(texts, d.Text())
+	}
+	require.Equal(t, []string{
+		"environment 'default': env item 'A': validate: invalid mode 'lenient': must be one of: strict, warn",
+		"environment 'default': env item 'A': validate: invalid type 'integer': must be one of: int, bool, url, email, json, base64",
+		"environment 'default': env item 'B': validate: invalid pattern '(': error parsing regexp: missing closing ): `(`",
+		"environment 'default': env item 'B': validate: min-length is greater than max-length",
+		"environment 'default': env item 'C': unknown key 'max-lenght' (did you mean `max-length`?)",
+	}, texts)
+	require.True(t, strings.HasSuffix(diagnostics[4].Position.String(), "kuba.yaml:19:9"))
+}
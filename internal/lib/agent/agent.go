@@ -0,0 +1,70 @@
+// Package agent implements the kuba secrets agent, a long-lived per-user
+// daemon that keeps authenticated secret managers and an in-memory cache
+// behind a Unix socket so that commands like `kuba run` don't have to
+// re-authenticate with every provider on each invocation.
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/lib/fileutils"
+)
+
+// Operations supported by the agent
+const (
+	OpGetSecrets       = "get-secrets"
+	OpGetSecretsByPath = "get-secrets-by-path"
+	OpForget           = "forget"
+	OpStatus           = "status"
+	OpStop             = "stop"
+)
+
+// Request is a single request sent to the agent
+type Request struct {
+	Op        string   `json:"op"`
+	Provider  string   `json:"provider,omitempty"`
+	Project   string   `json:"project,omitempty"`
+	SecretIDs []string `json:"secret_ids,omitempty"`
+	Path      string   `json:"path,omitempty"`
+}
+
+// Response is the agent's answer to a Request
+type Response struct {
+	Secrets map[string]string `json:"secrets,omitempty"`
+	Status  *Status           `json:"status,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// Status describes a running agent
+type Status struct {
+	PID           int       `json:"pid"`
+	SocketPath    string    `json:"socket_path"`
+	StartedAt     time.Time `json:"started_at"`
+	LastActivity  time.Time `json:"last_activity"`
+	IdleTimeout   string    `json:"idle_timeout"`
+	CacheTTL      string    `json:"cache_ttl"`
+	Managers      []string  `json:"managers"`
+	CachedEntries int       `json:"cached_entries"`
+}
+
+// SecretReader is the part of a secret manager the agent needs
+type SecretReader interface {
+	GetSecrets(projectID string, secretIDs []string) (map[string]string, error)
+	GetSecretsByPath(projectID, secretPath string) (map[string]string, error)
+	Close() error
+}
+
+// SocketPath returns the path of the agent socket.
+// KUBA_AGENT_SOCKET overrides the location, otherwise the socket lives in
+// $XDG_RUNTIME_DIR/kuba or the kuba app data directory.
+func SocketPath() string {
+	if socketPath := os.Getenv("KUBA_AGENT_SOCKET"); socketPath != "" {
+		return socketPath
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "kuba", "agent.sock")
+	}
+	return filepath.Join(fileutils.GetAppDataPath(), "agent.sock")
+}
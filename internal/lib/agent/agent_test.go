@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeReader struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeReader) GetSecrets(projectID string, secretIDs []string) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	secrets := make(map[string]string, len(secretIDs))
+	for _, id := range secretIDs {
+		secrets[id] = projectID + "/" + id
+	}
+	return secrets, nil
+}
+
+func (f *fakeReader) GetSecretsByPath(projectID, secretPath string) (map[string]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return map[string]string{"NAME": projectID + secretPath}, nil
+}
+
+func (f *fakeReader) Close() error { return nil }
+
+func startTestServer(t *testing.T, idleTimeout time.Duration) (*Client, *fakeReader, *int, chan error) {
+	t.Helper()
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("agent peer credentials are not supported on " + runtime.GOOS)
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	reader := &fakeReader{}
+	created := 0
+	newManager := func(ctx context.Context, provider, project string) (SecretReader, error) {
+		created++
+		return reader, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	server := NewServer(socketPath, newManager, idleTimeout, time.Minute)
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx) }()
+
+	var client *Client
+	var err error
+	for i := 0; i < 100; i++ {
+		if client, err = DialPath(socketPath); err == nil {
+			return client, reader, &created, done
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("agent did not start: %v", err)
+	return nil, nil, nil, nil
+}
+
+func TestAgentReusesManagersAndCachesSecrets(t *testing.T) {
+	client, reader, created, done := startTestServer(t, 0)
+
+	for i := 0; i < 2; i++ {
+		secrets, err := client.GetSecrets("gcp", "proj", []string{"db-password"})
+		if err != nil {
+			t.Fatalf("GetSecrets() error: %v", err)
+		}
+		if secrets["db-password"] != "proj/db-password" {
+			t.Fatalf("unexpected secrets: %#v", secrets)
+		}
+	}
+	if reader.calls != 1 {
+		t.Fatalf("expected second lookup to be served from cache, provider called %d times", reader.calls)
+	}
+
+	if _, err := client.GetSecrets("gcp", "proj", []string{"api-key"}); err != nil {
+		t.Fatalf("GetSecrets() error: %v", err)
+	}
+	if *created != 1 {
+		t.Fatalf("expected the manager to be reused, created %d", *created)
+	}
+
+	status, err := client.Status()
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if len(status.Managers) != 1 || status.Managers[0] != "gcp:proj" || status.CachedEntries != 2 {
+		t.Fatalf("unexpected status: %#v", status)
+	}
+
+	if err := client.Stop(); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Serve() error: %v", err)
+	}
+}
+
+func TestAgentStopsAfterIdleTimeout(t *testing.T) {
+	_, _, _, done := startTestServer(t, 50*time.Millisecond)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Serve() error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("agent did not stop after idle timeout")
+	}
+}
+
+func TestAgentForgetsCachedSecrets(t *testing.T) {
+	client, reader, _, _ := startTestServer(t, 0)
+
+	if _, err := client.GetSecrets("gcp", "proj", []string{"db-password", "api-key"}); err != nil {
+		t.Fatalf("GetSecrets() error: %v", err)
+	}
+	if _, err := client.GetSecretsByPath("gcp", "proj", "app/"); err != nil {
+		t.Fatalf("GetSecretsByPath() error: %v", err)
+	}
+	if err := client.Forget("gcp", "proj", []string{"db-password"}); err != nil {
+		t.Fatalf("Forget() error: %v", err)
+	}
+
+	status, err := client.Status()
+	if err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+	if status.CachedEntries != 1 {
+		t.Fatalf("expected only api-key to stay cached, got %d entries", status.CachedEntries)
+	}
+
+	calls := reader.calls
+	if _, err := client.GetSecrets("gcp", "proj", []string{"db-password"}); err != nil {
+		t.Fatalf("GetSecrets() error: %v", err)
+	}
+	if reader.calls != calls+1 {
+		t.Fatalf("expected forgotten secret to be fetched again")
+	}
+	_ = client.Stop()
+}
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+)
+
+// dialTimeout bounds how long clients wait for the agent to accept a connection
+const dialTimeout = 200 * time.Millisecond
+
+// Client talks to a running agent
+type Client struct {
+	socketPath string
+}
+
+// Dial returns a client for the agent listening on the default socket path.
+// It fails if no agent is running.
+func Dial() (*Client, error) {
+	return DialPath(SocketPath())
+}
+
+// DialPath returns a client for the agent listening on socketPath.
+// It fails if no agent is running.
+func DialPath(socketPath string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", socketPath, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("agent is not running: %w", err)
+	}
+	conn.Close()
+	return &Client{socketPath: socketPath}, nil
+}
+
+// GetSecrets fetches secrets through the agent
+func (c *Client) GetSecrets(provider, project string, secretIDs []string) (map[string]string, error) {
+	resp, err := c.do(Request{Op: OpGetSecrets, Provider: provider, Project: project, SecretIDs: secretIDs})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Secrets, nil
+}
+
+// GetSecretsByPath fetches all secrets below a path through the agent
+func (c *Client) GetSecretsByPath(provider, project, secretPath string) (map[string]string, error) {
+	resp, err := c.do(Request{Op: OpGetSecretsByPath, Provider: provider, Project: project, Path: secretPath})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Secrets, nil
+}
+
+// Forget drops cached secrets from the agent. Without secretIDs all cached
+// secrets of the provider and project are dropped.
+func (c *Client) Forget(provider, project string, secretIDs []string) error {
+	_, err := c.do(Request{Op: OpForget, Provider: provider, Project: project, SecretIDs: secretIDs})
+	return err
+}
+
+// Status returns the status of the agent
+func (c *Client) Status() (*Status, error) {
+	resp, err := c.do(Request{Op: OpStatus})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// Stop asks the agent to shut down
+func (c *Client) Stop() error {
+	_, err := c.do(Request{Op: OpStop})
+	return err
+}
+
+// do sends a single request over a fresh connection
+func (c *Client) do(req Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", c.socketPath, dialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to agent: %w", err)
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request to agent: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response from agent: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("agent: %s", resp.Error)
+	}
+	return &resp, nil
+}
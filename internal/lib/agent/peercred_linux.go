@@ -0,0 +1,29 @@
+//go:build linux
+
+package agent
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// peerUID returns the user ID of the process on the other end of conn
+func peerUID(conn *net.UnixConn) (int, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return -1, err
+	}
+
+	var cred *syscall.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return -1, err
+	}
+	if credErr != nil {
+		return -1, fmt.Errorf("failed to read peer credentials: %w", credErr)
+	}
+	return int(cred.Uid), nil
+}
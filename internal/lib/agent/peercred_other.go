@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package agent
+
+import (
+	"fmt"
+	"net"
+	"runtime"
+)
+
+// peerUID is not supported on this platform, so every peer is refused
+func peerUID(conn *net.UnixConn) (int, error) {
+	return -1, fmt.Errorf("peer credentials are not supported on %s", runtime.GOOS)
+}
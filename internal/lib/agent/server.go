@@ -0,0 +1,377 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/lib/cache"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+)
+
+// ManagerFactory creates an authenticated secret manager for a provider and project
+type ManagerFactory func(ctx context.Context, provider, project string) (SecretReader, error)
+
+// pathEntryPrefix marks cache entries that hold the JSON encoded result of a path lookup
+const pathEntryPrefix = "path:"
+
+// pooledManager is a secret manager kept alive by the agent. Calls are
+// serialized since not all provider SDKs are safe for concurrent use.
+type pooledManager struct {
+	mu      sync.Mutex
+	manager SecretReader
+}
+
+// Server is the agent daemon
+type Server struct {
+	socketPath  string
+	newManager  ManagerFactory
+	idleTimeout time.Duration
+	cacheTTL    time.Duration
+
+	mu           sync.Mutex
+	managers     map[string]*pooledManager
+	cache        *cache.MemoryBackend
+	startedAt    time.Time
+	lastActivity time.Time
+	listener     net.Listener
+	stopOnce     sync.Once
+}
+
+// NewServer creates an agent listening on socketPath. An idleTimeout of zero
+// keeps the agent running until it is stopped, a cacheTTL of zero disables
+// the in-memory cache.
+func NewServer(socketPath string, newManager ManagerFactory, idleTimeout, cacheTTL time.Duration) *Server {
+	return &Server{
+		socketPath:  socketPath,
+		newManager:  newManager,
+		idleTimeout: idleTimeout,
+		cacheTTL:    cacheTTL,
+		managers:    make(map[string]*pooledManager),
+		cache:       cache.NewMemoryBackend(),
+	}
+}
+
+// Serve listens on the socket and handles requests until the context is
+// cancelled, the agent is stopped or the idle timeout expires
+func (s *Server) Serve(ctx context.Context) error {
+	logger := log.NewLogger()
+
+	listener, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.startedAt = time.Now()
+	s.lastActivity = s.startedAt
+	s.mu.Unlock()
+
+	defer s.closeManagers()
+	defer os.Remove(s.socketPath)
+
+	go func() {
+		<-ctx.Done()
+		s.Stop()
+	}()
+	if s.idleTimeout > 0 {
+		go s.watchIdle(ctx)
+	}
+
+	logger.Debug("Agent listening", "socket", s.socketPath, "idle_timeout", s.idleTimeout, "cache_ttl", s.cacheTTL)
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		go s.handleConn(ctx, conn.(*net.UnixConn))
+	}
+}
+
+// Stop shuts the agent down
+func (s *Server) Stop() {
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.listener != nil {
+			s.listener.Close()
+		}
+	})
+}
+
+// listen creates the socket, refusing to start if another agent is running
+func (s *Server) listen() (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if _, err := os.Stat(s.socketPath); err == nil {
+		if _, err := DialPath(s.socketPath); err == nil {
+			return nil, fmt.Errorf("agent is already running on %s", s.socketPath)
+		}
+		// Remove the stale socket of an agent that did not shut down cleanly
+		if err := os.Remove(s.socketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", s.socketPath, err)
+	}
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+// watchIdle stops the agent once no request arrived within the idle timeout
+func (s *Server) watchIdle(ctx context.Context) {
+	interval := s.idleTimeout / 10
+	if interval < 10*time.Millisecond {
+		interval = 10 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			idle := time.Since(s.lastActivity)
+			s.mu.Unlock()
+			if idle >= s.idleTimeout {
+				log.NewLogger().Debug("Agent idle timeout reached", "idle", idle)
+				s.Stop()
+				return
+			}
+		}
+	}
+}
+
+// handleConn serves a single request
+func (s *Server) handleConn(ctx context.Context, conn *net.UnixConn) {
+	logger := log.NewLogger()
+	defer conn.Close()
+
+	uid, err := peerUID(conn)
+	if err != nil || uid != os.Getuid() {
+		logger.Debug("Refusing agent connection", "peer_uid", uid, "error", err)
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		logger.Debug("Failed to decode agent request", "error", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.lastActivity = time.Now()
+	s.mu.Unlock()
+
+	resp := s.handle(ctx, req)
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		logger.Debug("Failed to encode agent response", "error", err)
+	}
+
+	if req.Op == OpStop {
+		s.Stop()
+	}
+}
+
+// handle dispatches a request to its operation
+func (s *Server) handle(ctx context.Context, req Request) Response {
+	switch req.Op {
+	case OpGetSecrets:
+		secrets, err := s.getSecrets(ctx, req.Provider, req.Project, req.SecretIDs)
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		return Response{Secrets: secrets}
+	case OpGetSecretsByPath:
+		secrets, err := s.getSecretsByPath(ctx, req.Provider, req.Project, req.Path)
+		if err != nil {
+			return Response{Error: err.Error()}
+		}
+		return Response{Secrets: secrets}
+	case OpForget:
+		s.forget(req.Provider, req.Project, req.SecretIDs)
+		return Response{}
+	case OpStatus:
+		return Response{Status: s.status()}
+	case OpStop:
+		return Response{}
+	default:
+		return Response{Error: fmt.Sprintf("unsupported operation: %s", req.Op)}
+	}
+}
+
+// getSecrets serves secrets from the cache and fetches the missing ones
+func (s *Server) getSecrets(ctx context.Context, provider, project string, secretIDs []string) (map[string]string, error) {
+	secrets := make(map[string]string, len(secretIDs))
+	var missing []string
+	for _, secretID := range secretIDs {
+		if value, found, _ := s.cache.Get(provider, project, secretID); found {
+			secrets[secretID] = value
+		} else {
+			missing = append(missing, secretID)
+		}
+	}
+	if len(missing) == 0 {
+		return secrets, nil
+	}
+
+	var fetched map[string]string
+	err := s.withManager(ctx, provider, project, func(m SecretReader) error {
+		var err error
+		fetched, err = m.GetSecrets(project, missing)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for secretID, value := range fetched {
+		secrets[secretID] = value
+		if s.cacheTTL > 0 {
+			s.cache.Set(provider, project, secretID, value, s.cacheTTL)
+		}
+	}
+	return secrets, nil
+}
+
+// getSecretsByPath serves a path lookup from the cache or the provider
+func (s *Server) getSecretsByPath(ctx context.Context, provider, project, secretPath string) (map[string]string, error) {
+	cacheKey := pathEntryPrefix + secretPath
+	if value, found, _ := s.cache.Get(provider, project, cacheKey); found {
+		var secrets map[string]string
+		if err := json.Unmarshal([]byte(value), &secrets); err == nil {
+			return secrets, nil
+		}
+	}
+
+	var secrets map[string]string
+	err := s.withManager(ctx, provider, project, func(m SecretReader) error {
+		var err error
+		secrets, err = m.GetSecretsByPath(project, secretPath)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cacheTTL > 0 {
+		if data, err := json.Marshal(secrets); err == nil {
+			s.cache.Set(provider, project, cacheKey, string(data), s.cacheTTL)
+		}
+	}
+	return secrets, nil
+}
+
+// forget drops cached secrets of a provider and project, so that the next
+// request fetches them again. Path lookups for the provider and project are
+// always dropped since they may contain any of the secrets.
+func (s *Server) forget(provider, project string, secretIDs []string) {
+	if len(secretIDs) == 0 {
+		_ = s.cache.ClearByEnvironment(provider, project)
+		return
+	}
+	for _, secretID := range secretIDs {
+		_ = s.cache.Delete(provider, project, secretID)
+	}
+	entries, _ := s.cache.List()
+	for _, entry := range entries {
+		if entry.Path == provider && entry.KubaEnv == project && strings.HasPrefix(entry.Env, pathEntryPrefix) {
+			_ = s.cache.Delete(provider, project, entry.Env)
+		}
+	}
+}
+
+// withManager runs fn with the pooled manager for provider and project,
+// creating it on first use. Managers that fail are dropped so that the next
+// request authenticates again.
+func (s *Server) withManager(ctx context.Context, provider, project string, fn func(SecretReader) error) error {
+	key := provider + ":" + project
+
+	s.mu.Lock()
+	pooled, ok := s.managers[key]
+	if !ok {
+		pooled = &pooledManager{}
+		s.managers[key] = pooled
+	}
+	s.mu.Unlock()
+
+	pooled.mu.Lock()
+	defer pooled.mu.Unlock()
+
+	if pooled.manager == nil {
+		manager, err := s.newManager(ctx, provider, project)
+		if err != nil {
+			return fmt.Errorf("failed to create secret manager for %s: %w", provider, err)
+		}
+		pooled.manager = manager
+	}
+
+	if err := fn(pooled.manager); err != nil {
+		pooled.manager.Close()
+		pooled.manager = nil
+		return err
+	}
+	return nil
+}
+
+// status reports the current agent state
+func (s *Server) status() *Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	managers := make([]string, 0, len(s.managers))
+	for key := range s.managers {
+		managers = append(managers, key)
+	}
+	sort.Strings(managers)
+
+	entries, _ := s.cache.List()
+	return &Status{
+		PID:           os.Getpid(),
+		SocketPath:    s.socketPath,
+		StartedAt:     s.startedAt,
+		LastActivity:  s.lastActivity,
+		IdleTimeout:   s.idleTimeout.String(),
+		CacheTTL:      s.cacheTTL.String(),
+		Managers:      managers,
+		CachedEntries: len(entries),
+	}
+}
+
+// closeManagers closes all pooled managers
+func (s *Server) closeManagers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, pooled := range s.managers {
+		pooled.mu.Lock()
+		if pooled.manager != nil {
+			pooled.manager.Close()
+			pooled.manager = nil
+		}
+		pooled.mu.Unlock()
+		delete(s.managers, key)
+	}
+}
@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testBackend(t *testing.T, b Backend) {
+	t.Helper()
+
+	if err := b.Set("/p/kuba.yaml", "default", "DB_PASSWORD", "s3cret", time.Hour); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if err := b.Set("/p/kuba.yaml", "staging", "DB_PASSWORD", "old", -time.Hour); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+
+	value, found, err := b.Get("/p/kuba.yaml", "default", "DB_PASSWORD")
+	if err != nil || !found || value != "s3cret" {
+		t.Fatalf("Get() = %q, %v, %v", value, found, err)
+	}
+	if _, found, _ := b.Get("/p/kuba.yaml", "staging", "DB_PASSWORD"); found {
+		t.Fatalf("expected expired entry to be hidden from Get()")
+	}
+	entry, err := b.GetEntry("/p/kuba.yaml", "staging", "DB_PASSWORD")
+	if err != nil || entry == nil || entry.Value != "old" {
+		t.Fatalf("GetEntry() = %#v, %v", entry, err)
+	}
+
+	entries, err := b.List()
+	if err != nil || len(entries) != 2 || entries[0].KubaEnv != "default" {
+		t.Fatalf("List() = %#v, %v", entries, err)
+	}
+
+	count, err := b.UpdateExpiry("", "staging", "", time.Hour)
+	if err != nil || count != 1 {
+		t.Fatalf("UpdateExpiry() = %d, %v", count, err)
+	}
+	if _, found, _ := b.Get("/p/kuba.yaml", "staging", "DB_PASSWORD"); !found {
+		t.Fatalf("expected entry to be valid after UpdateExpiry()")
+	}
+
+	count, err = b.ClearFiltered("/p/kuba.yaml", "default", "", false)
+	if err != nil || count != 1 {
+		t.Fatalf("ClearFiltered() = %d, %v", count, err)
+	}
+	if err := b.Clear(); err != nil {
+		t.Fatalf("Clear() error: %v", err)
+	}
+	if entries, _ := b.List(); len(entries) != 0 {
+		t.Fatalf("expected empty cache after Clear(), got %d entries", len(entries))
+	}
+}
+
+func TestMemoryBackend(t *testing.T) {
+	testBackend(t, NewMemoryBackend())
+}
+
+func TestFileBackendPersistsEncryptedEntries(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("KUBA_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	b, err := NewFileBackend(0)
+	if err != nil {
+		t.Fatalf("NewFileBackend() error: %v", err)
+	}
+	testBackend(t, b)
+
+	if err := b.Set("/p/kuba.yaml", "default", "API_KEY", "persisted", time.Hour); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	b.Close()
+
+	reopened, err := NewFileBackend(0)
+	if err != nil {
+		t.Fatalf("NewFileBackend() reopen error: %v", err)
+	}
+	defer reopened.Close()
+	value, found, err := reopened.Get("/p/kuba.yaml", "default", "API_KEY")
+	if err != nil || !found || value != "persisted" {
+		t.Fatalf("Get() after reopen = %q, %v, %v", value, found, err)
+	}
+}
+
+func TestFileBackendKeepsWritesOfOtherBackends(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("KUBA_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	// Like the workers of --recursive, every backend writes its own entries
+	backends := make([]*FileBackend, 4)
+	for i := range backends {
+		b, err := NewFileBackend(0)
+		if err != nil {
+			t.Fatalf("NewFileBackend() error: %v", err)
+		}
+		defer b.Close()
+		backends[i] = b
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(backends)*10)
+	for i, b := range backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				errs <- b.Set(fmt.Sprintf("/p%d/kuba.yaml", i), "default", fmt.Sprintf("VAR_%d", j), "value", time.Hour)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Set() error: %v", err)
+		}
+	}
+
+	reopened, err := NewFileBackend(0)
+	if err != nil {
+		t.Fatalf("NewFileBackend() reopen error: %v", err)
+	}
+	defer reopened.Close()
+	entries, err := reopened.List()
+	if err != nil || len(entries) != len(backends)*10 {
+		t.Fatalf("List() after concurrent writes = %d entries, %v, want %d", len(entries), err, len(backends)*10)
+	}
+}
+
+func TestLoadOrCreateKeyAgreesOnOneKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.key")
+
+	keys := make([][]byte, 8)
+	errs := make([]error, len(keys))
+	var wg sync.WaitGroup
+	for i := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys[i], errs[i] = loadOrCreateKey(path)
+		}()
+	}
+	wg.Wait()
+
+	stored, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read key: %v", err)
+	}
+	for i, key := range keys {
+		if errs[i] != nil {
+			t.Fatalf("loadOrCreateKey() error: %v", errs[i])
+		}
+		if !bytes.Equal(key, stored) {
+			t.Fatalf("loadOrCreateKey() returned a key that differs from the stored one")
+		}
+	}
+}
+
+func TestNewBackendRejectsUnknownBackend(t *testing.T) {
+	if _, err := NewBackend(CacheConfig{Backend: "redis"}); err == nil {
+		t.Fatalf("expected error for unknown backend")
+	}
+}
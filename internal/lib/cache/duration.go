@@ -8,6 +8,9 @@ import (
 	"time"
 )
 
+// DurationPattern matches the duration strings accepted by ParseDuration
+const DurationPattern = `^(\d+(?:\.\d+)?[smhdwy]?|true|false|(\d+(?:\.\d+)?(?:ns|us|µs|ms|s|m|h))+)$`
+
 // ParseDuration parses a duration string in various formats
 // Supports: "1d", "2w", "72h", "2y", "3600" (seconds), "true" (default 12h), "false" (disabled)
 func ParseDuration(duration interface{}) (time.Duration, bool, error) {
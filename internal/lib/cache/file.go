@@ -0,0 +1,279 @@
+package cache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/lib/fileutils"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+)
+
+// fileMu serializes changes of the cache file by backends of the same
+// process; lockFile does the same across processes
+var fileMu sync.Mutex
+
+// FileBackend is a Backend that stores secrets in an AES-GCM encrypted file.
+// The encryption key lives in the kuba app data directory, separate from the
+// cache directory. Entries are held in memory. Every change locks the file,
+// reloads it so changes of other backends are kept, and rewrites it.
+type FileBackend struct {
+	*MemoryBackend
+	path string
+	key  []byte
+}
+
+// NewFileBackend opens the encrypted cache file. Expired entries are kept
+// around for the given retention so they can be served as stale values.
+func NewFileBackend(retention time.Duration) (*FileBackend, error) {
+	logger := log.NewLogger()
+
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache directory: %w", err)
+	}
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	key, err := loadOrCreateKey(filepath.Join(fileutils.GetAppDataPath(), "cache.key"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load cache encryption key: %w", err)
+	}
+
+	b := &FileBackend{
+		MemoryBackend: NewMemoryBackend(),
+		path:          filepath.Join(cacheDir, "secrets.enc"),
+		key:           key,
+	}
+	logger.Debug("Opening cache file", "path", b.path)
+
+	if err := b.load(); err != nil {
+		return nil, fmt.Errorf("failed to read cache file: %w", err)
+	}
+
+	// Clean up expired entries
+	if b.cleanupExpired(retention) > 0 {
+		err := b.update(func() (bool, error) {
+			return b.cleanupExpired(retention) > 0, nil
+		})
+		if err != nil {
+			logger.Debug("Failed to cleanup expired entries", "error", err)
+		}
+	}
+
+	logger.Debug("Cache initialized successfully", "path", b.path)
+	return b, nil
+}
+
+// Set stores a secret in the cache
+func (f *FileBackend) Set(path, kubaEnv, env, value string, ttl time.Duration) error {
+	return f.update(func() (bool, error) {
+		return true, f.MemoryBackend.Set(path, kubaEnv, env, value, ttl)
+	})
+}
+
+// Delete removes a secret from the cache
+func (f *FileBackend) Delete(path, kubaEnv, env string) error {
+	return f.update(func() (bool, error) {
+		return true, f.MemoryBackend.Delete(path, kubaEnv, env)
+	})
+}
+
+// Clear removes all secrets from the cache
+func (f *FileBackend) Clear() error {
+	return f.update(func() (bool, error) {
+		return true, f.MemoryBackend.Clear()
+	})
+}
+
+// ClearByPath removes all secrets for a specific kuba.yaml path
+func (f *FileBackend) ClearByPath(path string) error {
+	_, err := f.ClearFiltered(path, "", "", false)
+	return err
+}
+
+// ClearByEnvironment removes all secrets for a specific environment
+func (f *FileBackend) ClearByEnvironment(path, kubaEnv string) error {
+	_, err := f.ClearFiltered(path, kubaEnv, "", false)
+	return err
+}
+
+// ClearFiltered clears cache entries based on filters
+func (f *FileBackend) ClearFiltered(path, kubaEnv, env string, expiredOnly bool) (int, error) {
+	var count int
+	err := f.update(func() (bool, error) {
+		var err error
+		count, err = f.MemoryBackend.ClearFiltered(path, kubaEnv, env, expiredOnly)
+		return count > 0, err
+	})
+	return count, err
+}
+
+// UpdateExpiry updates the expiry time for cache entries based on filters
+func (f *FileBackend) UpdateExpiry(path, kubaEnv, env string, newTTL time.Duration) (int, error) {
+	var count int
+	err := f.update(func() (bool, error) {
+		var err error
+		count, err = f.MemoryBackend.UpdateExpiry(path, kubaEnv, env, newTTL)
+		return count > 0, err
+	})
+	return count, err
+}
+
+// update applies change to the current content of the cache file while
+// holding its lock, and saves the file when change reports a modification
+func (f *FileBackend) update(change func() (bool, error)) error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	unlock, err := lockFile(f.path + ".lock")
+	if err != nil {
+		return fmt.Errorf("failed to lock cache file: %w", err)
+	}
+	defer unlock()
+
+	if err := f.load(); err != nil {
+		return fmt.Errorf("failed to read cache file: %w", err)
+	}
+	changed, err := change()
+	if err != nil || !changed {
+		return err
+	}
+	return f.save()
+}
+
+// load decrypts the cache file into memory, replacing the entries held in
+// memory
+func (f *FileBackend) load() error {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			f.mu.Lock()
+			f.entries = make(map[entryKey]CacheEntry)
+			f.mu.Unlock()
+			return nil
+		}
+		return err
+	}
+
+	gcm, err := newGCM(f.key)
+	if err != nil {
+		return err
+	}
+	if len(data) < gcm.NonceSize() {
+		return fmt.Errorf("cache file is truncated")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt cache file: %w", err)
+	}
+
+	var entries []CacheEntry
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return fmt.Errorf("failed to decode cache file: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.entries = make(map[entryKey]CacheEntry, len(entries))
+	for _, entry := range entries {
+		f.entries[entryKey{entry.Path, entry.KubaEnv, entry.Env}] = entry
+	}
+	return nil
+}
+
+// save encrypts all entries and atomically replaces the cache file
+func (f *FileBackend) save() error {
+	entries, err := f.List()
+	if err != nil {
+		return err
+	}
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache file: %w", err)
+	}
+
+	gcm, err := newGCM(f.key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	data := gcm.Seal(nonce, nonce, plaintext, nil)
+
+	tmpPath := f.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace cache file: %w", err)
+	}
+	return nil
+}
+
+// newGCM creates an AES-GCM cipher for the given key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// loadOrCreateKey reads the 256-bit cache key, generating it on first use
+func loadOrCreateKey(path string) ([]byte, error) {
+	key, err := readKey(path)
+	if err == nil || !os.IsNotExist(err) {
+		return key, err
+	}
+
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	// The key is written to a temporary file and linked into place, which
+	// fails if another process created the key meanwhile. Then its key is
+	// used, so entries encrypted by either process stay readable.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cache.key-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to write key: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(key); err != nil {
+		tmp.Close()
+		return nil, fmt.Errorf("failed to write key: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write key: %w", err)
+	}
+	if err := os.Link(tmp.Name(), path); err != nil {
+		if os.IsExist(err) {
+			return readKey(path)
+		}
+		return nil, fmt.Errorf("failed to write key: %w", err)
+	}
+	return key, nil
+}
+
+// readKey reads the cache key at path
+func readKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid key length in %s", path)
+	}
+	return key, nil
+}
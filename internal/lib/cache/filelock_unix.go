@@ -0,0 +1,26 @@
+//go:build unix
+
+package cache
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockFile takes an exclusive lock on the file at path, creating it if
+// needed. The returned function releases the lock.
+func lockFile(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Flock(int(file.Fd()), unix.LOCK_EX); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		unix.Flock(int(file.Fd()), unix.LOCK_UN)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package cache
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an exclusive lock on the file at path, creating it if
+// needed. The returned function releases the lock.
+func lockFile(path string) (func(), error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	handle := windows.Handle(file.Fd())
+	overlapped := new(windows.Overlapped)
+	if err := windows.LockFileEx(handle, windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, overlapped); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		windows.UnlockFileEx(handle, 0, 1, 0, overlapped)
+		file.Close()
+	}, nil
+}
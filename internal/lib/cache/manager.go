@@ -10,7 +10,7 @@ import (
 
 // Manager handles caching operations with global and environment-specific settings
 type Manager struct {
-	cache        *Cache
+	cache        Backend
 	globalConfig *GlobalConfig
 }
 
@@ -33,16 +33,21 @@ func NewManager(globalConfig *GlobalConfig) (*Manager, error) {
 	}
 
 	// Initialize cache
-	cache, err := NewCache()
+	backend, err := NewBackend(globalConfig.Cache)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize cache: %w", err)
 	}
 
-	logger.Debug("Cache manager initialized", "enabled", true, "ttl", globalConfig.Cache.TTL)
+	logger.Debug("Cache manager initialized", "enabled", true, "ttl", globalConfig.Cache.TTL, "backend", globalConfig.Cache.Backend)
+	return NewManagerWithBackend(globalConfig, backend), nil
+}
+
+// NewManagerWithBackend creates a cache manager on top of an existing backend
+func NewManagerWithBackend(globalConfig *GlobalConfig, backend Backend) *Manager {
 	return &Manager{
-		cache:        cache,
+		cache:        backend,
 		globalConfig: globalConfig,
-	}, nil
+	}
 }
 
 // Close closes the cache manager
@@ -58,24 +63,26 @@ func (m *Manager) IsEnabled() bool {
 	return m.cache != nil && m.globalConfig.Cache.Enabled
 }
 
-// GetCacheConfig returns the effective cache configuration for an environment
-func (m *Manager) GetCacheConfig(envCache *CacheConfig) (bool, time.Duration) {
+// GetCacheConfig returns the effective cache configuration for an environment.
+// Environments may override whether caching is enabled and the TTL; the
+// stale-if-error and stale-while-revalidate windows always come from the
+// global configuration.
+func (m *Manager) GetCacheConfig(envCache *CacheConfig) CacheConfig {
 	// Check if caching is disabled globally
 	if !m.globalConfig.Cache.Enabled {
-		return false, 0
+		return CacheConfig{}
 	}
 
-	// Use global TTL as default
-	enabled := m.globalConfig.Cache.Enabled
-	ttl := m.globalConfig.Cache.TTL
+	// Use global settings as default
+	effective := m.globalConfig.Cache
 
 	// Override with environment-specific settings if present
 	if envCache != nil {
-		enabled = envCache.Enabled
-		ttl = envCache.TTL
+		effective.Enabled = envCache.Enabled
+		effective.TTL = envCache.TTL
 	}
 
-	return enabled, ttl
+	return effective
 }
 
 // Get retrieves a secret from cache
@@ -93,6 +100,22 @@ func (m *Manager) Get(configPath, envName, secretName string) (string, bool, err
 	return m.cache.Get(absPath, envName, secretName)
 }
 
+// GetEntry retrieves a cache entry from cache, including expired entries
+// that are still retained for stale serving
+func (m *Manager) GetEntry(configPath, envName, secretName string) (*CacheEntry, error) {
+	if !m.IsEnabled() {
+		return nil, nil
+	}
+
+	// Get absolute path for consistent caching
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	return m.cache.GetEntry(absPath, envName, secretName)
+}
+
 // Set stores a secret in cache
 func (m *Manager) Set(configPath, envName, secretName, value string, ttl time.Duration) error {
 	if !m.IsEnabled() {
@@ -144,6 +167,34 @@ func (m *Manager) ClearByEnvironment(configPath, envName string) error {
 	return m.cache.ClearByEnvironment(absPath, envName)
 }
 
+// ClearFiltered clears cache entries matching the non-empty filters
+func (m *Manager) ClearFiltered(configPath, envName, secretName string, expiredOnly bool) (int, error) {
+	if !m.IsEnabled() {
+		return 0, nil
+	}
+
+	absPath, err := absPathOrEmpty(configPath)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.cache.ClearFiltered(absPath, envName, secretName, expiredOnly)
+}
+
+// UpdateExpiry updates the expiry time of cache entries matching the non-empty filters
+func (m *Manager) UpdateExpiry(configPath, envName, secretName string, newTTL time.Duration) (int, error) {
+	if !m.IsEnabled() {
+		return 0, nil
+	}
+
+	absPath, err := absPathOrEmpty(configPath)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.cache.UpdateExpiry(absPath, envName, secretName, newTTL)
+}
+
 // List returns all cached entries (for debugging)
 func (m *Manager) List() ([]CacheEntry, error) {
 	if !m.IsEnabled() {
@@ -172,9 +223,33 @@ func (m *Manager) GetStats() (map[string]interface{}, error) {
 	}
 
 	return map[string]interface{}{
-		"enabled":            true,
-		"total_entries":      len(entries),
-		"environment_counts": envCounts,
-		"ttl":                m.globalConfig.Cache.TTL.String(),
+		"enabled":                true,
+		"backend":                m.Backend(),
+		"total_entries":          len(entries),
+		"environment_counts":     envCounts,
+		"ttl":                    m.globalConfig.Cache.TTL.String(),
+		"stale_if_error":         m.globalConfig.Cache.StaleIfError.String(),
+		"stale_while_revalidate": m.globalConfig.Cache.StaleWhileRevalidate.String(),
 	}, nil
 }
+
+// Backend returns the name of the configured cache backend
+func (m *Manager) Backend() string {
+	if m.globalConfig.Cache.Backend == "" {
+		return defaultBackend
+	}
+	return m.globalConfig.Cache.Backend
+}
+
+// absPathOrEmpty resolves a config path filter to an absolute path, keeping
+// an empty filter empty
+func absPathOrEmpty(configPath string) (string, error) {
+	if configPath == "" {
+		return "", nil
+	}
+	absPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	return absPath, nil
+}
@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// entryKey identifies a cache entry
+type entryKey struct {
+	path    string
+	kubaEnv string
+	env     string
+}
+
+// MemoryBackend is a Backend that keeps secrets in memory only
+type MemoryBackend struct {
+	mu      sync.RWMutex
+	entries map[entryKey]CacheEntry
+}
+
+// NewMemoryBackend creates an empty in-memory backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{entries: make(map[entryKey]CacheEntry)}
+}
+
+// Close is a no-op for the in-memory backend
+func (m *MemoryBackend) Close() error {
+	return nil
+}
+
+// Get retrieves a secret from the cache
+func (m *MemoryBackend) Get(path, kubaEnv, env string) (string, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.entries[entryKey{path, kubaEnv, env}]
+	if !ok || !time.Now().Before(entry.ExpiresAt) {
+		return "", false, nil
+	}
+	return entry.Value, true, nil
+}
+
+// GetEntry retrieves a cache entry regardless of whether it has expired
+func (m *MemoryBackend) GetEntry(path, kubaEnv, env string) (*CacheEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.entries[entryKey{path, kubaEnv, env}]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// Set stores a secret in the cache
+func (m *MemoryBackend) Set(path, kubaEnv, env, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.entries[entryKey{path, kubaEnv, env}] = CacheEntry{
+		Path:      path,
+		KubaEnv:   kubaEnv,
+		Env:       env,
+		Value:     value,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	return nil
+}
+
+// Delete removes a secret from the cache
+func (m *MemoryBackend) Delete(path, kubaEnv, env string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, entryKey{path, kubaEnv, env})
+	return nil
+}
+
+// Clear removes all secrets from the cache
+func (m *MemoryBackend) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[entryKey]CacheEntry)
+	return nil
+}
+
+// ClearByPath removes all secrets for a specific kuba.yaml path
+func (m *MemoryBackend) ClearByPath(path string) error {
+	_, err := m.ClearFiltered(path, "", "", false)
+	return err
+}
+
+// ClearByEnvironment removes all secrets for a specific environment
+func (m *MemoryBackend) ClearByEnvironment(path, kubaEnv string) error {
+	_, err := m.ClearFiltered(path, kubaEnv, "", false)
+	return err
+}
+
+// List returns all cached entries (for debugging/inspection)
+func (m *MemoryBackend) List() ([]CacheEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := make([]CacheEntry, 0, len(m.entries))
+	for _, entry := range m.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Path != entries[j].Path {
+			return entries[i].Path < entries[j].Path
+		}
+		if entries[i].KubaEnv != entries[j].KubaEnv {
+			return entries[i].KubaEnv < entries[j].KubaEnv
+		}
+		return entries[i].Env < entries[j].Env
+	})
+	return entries, nil
+}
+
+// ClearFiltered clears cache entries based on filters
+func (m *MemoryBackend) ClearFiltered(path, kubaEnv, env string, expiredOnly bool) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	count := 0
+	for key, entry := range m.entries {
+		if !matchesFilter(entry, path, kubaEnv, env) {
+			continue
+		}
+		if expiredOnly && !entry.ExpiresAt.Before(now) {
+			continue
+		}
+		delete(m.entries, key)
+		count++
+	}
+	return count, nil
+}
+
+// UpdateExpiry updates the expiry time for cache entries based on filters
+func (m *MemoryBackend) UpdateExpiry(path, kubaEnv, env string, newTTL time.Duration) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	newExpiryTime := time.Now().Add(newTTL)
+	count := 0
+	for key, entry := range m.entries {
+		if !matchesFilter(entry, path, kubaEnv, env) {
+			continue
+		}
+		entry.ExpiresAt = newExpiryTime
+		m.entries[key] = entry
+		count++
+	}
+	return count, nil
+}
+
+// cleanupExpired removes entries that expired longer ago than the retention
+func (m *MemoryBackend) cleanupExpired(retention time.Duration) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cutoff := time.Now().Add(-retention)
+	count := 0
+	for key, entry := range m.entries {
+		if entry.ExpiresAt.Before(cutoff) {
+			delete(m.entries, key)
+			count++
+		}
+	}
+	return count
+}
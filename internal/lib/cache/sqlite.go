@@ -0,0 +1,315 @@
+//go:build cgo
+
+package cache
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+)
+
+// defaultBackend is used when no backend is configured
+const defaultBackend = BackendSQLite
+
+// SQLiteBackend is a Backend that stores secrets in a SQLite database
+type SQLiteBackend struct {
+	db *sql.DB
+}
+
+// NewSQLiteBackend opens the SQLite cache database. Expired entries are kept
+// around for the given retention so they can be served as stale values.
+func NewSQLiteBackend(retention time.Duration) (*SQLiteBackend, error) {
+	logger := log.NewLogger()
+
+	// Get cache directory
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache directory: %w", err)
+	}
+
+	// Create cache directory if it doesn't exist
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	dbPath := filepath.Join(cacheDir, "db.sqlite")
+	logger.Debug("Opening cache database", "path", dbPath)
+
+	// Open database
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache database: %w", err)
+	}
+
+	cache := &SQLiteBackend{db: db}
+
+	// Initialize database schema
+	if err := cache.initSchema(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize cache schema: %w", err)
+	}
+
+	// Clean up expired entries
+	if err := cache.cleanupExpired(retention); err != nil {
+		logger.Debug("Failed to cleanup expired entries", "error", err)
+		// Don't fail cache creation for cleanup errors
+	}
+
+	logger.Debug("Cache initialized successfully", "path", dbPath)
+	return cache, nil
+}
+
+// Close closes the cache database connection
+func (c *SQLiteBackend) Close() error {
+	if c.db != nil {
+		return c.db.Close()
+	}
+	return nil
+}
+
+// initSchema initializes the database schema
+func (c *SQLiteBackend) initSchema() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS secrets (
+		path TEXT NOT NULL,
+		kuba_env TEXT NOT NULL,
+		env TEXT NOT NULL,
+		value TEXT NOT NULL,
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		expires_at DATETIME NOT NULL,
+		PRIMARY KEY (path, kuba_env, env)
+	);
+	
+	CREATE INDEX IF NOT EXISTS idx_expires_at ON secrets(expires_at);
+	`
+
+	_, err := c.db.Exec(query)
+	return err
+}
+
+// cleanupExpired removes entries that expired longer ago than the retention
+func (c *SQLiteBackend) cleanupExpired(retention time.Duration) error {
+	query := `DELETE FROM secrets WHERE expires_at < ?`
+	_, err := c.db.Exec(query, time.Now().Add(-retention))
+	return err
+}
+
+// Set stores a secret in the cache
+func (c *SQLiteBackend) Set(path, kubaEnv, env, value string, ttl time.Duration) error {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	query := `
+	INSERT OR REPLACE INTO secrets (path, kuba_env, env, value, created_at, expires_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := c.db.Exec(query, path, kubaEnv, env, value, now, expiresAt)
+	return err
+}
+
+// Get retrieves a secret from the cache
+func (c *SQLiteBackend) Get(path, kubaEnv, env string) (string, bool, error) {
+	query := `
+	SELECT value FROM secrets 
+	WHERE path = ? AND kuba_env = ? AND env = ? AND expires_at > datetime('now')
+	`
+
+	var value string
+	err := c.db.QueryRow(query, path, kubaEnv, env).Scan(&value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	return value, true, nil
+}
+
+// GetEntry retrieves a cache entry regardless of whether it has expired
+func (c *SQLiteBackend) GetEntry(path, kubaEnv, env string) (*CacheEntry, error) {
+	query := `
+	SELECT path, kuba_env, env, value, created_at, expires_at
+	FROM secrets
+	WHERE path = ? AND kuba_env = ? AND env = ?
+	`
+
+	var entry CacheEntry
+	err := c.db.QueryRow(query, path, kubaEnv, env).Scan(&entry.Path, &entry.KubaEnv, &entry.Env, &entry.Value, &entry.CreatedAt, &entry.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// Delete removes a secret from the cache
+func (c *SQLiteBackend) Delete(path, kubaEnv, env string) error {
+	query := `DELETE FROM secrets WHERE path = ? AND kuba_env = ? AND env = ?`
+	_, err := c.db.Exec(query, path, kubaEnv, env)
+	return err
+}
+
+// Clear removes all secrets from the cache
+func (c *SQLiteBackend) Clear() error {
+	query := `DELETE FROM secrets`
+	_, err := c.db.Exec(query)
+	return err
+}
+
+// ClearByPath removes all secrets for a specific kuba.yaml path
+func (c *SQLiteBackend) ClearByPath(path string) error {
+	query := `DELETE FROM secrets WHERE path = ?`
+	_, err := c.db.Exec(query, path)
+	return err
+}
+
+// ClearByEnvironment removes all secrets for a specific environment
+func (c *SQLiteBackend) ClearByEnvironment(path, kubaEnv string) error {
+	query := `DELETE FROM secrets WHERE path = ? AND kuba_env = ?`
+	_, err := c.db.Exec(query, path, kubaEnv)
+	return err
+}
+
+// List returns all cached entries (for debugging/inspection)
+func (c *SQLiteBackend) List() ([]CacheEntry, error) {
+	query := `
+	SELECT path, kuba_env, env, value, created_at, expires_at
+	FROM secrets
+	ORDER BY path, kuba_env, env
+	`
+
+	rows, err := c.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []CacheEntry
+	for rows.Next() {
+		var entry CacheEntry
+		err := rows.Scan(&entry.Path, &entry.KubaEnv, &entry.Env, &entry.Value, &entry.CreatedAt, &entry.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// ClearFiltered clears cache entries based on filters
+func (c *SQLiteBackend) ClearFiltered(path, kubaEnv, env string, expiredOnly bool) (int, error) {
+	logger := log.NewLogger()
+
+	// Build WHERE clause based on filters
+	var conditions []string
+	var args []interface{}
+	argIndex := 1
+
+	if path != "" {
+		conditions = append(conditions, fmt.Sprintf("path = $%d", argIndex))
+		args = append(args, path)
+		argIndex++
+	}
+
+	if kubaEnv != "" {
+		conditions = append(conditions, fmt.Sprintf("kuba_env = $%d", argIndex))
+		args = append(args, kubaEnv)
+		argIndex++
+	}
+
+	if env != "" {
+		conditions = append(conditions, fmt.Sprintf("env = $%d", argIndex))
+		args = append(args, env)
+		argIndex++
+	}
+
+	if expiredOnly {
+		conditions = append(conditions, fmt.Sprintf("expires_at < $%d", argIndex))
+		args = append(args, time.Now())
+		argIndex++
+	}
+
+	// Build query
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf("DELETE FROM secrets %s", whereClause)
+
+	result, err := c.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear cache entries: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	logger.Debug("Cleared cache entries", "count", rowsAffected, "path", path, "kuba_env", kubaEnv, "env", env, "expired_only", expiredOnly)
+	return int(rowsAffected), nil
+}
+
+// UpdateExpiry updates the expiry time for cache entries based on filters
+func (c *SQLiteBackend) UpdateExpiry(path, kubaEnv, env string, newTTL time.Duration) (int, error) {
+	logger := log.NewLogger()
+
+	// Set new expiry time to now + TTL; SQLite numbers $N parameters in order
+	// of appearance, so the expiry has to be the first argument
+	newExpiryTime := time.Now().Add(newTTL)
+	args := []interface{}{newExpiryTime}
+	argIndex := 2
+
+	// Build WHERE clause based on filters
+	var conditions []string
+
+	if path != "" {
+		conditions = append(conditions, fmt.Sprintf("path = $%d", argIndex))
+		args = append(args, path)
+		argIndex++
+	}
+
+	if kubaEnv != "" {
+		conditions = append(conditions, fmt.Sprintf("kuba_env = $%d", argIndex))
+		args = append(args, kubaEnv)
+		argIndex++
+	}
+
+	if env != "" {
+		conditions = append(conditions, fmt.Sprintf("env = $%d", argIndex))
+		args = append(args, env)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf("UPDATE secrets SET expires_at = $1 %s", whereClause)
+
+	result, err := c.db.Exec(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update cache expiry: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	logger.Debug("Updated cache expiry", "count", rowsAffected, "path", path, "kuba_env", kubaEnv, "env", env, "new_ttl", newTTL, "new_expiry", newExpiryTime)
+	return int(rowsAffected), nil
+}
@@ -0,0 +1,21 @@
+//go:build !cgo
+
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultBackend is used when no backend is configured
+const defaultBackend = BackendFile
+
+// SQLiteBackend is not available in builds without cgo
+type SQLiteBackend struct {
+	Backend
+}
+
+// NewSQLiteBackend reports that the SQLite backend requires cgo
+func NewSQLiteBackend(retention time.Duration) (*SQLiteBackend, error) {
+	return nil, fmt.Errorf("the %s cache backend requires cgo, use the %s or %s backend instead", BackendSQLite, BackendFile, BackendMemory)
+}
@@ -0,0 +1,17 @@
+//go:build cgo
+
+package cache
+
+import "testing"
+
+func TestSQLiteBackend(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	b, err := NewSQLiteBackend(0)
+	if err != nil {
+		t.Fatalf("NewSQLiteBackend() error: %v", err)
+	}
+	defer b.Close()
+	testBackend(t, b)
+}
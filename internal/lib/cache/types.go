@@ -4,6 +4,42 @@ import "time"
 
 // CacheConfig represents the caching configuration
 type CacheConfig struct {
-	Enabled bool          `yaml:"enabled"`
-	TTL     time.Duration `yaml:"ttl"`
+	Enabled bool          `yaml:"enabled" doc:"Whether secrets are cached."`
+	TTL     time.Duration `yaml:"ttl" doc:"How long cached secrets are valid."`
+	// StaleIfError is how long past expiry an entry may still be served
+	// when the provider cannot be reached
+	StaleIfError time.Duration `yaml:"stale-if-error,omitempty" doc:"How long past expiry cached secrets may still be served, with a warning, when a provider cannot be reached."`
+	// StaleWhileRevalidate is how long past expiry an entry may be served
+	// immediately while a fresh value is fetched in the background
+	StaleWhileRevalidate time.Duration `yaml:"stale-while-revalidate,omitempty" doc:"How long past expiry cached secrets may be served immediately while fresh values are fetched in the background for the next run."`
+	// Backend selects where cached secrets are stored (sqlite, file or memory)
+	Backend string `yaml:"backend,omitempty" schema:"cache-backend" doc:"Where cached secrets are stored. sqlite requires a cgo build, file is an encrypted pure-Go store and memory only lives as long as the process."`
+}
+
+// Retention returns how long past expiry an entry has to be kept around so
+// that it can still be served as a stale value
+func (c CacheConfig) Retention() time.Duration {
+	if c.StaleIfError > c.StaleWhileRevalidate {
+		return c.StaleIfError
+	}
+	return c.StaleWhileRevalidate
+}
+
+// MarshalYAML writes durations as whole seconds so that the file can be read
+// back through ParseDuration
+func (c CacheConfig) MarshalYAML() (interface{}, error) {
+	type rawCacheConfig struct {
+		Enabled              bool   `yaml:"enabled"`
+		TTL                  int64  `yaml:"ttl"`
+		StaleIfError         int64  `yaml:"stale-if-error,omitempty"`
+		StaleWhileRevalidate int64  `yaml:"stale-while-revalidate,omitempty"`
+		Backend              string `yaml:"backend,omitempty"`
+	}
+	return rawCacheConfig{
+		Enabled:              c.Enabled,
+		TTL:                  int64(c.TTL / time.Second),
+		StaleIfError:         int64(c.StaleIfError / time.Second),
+		StaleWhileRevalidate: int64(c.StaleWhileRevalidate / time.Second),
+		Backend:              c.Backend,
+	}, nil
 }
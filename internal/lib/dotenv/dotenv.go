@@ -0,0 +1,27 @@
+package dotenv
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SafeUnquotedValue matches values that can be written without quoting in
+// dotenv files and in every other format that supports unquoted values
+var SafeUnquotedValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+// Quote double-quotes values that contain characters with a special meaning
+// in dotenv files
+func Quote(value string) string {
+	if SafeUnquotedValue.MatchString(value) {
+		return value
+	}
+	r := strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"$", `\$`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+	)
+	return `"` + r.Replace(value) + `"`
+}
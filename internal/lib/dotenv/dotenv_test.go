@@ -0,0 +1,19 @@
+package dotenv
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"":                 "",
+		"plain-value_1.2":  "plain-value_1.2",
+		"with space":       `"with space"`,
+		"$HOME":            `"\$HOME"`,
+		"line\nbreak":      `"line\nbreak"`,
+		`say "hi" \ there`: `"say \"hi\" \\ there"`,
+	}
+	for value, want := range tests {
+		if got := Quote(value); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package secrets
+
+import (
+	"fmt"
+
+	"github.com/mistweaverco/kuba/internal/lib/agent"
+)
+
+// agentSecretManager implements SecretManager by forwarding lookups to a
+// running kuba agent, which keeps the authenticated provider clients
+type agentSecretManager struct {
+	client   *agent.Client
+	provider string
+}
+
+// GetSecret retrieves a single secret through the agent
+func (a *agentSecretManager) GetSecret(projectID, secretID string) (string, error) {
+	secrets, err := a.client.GetSecrets(a.provider, projectID, []string{secretID})
+	if err != nil {
+		return "", err
+	}
+	value, ok := secrets[secretID]
+	if !ok {
+		return "", fmt.Errorf("%w: '%s'", ErrSecretNotFound, secretID)
+	}
+	return value, nil
+}
+
+// GetSecrets retrieves multiple secrets through the agent
+func (a *agentSecretManager) GetSecrets(projectID string, secretIDs []string) (map[string]string, error) {
+	return a.client.GetSecrets(a.provider, projectID, secretIDs)
+}
+
+// GetSecretsByPath retrieves all secrets below a path through the agent
+func (a *agentSecretManager) GetSecretsByPath(projectID, secretPath string) (map[string]string, error) {
+	return a.client.GetSecretsByPath(a.provider, projectID, secretPath)
+}
+
+// Close is a no-op, the provider clients stay alive in the agent
+func (a *agentSecretManager) Close() error {
+	return nil
+}
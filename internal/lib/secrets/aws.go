@@ -2,12 +2,15 @@ package secrets
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 )
 
 // AWSSecretsManager handles AWS Secrets Manager operations
@@ -55,6 +58,10 @@ func (a *AWSSecretsManager) GetSecret(projectID, secretID string) (string, error
 	}
 
 	result, err := a.client.GetSecretValue(a.ctx, input)
+	var notFound *types.ResourceNotFoundException
+	if errors.As(err, &notFound) {
+		return "", fmt.Errorf("failed to get secret '%s': %w: %w", secretID, ErrSecretNotFound, err)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to get secret '%s': %w", secretID, err)
 	}
@@ -198,3 +205,108 @@ func (a *AWSSecretsManager) DeleteSecret(secretName string, forceDelete bool) er
 
 	return nil
 }
+
+// CurrentVersion returns the ID of the AWSCURRENT version of a secret
+func (a *AWSSecretsManager) CurrentVersion(secretName string) (string, error) {
+	result, err := a.client.GetSecretValue(a.ctx, &secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(secretName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get current version of '%s': %w", secretName, err)
+	}
+	return aws.ToString(result.VersionId), nil
+}
+
+// AddVersion puts a new secret value, which becomes AWSCURRENT, and returns its version ID
+func (a *AWSSecretsManager) AddVersion(secretName, secretValue string) (string, error) {
+	result, err := a.client.PutSecretValue(a.ctx, &secretsmanager.PutSecretValueInput{
+		SecretId:     aws.String(secretName),
+		SecretString: aws.String(secretValue),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to put secret value for '%s': %w", secretName, err)
+	}
+	return aws.ToString(result.VersionId), nil
+}
+
+// DisableVersion removes the AWSPREVIOUS staging label from a version.
+// AWS has no explicit disable; unlabeled versions are deprecated and can no
+// longer be read by stage.
+func (a *AWSSecretsManager) DisableVersion(secretName, versionID string) error {
+	_, err := a.client.UpdateSecretVersionStage(a.ctx, &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:            aws.String(secretName),
+		VersionStage:        aws.String("AWSPREVIOUS"),
+		RemoveFromVersionId: aws.String(versionID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to disable version '%s' of '%s': %w", versionID, secretName, err)
+	}
+	return nil
+}
+
+// ListVersions returns all versions of a secret including deprecated ones,
+// newest first. The state lists the version's staging labels.
+func (a *AWSSecretsManager) ListVersions(secretName string) ([]SecretVersion, error) {
+	var versions []SecretVersion
+	paginator := secretsmanager.NewListSecretVersionIdsPaginator(a.client, &secretsmanager.ListSecretVersionIdsInput{
+		SecretId:          aws.String(secretName),
+		IncludeDeprecated: aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(a.ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list versions of '%s': %w", secretName, err)
+		}
+		for _, entry := range page.Versions {
+			state := strings.Join(entry.VersionStages, ",")
+			if state == "" {
+				state = "deprecated"
+			}
+			versions = append(versions, SecretVersion{
+				ID:        aws.ToString(entry.VersionId),
+				CreatedAt: aws.ToTime(entry.CreatedDate),
+				State:     state,
+				Current:   slices.Contains(entry.VersionStages, "AWSCURRENT"),
+			})
+		}
+	}
+
+	sortVersionsNewestFirst(versions)
+	return versions, nil
+}
+
+// AccessVersion returns the value of a single secret version
+func (a *AWSSecretsManager) AccessVersion(secretName, versionID string) (string, error) {
+	result, err := a.client.GetSecretValue(a.ctx, &secretsmanager.GetSecretValueInput{
+		SecretId:  aws.String(secretName),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to access version '%s' of '%s': %w", versionID, secretName, err)
+	}
+	if result.SecretString != nil {
+		return *result.SecretString, nil
+	}
+	return string(result.SecretBinary), nil
+}
+
+// PromoteVersion moves the AWSCURRENT label to an older version
+func (a *AWSSecretsManager) PromoteVersion(secretName, versionID string) (string, error) {
+	current, err := a.CurrentVersion(secretName)
+	if err != nil {
+		return "", err
+	}
+	if current == versionID {
+		return versionID, nil
+	}
+	_, err = a.client.UpdateSecretVersionStage(a.ctx, &secretsmanager.UpdateSecretVersionStageInput{
+		SecretId:            aws.String(secretName),
+		VersionStage:        aws.String("AWSCURRENT"),
+		MoveToVersionId:     aws.String(versionID),
+		RemoveFromVersionId: aws.String(current),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to promote version '%s' of '%s': %w", versionID, secretName, err)
+	}
+	return versionID, nil
+}
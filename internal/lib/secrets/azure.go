@@ -2,7 +2,9 @@ package secrets
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
@@ -65,6 +67,10 @@ func NewAzureKeyVaultManager(ctx context.Context, vaultURL string, tenantID stri
 func (a *AzureKeyVaultManager) GetSecret(projectID, secretID string) (string, error) {
 	// Get the secret value
 	resp, err := a.client.GetSecret(a.ctx, secretID, "", nil)
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("failed to get secret '%s': %w: %w", secretID, ErrSecretNotFound, err)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to get secret '%s': %w", secretID, err)
 	}
@@ -205,3 +211,97 @@ func (a *AzureKeyVaultManager) DeleteSecret(secretName string, forceDelete bool)
 
 	return nil
 }
+
+// CurrentVersion returns the ID of the latest version of a secret
+func (a *AzureKeyVaultManager) CurrentVersion(secretName string) (string, error) {
+	resp, err := a.client.GetSecret(a.ctx, secretName, "", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get current version of '%s': %w", secretName, err)
+	}
+	if resp.ID == nil {
+		return "", fmt.Errorf("secret '%s' has no version", secretName)
+	}
+	return resp.ID.Version(), nil
+}
+
+// AddVersion sets a new secret value and returns its version ID
+func (a *AzureKeyVaultManager) AddVersion(secretName, secretValue string) (string, error) {
+	resp, err := a.client.SetSecret(a.ctx, secretName, azsecrets.SetSecretParameters{
+		Value: &secretValue,
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to set secret '%s': %w", secretName, err)
+	}
+	if resp.ID == nil {
+		return "", nil
+	}
+	return resp.ID.Version(), nil
+}
+
+// DisableVersion disables a single secret version
+func (a *AzureKeyVaultManager) DisableVersion(secretName, versionID string) error {
+	enabled := false
+	_, err := a.client.UpdateSecretProperties(a.ctx, secretName, versionID, azsecrets.UpdateSecretPropertiesParameters{
+		SecretAttributes: &azsecrets.SecretAttributes{Enabled: &enabled},
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to disable version '%s' of '%s': %w", versionID, secretName, err)
+	}
+	return nil
+}
+
+// ListVersions returns all versions of a secret, newest first
+func (a *AzureKeyVaultManager) ListVersions(secretName string) ([]SecretVersion, error) {
+	var versions []SecretVersion
+	pager := a.client.NewListSecretPropertiesVersionsPager(secretName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(a.ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list versions of '%s': %w", secretName, err)
+		}
+		for _, props := range page.Value {
+			if props == nil || props.ID == nil {
+				continue
+			}
+			version := SecretVersion{ID: props.ID.Version(), State: "enabled"}
+			if props.Attributes != nil {
+				if props.Attributes.Created != nil {
+					version.CreatedAt = *props.Attributes.Created
+				}
+				if props.Attributes.Enabled != nil && !*props.Attributes.Enabled {
+					version.State = "disabled"
+				}
+			}
+			versions = append(versions, version)
+		}
+	}
+
+	sortVersionsNewestFirst(versions)
+	// Key Vault serves the most recently created version by default.
+	if len(versions) > 0 {
+		versions[0].Current = true
+	}
+	return versions, nil
+}
+
+// AccessVersion returns the value of a single secret version
+func (a *AzureKeyVaultManager) AccessVersion(secretName, versionID string) (string, error) {
+	resp, err := a.client.GetSecret(a.ctx, secretName, versionID, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to access version '%s' of '%s': %w", versionID, secretName, err)
+	}
+	if resp.Value == nil {
+		return "", fmt.Errorf("version '%s' of '%s' has no value", versionID, secretName)
+	}
+	return *resp.Value, nil
+}
+
+// PromoteVersion sets the value of an older version as a new version, since
+// Key Vault always serves the latest version
+func (a *AzureKeyVaultManager) PromoteVersion(secretName, versionID string) (string, error) {
+	value, err := a.AccessVersion(secretName, versionID)
+	if err != nil {
+		return "", err
+	}
+	return a.AddVersion(secretName, value)
+}
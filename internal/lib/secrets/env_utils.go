@@ -34,6 +34,12 @@ func sanitizeEnvVarName(name string) string {
 	return strings.ToUpper(sanitized)
 }
 
+// ProposeEnvVarName proposes an environment variable name for a secret name
+// or path as returned by a provider listing
+func ProposeEnvVarName(secretName string) string {
+	return sanitizeEnvVarName(extractSecretNameFromPath(secretName))
+}
+
 // extractSecretNameFromPath extracts the secret name from a full secret path
 // This is useful for providers where the path contains additional metadata
 func extractSecretNameFromPath(path string) string {
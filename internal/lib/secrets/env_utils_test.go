@@ -69,3 +69,22 @@ func TestExtractSecretNameFromPath(t *testing.T) {
 		}
 	}
 }
+
+func TestProposeEnvVarName(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"db-password", "DB_PASSWORD"},
+		{"secret/openbao-secret", "OPENBAO_SECRET"},
+		{"projects/123/secrets/api.key", "API_KEY"},
+		{"1password", "_1PASSWORD"},
+	}
+
+	for _, test := range tests {
+		result := ProposeEnvVarName(test.input)
+		if result != test.expected {
+			t.Errorf("ProposeEnvVarName(%q) = %q, want %q", test.input, result, test.expected)
+		}
+	}
+}
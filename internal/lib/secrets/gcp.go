@@ -8,9 +8,12 @@ import (
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"github.com/mistweaverco/kuba/internal/config"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 	locationpb "google.golang.org/genproto/googleapis/cloud/location"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // GCPSecretManager handles GCP Secret Manager operations
@@ -54,6 +57,31 @@ func (g *GCPSecretManager) SetCreateLocations(locs []string) {
 	g.createLocations = cp
 }
 
+// ApplyCreateDefaults configures user-managed replication for new secrets
+// from the default gcp regions in the global configuration. Without default
+// regions, new secrets keep using automatic replication.
+func (g *GCPSecretManager) ApplyCreateDefaults(globalConfig *config.GlobalConfig) error {
+	if globalConfig == nil || globalConfig.Defaults == nil {
+		return nil
+	}
+	pd, ok := globalConfig.Defaults.Providers["gcp"]
+	if !ok || len(pd.Regions) == 0 {
+		return nil
+	}
+
+	var available []string
+	if pd.HasRegionPatterns() {
+		locs, err := g.SupportedLocations(g.projectID)
+		if err != nil {
+			return fmt.Errorf("failed to resolve default gcp regions: %w", err)
+		}
+		available = locs
+	}
+
+	g.SetCreateLocations(pd.FilterRegions(available))
+	return nil
+}
+
 // GetSecret retrieves a secret from GCP Secret Manager
 func (g *GCPSecretManager) GetSecret(projectID, secretID string) (string, error) {
 	// Build the resource name
@@ -65,6 +93,9 @@ func (g *GCPSecretManager) GetSecret(projectID, secretID string) (string, error)
 	}
 
 	result, err := g.client.AccessSecretVersion(g.ctx, req)
+	if status.Code(err) == codes.NotFound {
+		return "", fmt.Errorf("failed to access secret version: %w: %w", ErrSecretNotFound, err)
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to access secret version: %w", err)
 	}
@@ -134,6 +165,32 @@ func (g *GCPSecretManager) GetSecretsByPath(projectID, secretPath string) (map[s
 	return secrets, nil
 }
 
+// ListSecrets returns the IDs of all secrets in the project
+func (g *GCPSecretManager) ListSecrets() ([]string, error) {
+	if g.projectID == "" {
+		return nil, fmt.Errorf("gcp projectID is required")
+	}
+
+	req := &secretmanagerpb.ListSecretsRequest{
+		Parent: fmt.Sprintf("projects/%s", g.projectID),
+	}
+
+	var secretNames []string
+	it := g.client.ListSecrets(g.ctx, req)
+	for {
+		secret, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list secrets: %w", err)
+		}
+		secretNames = append(secretNames, extractSecretNameFromPath(secret.Name))
+	}
+
+	return secretNames, nil
+}
+
 // SupportedLocations returns Secret Manager-supported locations for the project.
 // Note: this does not account for org-policy constraints; it's just the service
 // location catalog for the project.
@@ -263,3 +320,105 @@ func (g *GCPSecretManager) DeleteSecret(secretName string, forceDelete bool) err
 	}
 	return nil
 }
+
+// CurrentVersion returns the ID of the latest version of a secret
+func (g *GCPSecretManager) CurrentVersion(secretName string) (string, error) {
+	if g.projectID == "" {
+		return "", fmt.Errorf("gcp projectID is required")
+	}
+	version, err := g.client.GetSecretVersion(g.ctx, &secretmanagerpb.GetSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/latest", g.projectID, secretName),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to get latest version of '%s': %w", secretName, err)
+	}
+	return extractSecretNameFromPath(version.Name), nil
+}
+
+// AddVersion adds a new secret version and returns its ID
+func (g *GCPSecretManager) AddVersion(secretName, secretValue string) (string, error) {
+	if g.projectID == "" {
+		return "", fmt.Errorf("gcp projectID is required")
+	}
+	version, err := g.client.AddSecretVersion(g.ctx, &secretmanagerpb.AddSecretVersionRequest{
+		Parent: fmt.Sprintf("projects/%s/secrets/%s", g.projectID, secretName),
+		Payload: &secretmanagerpb.SecretPayload{
+			Data: []byte(secretValue),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to add secret version for '%s': %w", secretName, err)
+	}
+	return extractSecretNameFromPath(version.Name), nil
+}
+
+// DisableVersion disables a single secret version
+func (g *GCPSecretManager) DisableVersion(secretName, versionID string) error {
+	if g.projectID == "" {
+		return fmt.Errorf("gcp projectID is required")
+	}
+	_, err := g.client.DisableSecretVersion(g.ctx, &secretmanagerpb.DisableSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/%s", g.projectID, secretName, versionID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to disable version '%s' of '%s': %w", versionID, secretName, err)
+	}
+	return nil
+}
+
+// ListVersions returns all versions of a secret, newest first
+func (g *GCPSecretManager) ListVersions(secretName string) ([]SecretVersion, error) {
+	if g.projectID == "" {
+		return nil, fmt.Errorf("gcp projectID is required")
+	}
+
+	var versions []SecretVersion
+	it := g.client.ListSecretVersions(g.ctx, &secretmanagerpb.ListSecretVersionsRequest{
+		Parent: fmt.Sprintf("projects/%s/secrets/%s", g.projectID, secretName),
+	})
+	for {
+		v, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list versions of '%s': %w", secretName, err)
+		}
+		versions = append(versions, SecretVersion{
+			ID:        extractSecretNameFromPath(v.Name),
+			CreatedAt: v.CreateTime.AsTime(),
+			State:     strings.ToLower(v.State.String()),
+		})
+	}
+
+	sortVersionsNewestFirst(versions)
+	// "latest" always resolves to the most recently created version.
+	if len(versions) > 0 {
+		versions[0].Current = true
+	}
+	return versions, nil
+}
+
+// AccessVersion returns the value of a single secret version
+func (g *GCPSecretManager) AccessVersion(secretName, versionID string) (string, error) {
+	if g.projectID == "" {
+		return "", fmt.Errorf("gcp projectID is required")
+	}
+	result, err := g.client.AccessSecretVersion(g.ctx, &secretmanagerpb.AccessSecretVersionRequest{
+		Name: fmt.Sprintf("projects/%s/secrets/%s/versions/%s", g.projectID, secretName, versionID),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to access version '%s' of '%s': %w", versionID, secretName, err)
+	}
+	return string(result.Payload.Data), nil
+}
+
+// PromoteVersion adds the value of an older version as a new version, since
+// GCP always serves the latest version
+func (g *GCPSecretManager) PromoteVersion(secretName, versionID string) (string, error) {
+	value, err := g.AccessVersion(secretName, versionID)
+	if err != nil {
+		return "", err
+	}
+	return g.AddVersion(secretName, value)
+}
@@ -0,0 +1,80 @@
+package secrets
+
+import (
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/agent"
+	"github.com/mistweaverco/kuba/internal/lib/cache"
+	"github.com/mistweaverco/kuba/internal/lib/log"
+)
+
+// ChangedSecret is a secret that was written or deleted in a provider
+type ChangedSecret struct {
+	Provider  string
+	Project   string
+	SecretKey string
+}
+
+// InvalidateSecrets drops cached values of changed secrets, so the next run
+// doesn't serve outdated values. The kuba cache entries of every env item in
+// kubaConfig mapping one of the secrets are cleared, and a running agent
+// forgets the secrets.
+func InvalidateSecrets(kubaConfig *config.KubaConfig, configPath string, changed []ChangedSecret) {
+	if len(changed) == 0 {
+		return
+	}
+	logger := log.NewLogger()
+
+	// Projects are compared and forgotten under the key they are cached with
+	changedKeys := make(map[ChangedSecret]bool, len(changed))
+	forget := make(map[[2]string][]string)
+	for _, secret := range changed {
+		secret.Project = NormalizeProject(secret.Provider, secret.Project)
+		if changedKeys[secret] {
+			continue
+		}
+		changedKeys[secret] = true
+		group := [2]string{secret.Provider, secret.Project}
+		forget[group] = append(forget[group], secret.SecretKey)
+	}
+
+	if globalConfig, err := config.LoadGlobalConfig(); err == nil {
+		if manager, err := cache.NewManager(&cache.GlobalConfig{Cache: globalConfig.Cache}); err == nil {
+			defer manager.Close()
+			for envName := range kubaConfig.Environments {
+				env, err := kubaConfig.GetEnvironment(envName)
+				if err != nil {
+					continue
+				}
+				for name, item := range env.Env {
+					if item.SecretKey == "" {
+						continue
+					}
+					provider, project := item.Provider, item.Project
+					if provider == "" {
+						provider = env.Provider
+					}
+					if project == "" {
+						project = env.Project
+					}
+					key := ChangedSecret{Provider: provider, Project: NormalizeProject(provider, project), SecretKey: item.SecretKey}
+					if !changedKeys[key] {
+						continue
+					}
+					if _, err := manager.ClearFiltered(configPath, envName, name, false); err != nil {
+						logger.Debug("Failed to clear cache entry", "env", envName, "name", name, "error", err)
+					}
+				}
+			}
+		}
+	}
+
+	client, err := agent.Dial()
+	if err != nil {
+		return
+	}
+	for group, secretIDs := range forget {
+		if err := client.Forget(group[0], group[1], secretIDs); err != nil {
+			logger.Debug("Failed to clear agent cache", "provider", group[0], "project", group[1], "error", err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package secrets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/agent"
+	"github.com/mistweaverco/kuba/internal/lib/cache"
+)
+
+// countingReader serves fixed values and counts the lookups reaching it
+type countingReader struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (r *countingReader) GetSecrets(projectID string, secretIDs []string) (map[string]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls++
+	values := make(map[string]string, len(secretIDs))
+	for _, id := range secretIDs {
+		values[id] = "value-of-" + id
+	}
+	return values, nil
+}
+
+func (r *countingReader) GetSecretsByPath(projectID, secretPath string) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+func (r *countingReader) Close() error { return nil }
+
+func TestInvalidateSecretsClearsCacheAndAgent(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("agent peer credentials are not supported on " + runtime.GOOS)
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	globalConfig := config.DefaultGlobalConfig()
+	globalConfig.Cache.Enabled = true
+	globalConfig.Cache.Backend = cache.BackendFile
+	if err := config.SaveGlobalConfig(globalConfig); err != nil {
+		t.Fatalf("SaveGlobalConfig() error = %v", err)
+	}
+
+	configPath := filepath.Join(t.TempDir(), "kuba.yaml")
+	content := `default:
+  provider: aws
+  env:
+    DB_PASSWORD:
+      secret-key: db-password
+    API_KEY:
+      secret-key: api-key
+staging:
+  provider: aws
+  env:
+    DATABASE_PASSWORD:
+      secret-key: db-password
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	kubaConfig, err := config.LoadKubaConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadKubaConfig() error = %v", err)
+	}
+
+	manager, err := cache.NewManager(&cache.GlobalConfig{Cache: globalConfig.Cache})
+	if err != nil {
+		t.Fatalf("cache.NewManager() error = %v", err)
+	}
+	for _, entry := range [][2]string{{"default", "DB_PASSWORD"}, {"default", "API_KEY"}, {"staging", "DATABASE_PASSWORD"}} {
+		if err := manager.Set(configPath, entry[0], entry[1], "old", time.Hour); err != nil {
+			t.Fatalf("Set() error = %v", err)
+		}
+	}
+	manager.Close()
+
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	t.Setenv("KUBA_AGENT_SOCKET", socketPath)
+	reader := &countingReader{}
+	server := agent.NewServer(socketPath, func(ctx context.Context, provider, project string) (agent.SecretReader, error) {
+		return reader, nil
+	}, 0, time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	var client *agent.Client
+	for i := 0; i < 100; i++ {
+		if client, err = agent.Dial(); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("agent did not start: %v", err)
+	}
+	if _, err := client.GetSecrets("aws", DefaultProject, []string{"db-password"}); err != nil {
+		t.Fatalf("GetSecrets() error = %v", err)
+	}
+
+	// The project is empty like in kuba.yaml, the agent caches it under the
+	// default project key
+	InvalidateSecrets(kubaConfig, configPath, []ChangedSecret{{Provider: "aws", SecretKey: "db-password"}})
+
+	if _, err := client.GetSecrets("aws", DefaultProject, []string{"db-password"}); err != nil {
+		t.Fatalf("GetSecrets() error = %v", err)
+	}
+	if reader.calls != 2 {
+		t.Errorf("provider lookups = %d, want 2 (the agent should have forgotten the secret)", reader.calls)
+	}
+
+	manager, err = cache.NewManager(&cache.GlobalConfig{Cache: globalConfig.Cache})
+	if err != nil {
+		t.Fatalf("cache.NewManager() error = %v", err)
+	}
+	defer manager.Close()
+	for _, entry := range []struct {
+		env, name string
+		cached    bool
+	}{
+		{"default", "DB_PASSWORD", false},
+		{"staging", "DATABASE_PASSWORD", false},
+		{"default", "API_KEY", true},
+	} {
+		_, found, err := manager.Get(configPath, entry.env, entry.name)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if found != entry.cached {
+			t.Errorf("%s/%s cached = %v, want %v", entry.env, entry.name, found, entry.cached)
+		}
+	}
+}
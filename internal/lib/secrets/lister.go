@@ -0,0 +1,64 @@
+package secrets
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SecretLister is implemented by providers that can list their secrets
+type SecretLister interface {
+	ListSecrets() ([]string, error)
+}
+
+// AsLister converts a SecretManager into a SecretLister when supported.
+//
+// The returned names can be used as secret-key in kuba.yaml.
+func AsLister(sm SecretManager) (SecretLister, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("nil secret manager")
+	}
+
+	// Providers that already match SecretLister.
+	if l, ok := sm.(SecretLister); ok {
+		return l, nil
+	}
+
+	// Providers needing adapters.
+	switch v := sm.(type) {
+	case *OpenBaoManager:
+		return &openBaoLister{m: v}, nil
+	default:
+		return nil, fmt.Errorf("provider does not support listing secrets")
+	}
+}
+
+type openBaoLister struct {
+	m *OpenBaoManager
+}
+
+// ListSecrets lists the secrets under the default "secret" mount, falling back
+// to the root path, and returns their full paths.
+func (o *openBaoLister) ListSecrets() ([]string, error) {
+	prefix := "secret"
+	keys, err := o.m.ListSecrets(prefix)
+	if err != nil {
+		prefix = ""
+		keys, err = o.m.ListSecrets(prefix)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		// Keys ending with a slash are folders, not secrets.
+		if strings.HasSuffix(key, "/") {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "/" + key
+		}
+		names = append(names, key)
+	}
+	return names, nil
+}
@@ -23,7 +23,7 @@ func (l *LocalManager) GetSecret(projectID, secretID string) (string, error) {
 	// For local provider, we just return the environment variable value
 	value := os.Getenv(secretID)
 	if value == "" {
-		return "", fmt.Errorf("environment variable '%s' not found", secretID)
+		return "", fmt.Errorf("%w: environment variable '%s' is not set", ErrSecretNotFound, secretID)
 	}
 	return value, nil
 }
@@ -2,16 +2,24 @@ package secrets
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/agent"
 	"github.com/mistweaverco/kuba/internal/lib/cache"
 	"github.com/mistweaverco/kuba/internal/lib/log"
 )
 
+// ErrSecretNotFound is wrapped by the errors of GetSecret when the provider
+// reports that the secret does not exist
+var ErrSecretNotFound = errors.New("secret not found")
+
 // SecretManager defines the interface for secret management operations
 type SecretManager interface {
 	GetSecret(projectID, secretID string) (string, error)
@@ -29,7 +37,17 @@ type SecretMutator interface {
 }
 
 // SecretManagerFactory creates secret managers for different cloud providers
-type SecretManagerFactory struct{}
+type SecretManagerFactory struct {
+	// Offline restricts secret lookups to values that are already cached
+	Offline bool
+	// DisableAgent makes secret lookups bypass a running kuba agent
+	DisableAgent bool
+	// FailOnError makes lookups fail when a provider returns an error,
+	// instead of printing a warning and resolving the other values
+	FailOnError bool
+
+	refreshes sync.WaitGroup
+}
 
 // NewSecretManagerFactory creates a new secret manager factory
 func NewSecretManagerFactory() *SecretManagerFactory {
@@ -72,7 +90,12 @@ func (f *SecretManagerFactory) CreateSecretManager(ctx context.Context, provider
 		token := os.Getenv("OPENBAO_TOKEN")
 		namespace := os.Getenv("OPENBAO_NAMESPACE")
 
-		return NewOpenBaoManager(ctx, address, token, namespace)
+		manager, err := NewOpenBaoManager(ctx, address, token, namespace)
+		if err != nil {
+			return nil, err
+		}
+		manager.projectID = projectID
+		return manager, nil
 	case "local":
 		// Local provider doesn't require any external configuration
 		return NewLocalManager(ctx)
@@ -85,6 +108,19 @@ func (f *SecretManagerFactory) CreateSecretManager(ctx context.Context, provider
 	}
 }
 
+// createSecretReader creates a secret manager for reading secrets. Lookups go
+// through the kuba agent when one is running, so provider clients don't have
+// to authenticate again on every invocation.
+func (f *SecretManagerFactory) createSecretReader(ctx context.Context, provider string, projectID string) (SecretManager, error) {
+	if !f.DisableAgent {
+		if client, err := agent.Dial(); err == nil {
+			log.NewLogger().Debug("Using kuba agent for secret lookups", "provider", provider, "project", projectID)
+			return &agentSecretManager{client: client, provider: provider}, nil
+		}
+	}
+	return f.CreateSecretManager(ctx, provider, projectID)
+}
+
 // GetSecretsForEnvironment retrieves all secrets and values for a given environment configuration
 func (f *SecretManagerFactory) GetSecretsForEnvironment(ctx context.Context, env *config.Environment) (map[string]string, error) {
 	return f.GetSecretsForEnvironmentWithCache(ctx, env, "", "")
@@ -92,12 +128,22 @@ func (f *SecretManagerFactory) GetSecretsForEnvironment(ctx context.Context, env
 
 // GetSecretsForEnvironmentWithCache retrieves all secrets and values for a given environment configuration with caching
 func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Context, env *config.Environment, configPath, envName string) (map[string]string, error) {
+	resolution, err := f.ResolveEnvironment(ctx, env, configPath, envName)
+	if err != nil {
+		return nil, err
+	}
+	return resolution.Values, nil
+}
+
+// ResolveEnvironment retrieves all secrets and values for a given environment
+// configuration like GetSecretsForEnvironmentWithCache and reports where each
+// value came from
+func (f *SecretManagerFactory) ResolveEnvironment(ctx context.Context, env *config.Environment, configPath, envName string) (*Resolution, error) {
 	logger := log.NewLogger()
 
 	// Initialize cache manager if config path is provided
 	var cacheManager *cache.Manager
-	var cacheEnabled bool
-	var cacheTTL time.Duration
+	var cacheConfig cache.CacheConfig
 
 	if configPath != "" {
 		// Load global config
@@ -117,10 +163,7 @@ func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Con
 		if shouldEnableCache {
 			// Convert to cache types
 			cacheGlobalConfig := &cache.GlobalConfig{
-				Cache: cache.CacheConfig{
-					Enabled: globalConfig.Cache.Enabled,
-					TTL:     globalConfig.Cache.TTL,
-				},
+				Cache: globalConfig.Cache,
 			}
 
 			cacheManager, err = cache.NewManager(cacheGlobalConfig)
@@ -136,79 +179,260 @@ func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Con
 					}
 				}
 
-				cacheEnabled, cacheTTL = cacheManager.GetCacheConfig(envCache)
-				logger.Debug("Cache configuration", "enabled", cacheEnabled, "ttl", cacheTTL)
+				cacheConfig = cacheManager.GetCacheConfig(envCache)
+				logger.Debug("Cache configuration", "enabled", cacheConfig.Enabled, "ttl", cacheConfig.TTL, "stale_if_error", cacheConfig.StaleIfError, "stale_while_revalidate", cacheConfig.StaleWhileRevalidate)
 			}
 		} else {
 			logger.Debug("Caching disabled", "global_enabled", globalConfig.Cache.Enabled, "env_cache", env.Cache != nil)
 		}
 	}
 
-	// Try to retrieve all secrets from cache first
-	if cacheManager != nil && cacheEnabled && configPath != "" && envName != "" {
-		logger.Debug("Attempting to retrieve secrets from cache", "config_path", configPath, "env_name", envName)
+	useCache := cacheManager != nil && cacheConfig.Enabled && configPath != "" && envName != ""
+	if f.Offline && !useCache {
+		if cacheManager != nil {
+			cacheManager.Close()
+		}
+		return nil, fmt.Errorf("offline mode requires caching to be enabled")
+	}
 
-		// Get all env items to know what to look for
-		envItems := env.GetEnvItems()
-		cachedSecrets := make(map[string]string)
-		allCached := true
+	// Get all env items (from map)
+	envItems := env.GetEnvItems()
 
-		for _, envItem := range envItems {
-			// Skip value-based mappings as they don't need caching
-			if envItem.Value != nil {
-				continue
+	// Try to retrieve all secrets from cache first
+	var cachedEntries map[string]*cache.CacheEntry
+	if useCache {
+		logger.Debug("Attempting to retrieve secrets from cache", "config_path", configPath, "env_name", envName)
+		cachedEntries = getCachedEntries(cacheManager, configPath, envName, envItems)
+		now := time.Now()
+
+		// In offline mode every secret has to come from the cache, no matter how old
+		if f.Offline {
+			defer cacheManager.Close()
+			cachedSecrets, stale, ok := cachedSecretValues(cachedEntries, envItems, now, time.Duration(math.MaxInt64))
+			if !ok {
+				return nil, fmt.Errorf("offline mode: not all secrets for environment '%s' are cached", envName)
 			}
-
-			// Try to get from cache
-			if value, found, err := cacheManager.Get(configPath, envName, envItem.EnvironmentVariable); err != nil {
-				logger.Debug("Failed to get secret from cache", "env_var", envItem.EnvironmentVariable, "error", err)
-				allCached = false
-				break
-			} else if found {
-				cachedSecrets[envItem.EnvironmentVariable] = value
-				logger.Debug("Retrieved secret from cache", "env_var", envItem.EnvironmentVariable)
-			} else {
-				logger.Debug("Secret not found in cache", "env_var", envItem.EnvironmentVariable)
-				allCached = false
-				break
+			if stale {
+				fmt.Fprintf(os.Stderr, "Warning: offline mode is using expired cached secrets for environment '%s'\n", envName)
 			}
+			return newResolution(envItems, cachedSecrets, nil, cachedEntries, now)
 		}
 
-		// If all secrets were found in cache, combine with static values
-		if allCached && len(cachedSecrets) > 0 {
+		// If all secrets are fresh in cache, combine with static values
+		if cachedSecrets, _, ok := cachedSecretValues(cachedEntries, envItems, now, 0); ok && len(cachedSecrets) > 0 {
 			logger.Debug("All secrets retrieved from cache", "count", len(cachedSecrets))
+			cacheManager.Close()
+			return newResolution(envItems, cachedSecrets, nil, cachedEntries, now)
+		}
 
-			// Combine cached secrets with static values
-			allSecrets := make(map[string]string)
-
-			// Add cached secrets
-			for envVar, value := range cachedSecrets {
-				allSecrets[envVar] = value
+		// Serve stale secrets right away and refresh the cache for the next run
+		if cacheConfig.StaleWhileRevalidate > 0 {
+			if cachedSecrets, _, ok := cachedSecretValues(cachedEntries, envItems, now, cacheConfig.StaleWhileRevalidate); ok && len(cachedSecrets) > 0 {
+				logger.Debug("Serving stale secrets from cache while revalidating", "count", len(cachedSecrets))
+				f.refreshes.Add(1)
+				go func() {
+					defer f.refreshes.Done()
+					defer cacheManager.Close()
+					fetched, _, errs := f.fetchProviderSecrets(ctx, env, envItems)
+					for _, err := range errs {
+						logger.Debug("Failed to refresh secrets in background", "error", err)
+					}
+					storeSecretsInCache(cacheManager, configPath, envName, envItems, fetched, cacheConfig.TTL)
+				}()
+				return newResolution(envItems, cachedSecrets, nil, cachedEntries, now)
 			}
+		}
+
+		logger.Debug("Not all secrets found in cache, fetching from providers")
+	}
 
-			// Add static values
+	allSecrets, expanded, errs := f.fetchProviderSecrets(ctx, env, envItems)
+	if f.FailOnError && len(errs) > 0 {
+		if cacheManager != nil {
+			cacheManager.Close()
+		}
+		return nil, errors.Join(errs...)
+	}
+	servedFromCache := make(map[string]*cache.CacheEntry)
+	for _, err := range errs {
+		// Log warning but continue with other providers
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// Cache the results if caching is enabled (only cache secrets, not static values)
+	if useCache {
+		storeSecretsInCache(cacheManager, configPath, envName, envItems, allSecrets, cacheConfig.TTL)
+
+		// Fall back to expired cache entries for secrets the providers failed to return
+		if len(errs) > 0 && cacheConfig.StaleIfError > 0 {
+			now := time.Now()
 			for _, envItem := range envItems {
-				if envItem.Value != nil {
-					allSecrets[envItem.EnvironmentVariable] = fmt.Sprintf("%v", envItem.Value)
+				if !isCacheableEnvItem(envItem) {
+					continue
+				}
+				if _, exists := allSecrets[envItem.EnvironmentVariable]; exists {
+					continue
+				}
+				entry := cachedEntries[envItem.EnvironmentVariable]
+				if entry == nil || now.Sub(entry.ExpiresAt) > cacheConfig.StaleIfError {
+					continue
 				}
+				fmt.Fprintf(os.Stderr, "Warning: provider unavailable, using cached value for %s (cached %s)\n", envItem.EnvironmentVariable, entry.CreatedAt.Format("2006-01-02 15:04:05"))
+				allSecrets[envItem.EnvironmentVariable] = entry.Value
+				servedFromCache[envItem.EnvironmentVariable] = entry
 			}
+		}
+	}
 
-			// Interpolate all values
-			for key, value := range allSecrets {
-				if strings.Contains(value, "${") {
-					interpolatedValue := config.InterpolateEnvVars(value, allSecrets)
-					allSecrets[key] = interpolatedValue
-				}
+	// Clean up cache manager
+	if cacheManager != nil {
+		cacheManager.Close()
+	}
+
+	return newResolution(envItems, allSecrets, expanded, servedFromCache, time.Now())
+}
+
+// WaitForRefresh blocks until all background cache refreshes started by
+// stale-while-revalidate lookups have finished
+func (f *SecretManagerFactory) WaitForRefresh() {
+	f.refreshes.Wait()
+}
+
+// isCacheableEnvItem reports whether the env item is resolved from a provider
+// and therefore stored in the cache
+func isCacheableEnvItem(envItem config.EnvItem) bool {
+	return envItem.Value == nil && (envItem.SecretKey != "" || envItem.SecretPath != "")
+}
+
+// getCachedEntries looks up the cache entries for all secret-based env items,
+// including expired entries that are still retained
+func getCachedEntries(cacheManager *cache.Manager, configPath, envName string, envItems []config.EnvItem) map[string]*cache.CacheEntry {
+	logger := log.NewLogger()
+
+	entries := make(map[string]*cache.CacheEntry)
+	for _, envItem := range envItems {
+		// Skip value-based mappings as they don't need caching
+		if envItem.Value != nil {
+			continue
+		}
+
+		entry, err := cacheManager.GetEntry(configPath, envName, envItem.EnvironmentVariable)
+		if err != nil {
+			logger.Debug("Failed to get secret from cache", "env_var", envItem.EnvironmentVariable, "error", err)
+			continue
+		}
+		if entry == nil {
+			logger.Debug("Secret not found in cache", "env_var", envItem.EnvironmentVariable)
+			continue
+		}
+		entries[envItem.EnvironmentVariable] = entry
+	}
+	return entries
+}
+
+// cachedSecretValues returns the cached values for all secret-based env items
+// if every one of them is cached and expired no longer than maxStale ago.
+// The stale result reports whether any of the returned values has expired.
+func cachedSecretValues(entries map[string]*cache.CacheEntry, envItems []config.EnvItem, now time.Time, maxStale time.Duration) (map[string]string, bool, bool) {
+	values := make(map[string]string)
+	stale := false
+	for _, envItem := range envItems {
+		if envItem.Value != nil {
+			continue
+		}
+		entry, found := entries[envItem.EnvironmentVariable]
+		if !found {
+			return nil, false, false
+		}
+		if now.After(entry.ExpiresAt) {
+			if now.Sub(entry.ExpiresAt) > maxStale {
+				return nil, false, false
 			}
+			stale = true
+		}
+		values[envItem.EnvironmentVariable] = entry.Value
+	}
+	return values, stale, true
+}
 
-			// Clean up cache manager
-			cacheManager.Close()
+// storeSecretsInCache caches the provider values of all secret-based env items
+func storeSecretsInCache(cacheManager *cache.Manager, configPath, envName string, envItems []config.EnvItem, secrets map[string]string, ttl time.Duration) {
+	logger := log.NewLogger()
+
+	cachedCount := 0
+	for _, envItem := range envItems {
+		// Only cache secrets (not static values)
+		if !isCacheableEnvItem(envItem) {
+			continue
+		}
+		envVar := envItem.EnvironmentVariable
+		if value, exists := secrets[envVar]; exists {
+			if err := cacheManager.Set(configPath, envName, envVar, value, ttl); err != nil {
+				logger.Debug("Failed to cache secret", "env_var", envVar, "error", err)
+			} else {
+				cachedCount++
+			}
+		}
+	}
+	logger.Debug("Cached secrets", "count", cachedCount, "ttl", ttl)
+}
+
+// resolveEnvItemValues combines secret values with the static values of the
+// env items and interpolates the values that reference secrets. Secret
+// values are never interpolated.
+func resolveEnvItemValues(envItems []config.EnvItem, secrets map[string]string) (map[string]string, error) {
+	vars := make(map[string]string, len(envItems))
+	for envVar, value := range secrets {
+		vars[envVar] = value
+	}
 
-			return allSecrets, nil
+	templates := make(map[string]string)
+	for _, envItem := range envItems {
+		if envItem.Value == nil {
+			continue
+		}
+		value := fmt.Sprintf("%v", envItem.Value)
+		if envItem.Interpolated {
+			vars[envItem.EnvironmentVariable] = value
+		} else {
+			templates[envItem.EnvironmentVariable] = value
 		}
+	}
 
-		logger.Debug("Not all secrets found in cache, fetching from providers", "cached_count", len(cachedSecrets))
+	interpolated, err := config.InterpolateValues(templates, vars)
+	if err != nil {
+		return nil, err
+	}
+	for envVar, value := range interpolated {
+		vars[envVar] = value
 	}
+	return vars, nil
+}
+
+// DefaultProject is the project key used for providers that don't use
+// projects in the same way as GCP, when no project is configured
+const DefaultProject = config.DefaultProject
+
+// NormalizeProject returns the project key secrets of the provider are
+// fetched, cached and forgotten under. For AWS, Azure, OpenBao, Bitwarden,
+// and local, an empty project becomes DefaultProject.
+func NormalizeProject(provider, project string) string {
+	switch provider {
+	case "aws", "azure", "openbao", "bitwarden", "local":
+		if project == "" {
+			return DefaultProject
+		}
+	}
+	return project
+}
+
+// fetchProviderSecrets fetches the values of all secret-based env items from
+// their providers. Failures do not stop the other lookups and are returned
+// alongside the values that could be retrieved. The variables expanded from
+// secret-paths are returned mapped to the name of their path's env item.
+func (f *SecretManagerFactory) fetchProviderSecrets(ctx context.Context, env *config.Environment, envItems []config.EnvItem) (map[string]string, map[string]string, []error) {
+	logger := log.NewLogger()
+	var errs []error
 
 	// Group mappings by provider and project for secret-based mappings
 	providerGroups := make(map[string]map[string][]string)
@@ -216,10 +440,7 @@ func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Con
 	// Group mappings by provider and project for path-based mappings
 	pathGroups := make(map[string]map[string]string)
 
-	// Get all env items (from map)
-	envItems := env.GetEnvItems()
 	logger.Debug("Processing environment mappings", "total_mappings", len(envItems))
-
 	// Process all env items to separate secret-based and value-based ones
 	for i, envItem := range envItems {
 		logger.Debug("Processing mapping", "index", i, "env_var", envItem.EnvironmentVariable, "has_secret_key", envItem.SecretKey != "", "has_secret_path", envItem.SecretPath != "", "has_value", envItem.Value != nil)
@@ -241,11 +462,7 @@ func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Con
 			if project == "" {
 				project = env.Project
 			}
-
-			// For AWS, Azure, OpenBao, Bitwarden, and local, we use a default project key since they don't use projects in the same way as GCP
-			if (provider == "aws" || provider == "azure" || provider == "openbao" || provider == "bitwarden" || provider == "local") && project == "" {
-				project = "default"
-			}
+			project = NormalizeProject(provider, project)
 
 			logger.Debug("Adding secret-based mapping to provider group", "provider", provider, "project", project, "secret_key", envItem.SecretKey)
 
@@ -267,11 +484,7 @@ func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Con
 			if project == "" {
 				project = env.Project
 			}
-
-			// For AWS, Azure, OpenBao, Bitwarden, and local, we use a default project key since they don't use projects in the same way as GCP
-			if (provider == "aws" || provider == "azure" || provider == "openbao" || provider == "bitwarden" || provider == "local") && project == "" {
-				project = "default"
-			}
+			project = NormalizeProject(provider, project)
 
 			logger.Debug("Adding path-based mapping to provider group", "provider", provider, "project", project, "secret_path", envItem.SecretPath)
 
@@ -288,16 +501,17 @@ func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Con
 
 	// Fetch secrets from each provider
 	allSecrets := make(map[string]string)
+	expanded := make(map[string]string)
 
 	for provider, projects := range providerGroups {
 		for project, secretIDs := range projects {
 			logger.Debug("Creating secret manager", "provider", provider, "project", project, "secret_count", len(secretIDs))
 
-			secretManager, err := f.CreateSecretManager(ctx, provider, project)
+			secretManager, err := f.createSecretReader(ctx, provider, project)
 			if err != nil {
 				logger.Debug("Failed to create secret manager", "provider", provider, "project", project, "error", err)
-				// Log warning but continue with other providers
-				fmt.Printf("Warning: failed to create secret manager for %s: %v\n", provider, err)
+				// Record the failure but continue with other providers
+				errs = append(errs, fmt.Errorf("failed to create secret manager for %s: %w", provider, err))
 				continue
 			}
 			defer secretManager.Close()
@@ -306,8 +520,8 @@ func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Con
 			secrets, err := secretManager.GetSecrets(project, secretIDs)
 			if err != nil {
 				logger.Debug("Failed to get secrets from provider", "provider", provider, "project", project, "error", err)
-				// Log warning but continue with other providers
-				fmt.Printf("Warning: failed to get secrets from %s project %s: %v\n", provider, project, err)
+				// Record the failure but continue with other providers
+				errs = append(errs, fmt.Errorf("failed to get secrets from %s project %s: %w", provider, project, err))
 				continue
 			}
 
@@ -325,11 +539,7 @@ func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Con
 					if envItemProject == "" {
 						envItemProject = env.Project
 					}
-
-					// For AWS, Azure, OpenBao, Bitwarden, and local, we use a default project key since they don't use projects in the same way as GCP
-					if (envItemProvider == "aws" || envItemProvider == "azure" || envItemProvider == "openbao" || envItemProvider == "bitwarden" || envItemProvider == "local") && envItemProject == "" {
-						envItemProject = "default"
-					}
+					envItemProject = NormalizeProject(envItemProvider, envItemProject)
 
 					// Only process mappings that match the current provider and project
 					if envItemProvider == provider && envItemProject == project {
@@ -350,17 +560,17 @@ func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Con
 		// Parse the path key to get provider and project
 		parts := strings.Split(pathKey, ":")
 		if len(parts) != 2 {
-			fmt.Printf("Warning: invalid path key format: %s\n", pathKey)
+			errs = append(errs, fmt.Errorf("invalid path key format: %s", pathKey))
 			continue
 		}
 
 		provider := parts[0]
 		project := parts[1]
 
-		secretManager, err := f.CreateSecretManager(ctx, provider, project)
+		secretManager, err := f.createSecretReader(ctx, provider, project)
 		if err != nil {
-			// Log warning but continue with other providers
-			fmt.Printf("Warning: failed to create secret manager for %s: %v\n", provider, err)
+			// Record the failure but continue with other providers
+			errs = append(errs, fmt.Errorf("failed to create secret manager for %s: %w", provider, err))
 			continue
 		}
 		defer secretManager.Close()
@@ -369,8 +579,8 @@ func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Con
 		for envVar, secretPath := range pathMappings {
 			secrets, err := secretManager.GetSecretsByPath(project, secretPath)
 			if err != nil {
-				// Log warning but continue with other paths
-				fmt.Printf("Warning: failed to get secrets from path '%s': %v\n", secretPath, err)
+				// Record the failure but continue with other paths
+				errs = append(errs, fmt.Errorf("failed to get secrets from path '%s': %w", secretPath, err))
 				continue
 			}
 
@@ -380,61 +590,10 @@ func (f *SecretManagerFactory) GetSecretsForEnvironmentWithCache(ctx context.Con
 				// Create a unique environment variable name by combining the mapping's env var and the secret name
 				finalEnvVarName := envVar + "_" + secretName
 				allSecrets[finalEnvVarName] = secretValue
+				expanded[finalEnvVarName] = envVar
 			}
 		}
 	}
 
-	// Process value-based mappings (no bare items allowed anymore)
-	for _, envItem := range envItems {
-		if envItem.Value != nil {
-			// Convert value to string
-			var strValue string
-			switch v := envItem.Value.(type) {
-			case string:
-				strValue = v
-			case int, int32, int64:
-				strValue = fmt.Sprintf("%d", v)
-			case float32, float64:
-				strValue = fmt.Sprintf("%g", v)
-			default:
-				strValue = fmt.Sprintf("%v", v)
-			}
-			allSecrets[envItem.EnvironmentVariable] = strValue
-		}
-	}
-
-	// Perform interpolation on all values now that we have all secrets and values
-	// This allows values to reference other environment variables that were just resolved
-	for key, value := range allSecrets {
-		if strings.Contains(value, "${") {
-			interpolatedValue := config.InterpolateEnvVars(value, allSecrets)
-			allSecrets[key] = interpolatedValue
-		}
-	}
-
-	// Cache the results if caching is enabled (only cache secrets, not static values)
-	if cacheManager != nil && cacheEnabled && configPath != "" && envName != "" {
-		cachedCount := 0
-		for _, envItem := range envItems {
-			// Only cache secrets (not static values)
-			if envItem.Value == nil && (envItem.SecretKey != "" || envItem.SecretPath != "") {
-				envVar := envItem.EnvironmentVariable
-				if value, exists := allSecrets[envVar]; exists {
-					if err := cacheManager.Set(configPath, envName, envVar, value, cacheTTL); err != nil {
-						logger.Debug("Failed to cache secret", "env_var", envVar, "error", err)
-					} else {
-						cachedCount++
-					}
-				}
-			}
-		}
-		logger.Debug("Cached secrets", "count", cachedCount, "ttl", cacheTTL)
-	}
-
-	// Clean up cache manager
-	if cacheManager != nil {
-		cacheManager.Close()
-	}
-
-	return allSecrets, nil
+	return allSecrets, expanded, errs
 }
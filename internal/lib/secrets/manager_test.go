@@ -0,0 +1,57 @@
+package secrets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/cache"
+)
+
+func TestCachedSecretValuesHonorsStaleWindow(t *testing.T) {
+	now := time.Now()
+	envItems := []config.EnvItem{
+		{EnvironmentVariable: "DB_PASSWORD", SecretKey: "db-password"},
+		{EnvironmentVariable: "APP_ENV", Value: "production"},
+	}
+	entries := map[string]*cache.CacheEntry{
+		"DB_PASSWORD": {Env: "DB_PASSWORD", Value: "s3cret", ExpiresAt: now.Add(-time.Hour)},
+	}
+
+	if _, _, ok := cachedSecretValues(entries, envItems, now, 0); ok {
+		t.Fatalf("expected expired entry to be rejected without a stale window")
+	}
+
+	values, stale, ok := cachedSecretValues(entries, envItems, now, 2*time.Hour)
+	if !ok || !stale {
+		t.Fatalf("expected stale entry within window, got ok=%v stale=%v", ok, stale)
+	}
+	if values["DB_PASSWORD"] != "s3cret" {
+		t.Fatalf("unexpected cached value: %q", values["DB_PASSWORD"])
+	}
+	if _, exists := values["APP_ENV"]; exists {
+		t.Fatalf("static values must not be read from cache")
+	}
+
+	if _, _, ok := cachedSecretValues(map[string]*cache.CacheEntry{}, envItems, now, 2*time.Hour); ok {
+		t.Fatalf("expected missing entry to be rejected")
+	}
+}
+
+func TestGetSecretsForEnvironmentOfflineRequiresCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	env := &config.Environment{
+		Provider: "gcp",
+		Project:  "test-project",
+		Env: map[string]config.EnvItem{
+			"DB_PASSWORD": {SecretKey: "db-password"},
+		},
+	}
+
+	factory := NewSecretManagerFactory()
+	factory.Offline = true
+	if _, err := factory.GetSecretsForEnvironmentWithCache(t.Context(), env, "kuba.yaml", "default"); err == nil {
+		t.Fatalf("expected offline mode without cache to fail")
+	}
+}
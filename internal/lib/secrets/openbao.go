@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 
 	vault "github.com/openbao/openbao/api/v2"
@@ -13,6 +15,9 @@ import (
 type OpenBaoManager struct {
 	client *vault.Client
 	ctx    context.Context
+	// projectID prefixes the secret names passed to the versioning methods,
+	// like the projectID argument of GetSecret
+	projectID string
 }
 
 // NewOpenBaoManager creates a new OpenBao client
@@ -52,14 +57,45 @@ func NewOpenBaoManager(ctx context.Context, address string, token string, namesp
 	}, nil
 }
 
-// GetSecret retrieves a secret from OpenBao
-func (o *OpenBaoManager) GetSecret(projectID, secretID string) (string, error) {
-	// In OpenBao, we use the secret path (secretID) to retrieve the secret
+// openBaoSecretPath returns the path of a secret, prefixed with the project
+// when one is set
+func openBaoSecretPath(projectID, secretID string) string {
 	// The projectID can be used as a namespace prefix if needed
-	secretPath := secretID
 	if projectID != "" {
-		secretPath = fmt.Sprintf("%s/%s", projectID, secretID)
+		return fmt.Sprintf("%s/%s", projectID, secretID)
+	}
+	return secretID
+}
+
+// openBaoValueField returns the key of the field holding a secret's value:
+// "value" when present, the only key of single-field secrets, and otherwise
+// the first key with a string value in sorted order
+func openBaoValueField(data map[string]interface{}) (string, bool) {
+	if _, ok := data["value"].(string); ok {
+		return "value", true
+	}
+	if len(data) == 1 {
+		for key := range data {
+			return key, true
+		}
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := data[key].(string); ok {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// GetSecret retrieves a secret from OpenBao
+func (o *OpenBaoManager) GetSecret(projectID, secretID string) (string, error) {
+	// In OpenBao, we use the secret path (secretID) to retrieve the secret
+	secretPath := openBaoSecretPath(projectID, secretID)
 
 	// Read the secret from OpenBao
 	secret, err := o.client.Logical().Read(secretPath)
@@ -68,34 +104,22 @@ func (o *OpenBaoManager) GetSecret(projectID, secretID string) (string, error) {
 	}
 
 	if secret == nil {
-		return "", fmt.Errorf("secret '%s' not found", secretPath)
+		return "", fmt.Errorf("%w: '%s'", ErrSecretNotFound, secretPath)
 	}
 
 	// OpenBao secrets are stored as key-value pairs
-	// We'll return the first value we find, or an error if no values exist
 	if len(secret.Data) == 0 {
 		return "", fmt.Errorf("secret '%s' has no data", secretPath)
 	}
 
-	// If there's only one key-value pair, return its value
-	if len(secret.Data) == 1 {
-		for _, value := range secret.Data {
-			if str, ok := value.(string); ok {
-				return str, nil
-			}
-			return fmt.Sprintf("%v", value), nil
-		}
+	field, ok := openBaoValueField(secret.Data)
+	if !ok {
+		return "", fmt.Errorf("secret '%s' contains no string values", secretPath)
 	}
-
-	// If there are multiple key-value pairs, return the first string value
-	for _, value := range secret.Data {
-		if str, ok := value.(string); ok {
-			return str, nil
-		}
+	if str, ok := secret.Data[field].(string); ok {
+		return str, nil
 	}
-
-	// If no string values found, return an error
-	return "", fmt.Errorf("secret '%s' contains no string values", secretPath)
+	return fmt.Sprintf("%v", secret.Data[field]), nil
 }
 
 // GetSecrets retrieves multiple secrets from OpenBao
@@ -199,3 +223,144 @@ func (o *OpenBaoManager) DeleteSecret(path string) error {
 
 	return nil
 }
+
+// kvV2Path splits a secret name, prefixed with the manager's project like
+// in GetSecret, into the KV v2 mount and the path within the mount
+func (o *OpenBaoManager) kvV2Path(secretName string) (string, string) {
+	return splitKVv2Path(openBaoSecretPath(o.projectID, secretName))
+}
+
+// CurrentVersion returns the latest version of a KV v2 secret
+func (o *OpenBaoManager) CurrentVersion(secretName string) (string, error) {
+	mount, secretPath := o.kvV2Path(secretName)
+	secret, err := o.client.KVv2(mount).Get(o.ctx, secretPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret '%s': %w", secretName, err)
+	}
+	if secret.VersionMetadata == nil {
+		return "", fmt.Errorf("secret '%s' has no version metadata (is it a KV v2 mount?)", secretName)
+	}
+	return strconv.Itoa(secret.VersionMetadata.Version), nil
+}
+
+// AddVersion writes a new version of a KV v2 secret and returns it. Only the
+// field GetSecret returns is changed, the other fields of the secret are
+// carried over to the new version.
+func (o *OpenBaoManager) AddVersion(secretName, secretValue string) (string, error) {
+	mount, secretPath := o.kvV2Path(secretName)
+	kv := o.client.KVv2(mount)
+
+	current, err := kv.Get(o.ctx, secretPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret '%s': %w", secretName, err)
+	}
+
+	data := make(map[string]interface{}, len(current.Data)+1)
+	for key, value := range current.Data {
+		data[key] = value
+	}
+	field, ok := openBaoValueField(data)
+	if !ok {
+		field = "value"
+	}
+	data[field] = secretValue
+
+	// Check-and-set makes the write fail instead of dropping a version that
+	// was written since the read
+	var opts []vault.KVOption
+	if current.VersionMetadata != nil {
+		opts = append(opts, vault.WithCheckAndSet(current.VersionMetadata.Version))
+	}
+	secret, err := kv.Put(o.ctx, secretPath, data, opts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to write secret '%s': %w", secretName, err)
+	}
+	if secret.VersionMetadata == nil {
+		return "", nil
+	}
+	return strconv.Itoa(secret.VersionMetadata.Version), nil
+}
+
+// DisableVersion soft-deletes a single version of a KV v2 secret
+func (o *OpenBaoManager) DisableVersion(secretName, versionID string) error {
+	version, err := strconv.Atoi(versionID)
+	if err != nil {
+		return fmt.Errorf("invalid version '%s': %w", versionID, err)
+	}
+	mount, secretPath := o.kvV2Path(secretName)
+	if err := o.client.KVv2(mount).DeleteVersions(o.ctx, secretPath, []int{version}); err != nil {
+		return fmt.Errorf("failed to delete version '%s' of '%s': %w", versionID, secretName, err)
+	}
+	return nil
+}
+
+// ListVersions returns all versions of a KV v2 secret, newest first
+func (o *OpenBaoManager) ListVersions(secretName string) ([]SecretVersion, error) {
+	mount, secretPath := o.kvV2Path(secretName)
+	metadata, err := o.client.KVv2(mount).GetVersionsAsList(o.ctx, secretPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list versions of '%s': %w", secretName, err)
+	}
+
+	latest := 0
+	versions := make([]SecretVersion, 0, len(metadata))
+	for _, md := range metadata {
+		state := "active"
+		if md.Destroyed {
+			state = "destroyed"
+		} else if !md.DeletionTime.IsZero() {
+			state = "deleted"
+		}
+		versions = append(versions, SecretVersion{
+			ID:        strconv.Itoa(md.Version),
+			CreatedAt: md.CreatedTime,
+			State:     state,
+		})
+		if md.Version > latest {
+			latest = md.Version
+		}
+	}
+
+	for i := range versions {
+		versions[i].Current = versions[i].ID == strconv.Itoa(latest)
+	}
+	sortVersionsNewestFirst(versions)
+	return versions, nil
+}
+
+// AccessVersion returns the value of a single version of a KV v2 secret
+func (o *OpenBaoManager) AccessVersion(secretName, versionID string) (string, error) {
+	version, err := strconv.Atoi(versionID)
+	if err != nil {
+		return "", fmt.Errorf("invalid version '%s': %w", versionID, err)
+	}
+	mount, secretPath := o.kvV2Path(secretName)
+	secret, err := o.client.KVv2(mount).GetVersion(o.ctx, secretPath, version)
+	if err != nil {
+		return "", fmt.Errorf("failed to access version '%s' of '%s': %w", versionID, secretName, err)
+	}
+	if field, ok := openBaoValueField(secret.Data); ok {
+		if value, ok := secret.Data[field].(string); ok {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("version '%s' of '%s' contains no string values", versionID, secretName)
+}
+
+// PromoteVersion rolls a KV v2 secret back to an older version, which is
+// written as a new version
+func (o *OpenBaoManager) PromoteVersion(secretName, versionID string) (string, error) {
+	version, err := strconv.Atoi(versionID)
+	if err != nil {
+		return "", fmt.Errorf("invalid version '%s': %w", versionID, err)
+	}
+	mount, secretPath := o.kvV2Path(secretName)
+	secret, err := o.client.KVv2(mount).Rollback(o.ctx, secretPath, version)
+	if err != nil {
+		return "", fmt.Errorf("failed to roll back '%s' to version '%s': %w", secretName, versionID, err)
+	}
+	if secret.VersionMetadata == nil {
+		return "", nil
+	}
+	return strconv.Itoa(secret.VersionMetadata.Version), nil
+}
@@ -2,6 +2,10 @@ package secrets
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -226,3 +230,103 @@ func TestOpenBaoManager_DeleteSecret(t *testing.T) {
 		t.Errorf("Failed to delete secret: %v", err)
 	}
 }
+
+func TestOpenBaoManager_AddVersionKeepsOtherFields(t *testing.T) {
+	data := map[string]interface{}{"password": "old-password", "username": "admin"}
+	var written map[string]interface{}
+	var options map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The project is part of the path like in GetSecret
+		if r.URL.Path != "/v1/team/data/app/db" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		metadata := map[string]interface{}{
+			"version":       3,
+			"created_time":  "2026-01-02T15:04:05Z",
+			"deletion_time": "",
+			"destroyed":     false,
+		}
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"data": data, "metadata": metadata},
+			})
+		case http.MethodPut, http.MethodPost:
+			var body map[string]map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("failed to decode request: %v", err)
+			}
+			written, options = body["data"], body["options"]
+			metadata["version"] = 4
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": metadata})
+		default:
+			t.Errorf("unexpected method %s", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	manager, err := NewOpenBaoManager(context.Background(), server.URL, "test-token", "")
+	if err != nil {
+		t.Fatalf("Failed to create OpenBao manager: %v", err)
+	}
+	manager.projectID = "team"
+
+	version, err := manager.AddVersion("app/db", "new-password")
+	if err != nil {
+		t.Fatalf("AddVersion() error = %v", err)
+	}
+	if version != "4" {
+		t.Errorf("AddVersion() = %q, want %q", version, "4")
+	}
+	if written["password"] != "new-password" {
+		t.Errorf("password = %v, want %q", written["password"], "new-password")
+	}
+	if written["username"] != "admin" {
+		t.Errorf("username = %v, want it to be kept as %q", written["username"], "admin")
+	}
+	if len(written) != 2 {
+		t.Errorf("written fields = %v, want password and username only", written)
+	}
+	if options["cas"] != float64(3) {
+		t.Errorf("cas = %v, want 3", options["cas"])
+	}
+}
+
+func TestOpenBaoManager_GetSecretReportsMissingSecrets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"errors":[]}`))
+	}))
+	defer server.Close()
+
+	manager, err := NewOpenBaoManager(context.Background(), server.URL, "test-token", "")
+	if err != nil {
+		t.Fatalf("Failed to create OpenBao manager: %v", err)
+	}
+
+	_, err = manager.GetSecret("secret", "missing")
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("GetSecret() error = %v, want ErrSecretNotFound", err)
+	}
+}
+
+func TestOpenBaoValueField(t *testing.T) {
+	tests := []struct {
+		data  map[string]interface{}
+		field string
+	}{
+		{map[string]interface{}{"value": "a", "other": "b"}, "value"},
+		{map[string]interface{}{"token": 42}, "token"},
+		{map[string]interface{}{"username": "a", "password": "b", "count": 1}, "password"},
+	}
+
+	for _, test := range tests {
+		field, ok := openBaoValueField(test.data)
+		if !ok || field != test.field {
+			t.Errorf("openBaoValueField(%v) = (%q, %v), want %q", test.data, field, ok, test.field)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package secrets
+
+import (
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/cache"
+)
+
+// Origins of resolved values
+const (
+	OriginValue    = "value"    // static value from kuba.yaml
+	OriginProvider = "provider" // fetched from the provider
+	OriginCache    = "cache"    // served from the cache
+)
+
+// ValueSource describes where a resolved value came from
+type ValueSource struct {
+	Origin string
+	// Item is the env item the value was resolved from. It differs from the
+	// variable name for variables expanded from a secret-path.
+	Item string
+	// CachedAt, ExpiresAt and Stale are set for values served from the cache
+	CachedAt  time.Time
+	ExpiresAt time.Time
+	Stale     bool
+}
+
+// Resolution holds the resolved values of an environment and their sources
+type Resolution struct {
+	Values  map[string]string
+	Sources map[string]ValueSource
+}
+
+// Expanded returns the variables expanded from a secret-path, mapped to the
+// name of the path's env item
+func (r *Resolution) Expanded() map[string]string {
+	expanded := make(map[string]string)
+	for name, source := range r.Sources {
+		if source.Item != name {
+			expanded[name] = source.Item
+		}
+	}
+	return expanded
+}
+
+// newResolution resolves the env item values and records the source of each
+// of them. Secrets with an entry in cached were served from the cache, and
+// expanded maps the variables expanded from a secret-path to the path's item.
+func newResolution(envItems []config.EnvItem, secrets, expanded map[string]string, cached map[string]*cache.CacheEntry, now time.Time) (*Resolution, error) {
+	values, err := resolveEnvItemValues(envItems, secrets)
+	if err != nil {
+		return nil, err
+	}
+	sources := make(map[string]ValueSource, len(values))
+
+	for _, envItem := range envItems {
+		name := envItem.EnvironmentVariable
+		switch {
+		case envItem.Value != nil:
+			sources[name] = ValueSource{Origin: OriginValue, Item: name}
+		case envItem.SecretKey != "":
+			if _, ok := values[name]; !ok {
+				continue
+			}
+			if entry := cached[name]; entry != nil {
+				sources[name] = ValueSource{
+					Origin:    OriginCache,
+					Item:      name,
+					CachedAt:  entry.CreatedAt,
+					ExpiresAt: entry.ExpiresAt,
+					Stale:     now.After(entry.ExpiresAt),
+				}
+				continue
+			}
+			sources[name] = ValueSource{Origin: OriginProvider, Item: name}
+		}
+	}
+
+	// Expansions replace the values of secret-key items with the same name,
+	// but not static values
+	for key, item := range expanded {
+		if sources[key].Origin == OriginValue {
+			continue
+		}
+		sources[key] = ValueSource{Origin: OriginProvider, Item: item}
+	}
+
+	return &Resolution{Values: values, Sources: sources}, nil
+}
@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/cache"
+)
+
+func TestNewResolutionRecordsSources(t *testing.T) {
+	now := time.Now()
+	envItems := []config.EnvItem{
+		{EnvironmentVariable: "STATIC", Value: "plain"},
+		{EnvironmentVariable: "LIVE", SecretKey: "live-secret"},
+		{EnvironmentVariable: "CACHED", SecretKey: "cached-secret"},
+		{EnvironmentVariable: "MISSING", SecretKey: "missing-secret"},
+		{EnvironmentVariable: "APP", SecretPath: "app/"},
+	}
+	fetched := map[string]string{
+		"LIVE":       "live",
+		"CACHED":     "cached",
+		"APP_TOKEN":  "token",
+		"APP_SECRET": "secret",
+		"APP_CONFIG": "config",
+	}
+	// APP_CONFIG merely shares the prefix of the APP secret-path item
+	expanded := map[string]string{
+		"APP_TOKEN":  "APP",
+		"APP_SECRET": "APP",
+	}
+	cached := map[string]*cache.CacheEntry{
+		"CACHED": {Value: "cached", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+	}
+
+	resolution, err := newResolution(envItems, fetched, expanded, cached, now)
+	if err != nil {
+		t.Fatalf("failed to resolve values: %v", err)
+	}
+
+	if got := resolution.Values["STATIC"]; got != "plain" {
+		t.Errorf("expected STATIC=plain, got %q", got)
+	}
+	if got := resolution.Sources["STATIC"].Origin; got != OriginValue {
+		t.Errorf("expected STATIC from %s, got %s", OriginValue, got)
+	}
+	if got := resolution.Sources["LIVE"].Origin; got != OriginProvider {
+		t.Errorf("expected LIVE from %s, got %s", OriginProvider, got)
+	}
+
+	source := resolution.Sources["CACHED"]
+	if source.Origin != OriginCache {
+		t.Errorf("expected CACHED from %s, got %s", OriginCache, source.Origin)
+	}
+	if !source.Stale {
+		t.Errorf("expected expired cache entry to be reported as stale")
+	}
+	if !source.ExpiresAt.Equal(cached["CACHED"].ExpiresAt) {
+		t.Errorf("expected expiry %v, got %v", cached["CACHED"].ExpiresAt, source.ExpiresAt)
+	}
+
+	if _, ok := resolution.Sources["MISSING"]; ok {
+		t.Errorf("expected no source for a secret the provider did not return")
+	}
+
+	for _, name := range []string{"APP_TOKEN", "APP_SECRET"} {
+		source := resolution.Sources[name]
+		if source.Origin != OriginProvider || source.Item != "APP" {
+			t.Errorf("expected %s from the APP secret-path item, got %+v", name, source)
+		}
+	}
+	if source, ok := resolution.Sources["APP_CONFIG"]; ok {
+		t.Errorf("expected APP_CONFIG not to be attributed to the APP secret-path item, got %+v", source)
+	}
+	if got := resolution.Expanded(); len(got) != 2 || got["APP_TOKEN"] != "APP" || got["APP_SECRET"] != "APP" {
+		t.Errorf("expected the expansions of APP, got %v", got)
+	}
+}
+
+func TestResolveEnvItemValuesInterpolatesOnlyValues(t *testing.T) {
+	envItems := []config.EnvItem{
+		{EnvironmentVariable: "DB_PASSWORD", SecretKey: "db-password"},
+		{EnvironmentVariable: "TOKEN", SecretKey: "token"},
+		{EnvironmentVariable: "DB_URL", Value: "postgres://app:${DB_PASSWORD}@db/$$x"},
+		{EnvironmentVariable: "LITERAL", Value: "${not-interpolated}", Interpolated: true},
+		{EnvironmentVariable: "REQUIRED", Value: "${TOKEN:?token is required}"},
+	}
+
+	values, err := resolveEnvItemValues(envItems, map[string]string{"DB_PASSWORD": "pa$$", "TOKEN": "${HOME}"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"DB_PASSWORD": "pa$$",
+		"TOKEN":       "${HOME}",
+		"DB_URL":      "postgres://app:pa$$@db/$x",
+		"LITERAL":     "${not-interpolated}",
+		"REQUIRED":    "${HOME}",
+	}
+	for name, want := range expected {
+		if got := values[name]; got != want {
+			t.Errorf("expected %s=%q, got %q", name, want, got)
+		}
+	}
+
+	_, err = resolveEnvItemValues(envItems, map[string]string{"DB_PASSWORD": "x"})
+	if err == nil || err.Error() != "variable 'REQUIRED': TOKEN: token is required" {
+		t.Errorf("expected the missing token to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package secrets
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SecretVersioner is implemented by providers with native secret versioning
+type SecretVersioner interface {
+	// CurrentVersion returns the ID of the latest version of a secret
+	CurrentVersion(secretName string) (string, error)
+	// AddVersion writes a new version of a secret and returns its ID
+	AddVersion(secretName, secretValue string) (string, error)
+	// DisableVersion makes a single version of a secret unusable
+	DisableVersion(secretName, versionID string) error
+}
+
+// AsVersioner converts a SecretManager into a SecretVersioner when supported.
+func AsVersioner(sm SecretManager) (SecretVersioner, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("nil secret manager")
+	}
+	if v, ok := sm.(SecretVersioner); ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("provider does not support secret versioning")
+}
+
+// SecretVersion describes a single version of a secret
+type SecretVersion struct {
+	ID        string
+	CreatedAt time.Time
+	// State is the provider's state of the version, e.g. "enabled",
+	// "disabled", "destroyed" or AWS staging labels like "AWSCURRENT"
+	State string
+	// Current is set for the version that is served by default
+	Current bool
+}
+
+// SecretHistory is implemented by providers that can list, read and restore
+// previous versions of a secret
+type SecretHistory interface {
+	// ListVersions returns all versions of a secret, newest first
+	ListVersions(secretName string) ([]SecretVersion, error)
+	// AccessVersion returns the value of a single version
+	AccessVersion(secretName, versionID string) (string, error)
+	// PromoteVersion makes an older version current again and returns the
+	// ID of the version that is now current. Providers without a native way
+	// to do this write the old value as a new version.
+	PromoteVersion(secretName, versionID string) (string, error)
+}
+
+// AsHistory converts a SecretManager into a SecretHistory when supported.
+func AsHistory(sm SecretManager) (SecretHistory, error) {
+	if sm == nil {
+		return nil, fmt.Errorf("nil secret manager")
+	}
+	if h, ok := sm.(SecretHistory); ok {
+		return h, nil
+	}
+	return nil, fmt.Errorf("provider does not support secret version history")
+}
+
+// sortVersionsNewestFirst orders versions by creation time, newest first
+func sortVersionsNewestFirst(versions []SecretVersion) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].CreatedAt.After(versions[j].CreatedAt)
+	})
+}
+
+// splitKVv2Path splits an OpenBao secret path like "secret/app/db" into the
+// KV v2 mount ("secret") and the path within the mount ("app/db").
+// Paths without a mount use the default "secret" mount.
+func splitKVv2Path(secretName string) (string, string) {
+	secretName = strings.Trim(secretName, "/")
+	mount, rest, ok := strings.Cut(secretName, "/")
+	if !ok {
+		return "secret", secretName
+	}
+	return mount, rest
+}
@@ -0,0 +1,74 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSplitKVv2Path(t *testing.T) {
+	tests := []struct {
+		input string
+		mount string
+		path  string
+	}{
+		{"secret/openbao-secret", "secret", "openbao-secret"},
+		{"kv/app/db-password", "kv", "app/db-password"},
+		{"/secret/app/", "secret", "app"},
+		{"db-password", "secret", "db-password"},
+	}
+
+	for _, test := range tests {
+		mount, path := splitKVv2Path(test.input)
+		if mount != test.mount || path != test.path {
+			t.Errorf("splitKVv2Path(%q) = (%q, %q), want (%q, %q)", test.input, mount, path, test.mount, test.path)
+		}
+	}
+}
+
+func TestAsVersionerRejectsUnsupportedProviders(t *testing.T) {
+	local, err := NewLocalManager(context.Background())
+	if err != nil {
+		t.Fatalf("NewLocalManager() error = %v", err)
+	}
+	if _, err := AsVersioner(local); err == nil {
+		t.Fatalf("expected local provider to not support versioning")
+	}
+
+	var _ SecretVersioner = (*GCPSecretManager)(nil)
+	var _ SecretVersioner = (*AWSSecretsManager)(nil)
+	var _ SecretVersioner = (*AzureKeyVaultManager)(nil)
+	var _ SecretVersioner = (*OpenBaoManager)(nil)
+}
+
+func TestAsHistoryRejectsUnsupportedProviders(t *testing.T) {
+	local, err := NewLocalManager(context.Background())
+	if err != nil {
+		t.Fatalf("NewLocalManager() error = %v", err)
+	}
+	if _, err := AsHistory(local); err == nil {
+		t.Fatalf("expected local provider to not support version history")
+	}
+
+	var _ SecretHistory = (*GCPSecretManager)(nil)
+	var _ SecretHistory = (*AWSSecretsManager)(nil)
+	var _ SecretHistory = (*AzureKeyVaultManager)(nil)
+	var _ SecretHistory = (*OpenBaoManager)(nil)
+}
+
+func TestSortVersionsNewestFirst(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	versions := []SecretVersion{
+		{ID: "1", CreatedAt: base},
+		{ID: "3", CreatedAt: base.Add(2 * time.Hour)},
+		{ID: "2", CreatedAt: base.Add(time.Hour)},
+	}
+
+	sortVersionsNewestFirst(versions)
+
+	for i, want := range []string{"3", "2", "1"} {
+		if versions[i].ID != want {
+			t.Errorf("versions[%d].ID = %q, want %q", i, versions[i].ID, want)
+		}
+	}
+}
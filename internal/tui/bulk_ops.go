@@ -0,0 +1,218 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/dotenv"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+)
+
+// Bulk actions on the selected rows of the secrets table.
+const (
+	bulkUnmap  = "unmap"
+	bulkDelete = "delete"
+	bulkMove   = "move"
+	bulkCopy   = "copy"
+	bulkExport = "export"
+)
+
+// Outcomes of a bulk action for a single row.
+const (
+	bulkPending   = "pending"
+	bulkOK        = "ok"
+	bulkSkipped   = "skipped"
+	bulkFailed    = "failed"
+	bulkCancelled = "cancelled"
+)
+
+type bulkResult struct {
+	status string
+	detail string
+}
+
+// bulkJob is a bulk action running over a snapshot of rows, one row at a time.
+type bulkJob struct {
+	action     string
+	rows       []secretRow
+	results    []bulkResult
+	next       int
+	done       bool
+	configPath string
+	envName    string
+	targetEnv  string
+	exportPath string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// Secret managers are reused across rows, keyed by provider and project.
+	managers    map[string]secrets.SecretManager
+	exportLines []string
+}
+
+func (j *bulkJob) manager(provider, project string, globalCfg *config.GlobalConfig) (secrets.SecretManager, error) {
+	key := provider + "|" + project
+	if sm, ok := j.managers[key]; ok {
+		return sm, nil
+	}
+	sm, err := secrets.NewSecretManagerFactory().CreateSecretManager(j.ctx, provider, project)
+	if err != nil {
+		return nil, err
+	}
+	if gcpSM, ok := sm.(*secrets.GCPSecretManager); ok {
+		if err := gcpSM.ApplyCreateDefaults(globalCfg); err != nil {
+			sm.Close()
+			return nil, err
+		}
+	}
+	j.managers[key] = sm
+	return sm, nil
+}
+
+func (j *bulkJob) close() {
+	for _, sm := range j.managers {
+		sm.Close()
+	}
+	j.managers = map[string]secrets.SecretManager{}
+	if j.cancel != nil {
+		j.cancel()
+	}
+}
+
+// counts summarizes the results by status.
+func (j *bulkJob) counts() map[string]int {
+	counts := map[string]int{}
+	for _, r := range j.results {
+		counts[r.status]++
+	}
+	return counts
+}
+
+// runBulkStep applies the job's action to a single row.
+func (m *Model) runBulkStep(job *bulkJob, row secretRow) bulkResult {
+	if err := job.ctx.Err(); err != nil {
+		return bulkResult{status: bulkCancelled}
+	}
+
+	switch job.action {
+	case bulkUnmap:
+		if err := config.RemoveEnvMapping(job.configPath, job.envName, row.envVar); err != nil {
+			return bulkResult{status: bulkFailed, detail: err.Error()}
+		}
+		return bulkResult{status: bulkOK, detail: "mapping removed"}
+	case bulkDelete:
+		if row.refKind != "secret-key" {
+			return bulkResult{status: bulkSkipped, detail: "only secret-key mappings can be deleted"}
+		}
+		sm, err := job.manager(row.provider, row.project, m.globalCfg)
+		if err != nil {
+			return bulkResult{status: bulkFailed, detail: err.Error()}
+		}
+		mut, err := secrets.AsMutator(sm)
+		if err != nil {
+			return bulkResult{status: bulkFailed, detail: err.Error()}
+		}
+		if err := mut.DeleteSecret(row.ref, true); err != nil {
+			return bulkResult{status: bulkFailed, detail: err.Error()}
+		}
+		m.invalidateSecret(row.provider, row.project, row.ref)
+		if err := config.RemoveEnvMapping(job.configPath, job.envName, row.envVar); err != nil {
+			return bulkResult{status: bulkFailed, detail: "secret deleted, but " + err.Error()}
+		}
+		return bulkResult{status: bulkOK, detail: "secret and mapping deleted"}
+	case bulkCopy, bulkMove:
+		res := m.copyRowToEnv(job, row)
+		if job.action == bulkCopy || res.status != bulkOK {
+			return res
+		}
+		if err := config.RemoveEnvMapping(job.configPath, job.envName, row.envVar); err != nil {
+			return bulkResult{status: bulkFailed, detail: "copied, but " + err.Error()}
+		}
+		return bulkResult{status: bulkOK, detail: strings.Replace(res.detail, "copied", "moved", 1)}
+	case bulkExport:
+		job.exportLines = append(job.exportLines, dotenvLine(row.envVar, row.value))
+		return bulkResult{status: bulkOK, detail: "exported"}
+	default:
+		return bulkResult{status: bulkFailed, detail: fmt.Sprintf("unknown action '%s'", job.action)}
+	}
+}
+
+// copyRowToEnv writes the row's secret into the target environment's provider
+// and maps it there under the same secret key. Existing secrets and mappings
+// in the target are never overwritten.
+func (m *Model) copyRowToEnv(job *bulkJob, row secretRow) bulkResult {
+	if row.refKind != "secret-key" {
+		return bulkResult{status: bulkSkipped, detail: "only secret-key mappings can be copied"}
+	}
+	target, err := m.cfg.GetEnvironment(job.targetEnv)
+	if err != nil {
+		return bulkResult{status: bulkFailed, detail: err.Error()}
+	}
+	if _, ok := target.Env[row.envVar]; ok {
+		return bulkResult{status: bulkSkipped, detail: fmt.Sprintf("already mapped in '%s'", job.targetEnv)}
+	}
+
+	// Projects are compared the way secrets are looked up, so an empty and
+	// the default project are the same store
+	sourceProject := secrets.NormalizeProject(row.provider, row.project)
+	targetProject := secrets.NormalizeProject(target.Provider, target.Project)
+
+	detail := fmt.Sprintf("copied to '%s'", job.targetEnv)
+	if target.Provider != row.provider || targetProject != sourceProject {
+		// The value is read from the provider, not the cache or the agent,
+		// so a stale or unresolved value is never copied
+		source, err := job.manager(row.provider, sourceProject, m.globalCfg)
+		if err != nil {
+			return bulkResult{status: bulkFailed, detail: err.Error()}
+		}
+		value, err := source.GetSecret(sourceProject, row.ref)
+		if err != nil {
+			return bulkResult{status: bulkFailed, detail: fmt.Sprintf("failed to read secret '%s': %v", row.ref, err)}
+		}
+
+		sm, err := job.manager(target.Provider, targetProject, m.globalCfg)
+		if err != nil {
+			return bulkResult{status: bulkFailed, detail: err.Error()}
+		}
+		mut, err := secrets.AsMutator(sm)
+		if err != nil {
+			return bulkResult{status: bulkFailed, detail: err.Error()}
+		}
+		_, err = sm.GetSecret(targetProject, row.ref)
+		if err == nil {
+			return bulkResult{status: bulkSkipped, detail: fmt.Sprintf("secret '%s' already exists in %s", row.ref, target.Provider)}
+		}
+		if !errors.Is(err, secrets.ErrSecretNotFound) {
+			return bulkResult{status: bulkFailed, detail: fmt.Sprintf("failed to check secret '%s': %v", row.ref, err)}
+		}
+		if err := mut.CreateSecret(row.ref, value, ""); err != nil {
+			return bulkResult{status: bulkFailed, detail: err.Error()}
+		}
+		m.invalidateSecret(target.Provider, target.Project, row.ref)
+		detail = fmt.Sprintf("copied to '%s' (%s)", job.targetEnv, target.Provider)
+	}
+
+	if err := config.AddOrUpdateEnvSecretKeyMapping(job.configPath, job.targetEnv, row.envVar, row.ref); err != nil {
+		return bulkResult{status: bulkFailed, detail: err.Error()}
+	}
+	return bulkResult{status: bulkOK, detail: detail}
+}
+
+// writeExport writes the exported dotenv lines, readable only by the owner.
+func (j *bulkJob) writeExport() error {
+	lines := append([]string(nil), j.exportLines...)
+	sort.Strings(lines)
+	content := strings.Join(lines, "\n") + "\n"
+	return os.WriteFile(j.exportPath, []byte(content), 0600)
+}
+
+// dotenvLine formats a single KEY=VALUE line, quoted like kuba show does.
+func dotenvLine(key, value string) string {
+	return key + "=" + dotenv.Quote(value)
+}
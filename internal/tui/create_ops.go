@@ -106,6 +106,7 @@ func (m *Model) doCreateFromForm(in createInput) error {
 	if err := mut.CreateSecret(secretKey, val, desc); err != nil {
 		return err
 	}
+	m.invalidateSecret(provider, project, secretKey)
 
 	// Add mapping to kuba.yaml.
 	if err := config.AddOrUpdateEnvSecretKeyMapping(in.configPath, in.envName, envVar, secretKey); err != nil {
@@ -23,6 +23,8 @@ type Model struct {
 
 	cfg       *config.KubaConfig
 	globalCfg *config.GlobalConfig
+	// confirmed allows edits of environments whose policy requires --confirm
+	confirmed bool
 
 	screen Screen
 	errMsg string
@@ -37,6 +39,8 @@ type Model struct {
 
 	secretTable table.Model
 	allRows     []secretRow
+	visibleRows []secretRow // allRows matching the filter, in table order
+	selected    map[string]bool
 	maskValues  bool
 
 	filter textinput.Model
@@ -76,6 +80,45 @@ type Model struct {
 
 	busyText string
 	spinner  spinner.Model
+
+	versionsTarget   *secretRow
+	versionsTable    table.Model
+	versions         []secrets.SecretVersion
+	versionsLoading  bool
+	versionsErr      string
+	versionPreviewID string
+	versionPreview   string
+	versionReveal    bool
+	versionConfirm   string // version ID awaiting promote confirmation
+
+	compareForm      *huh.Form
+	compareEnvNames  []string
+	compareRows      []compareRow
+	compareTable     table.Model
+	compareCol       int
+	compareFilter    textinput.Model
+	compareDriftOnly bool
+	compareResolving bool
+	compareHashes    map[string]map[string]string
+	// compareReturn is set after jumping from the comparison to a cell, so
+	// leaving the secrets screen returns to the comparison.
+	compareReturn bool
+
+	bulkForm       *huh.Form
+	bulkAction     string
+	bulkTargetEnv  string
+	bulkExportPath string
+	bulkConfirm    bool
+	bulkJob        *bulkJob
+	bulkTable      table.Model
+
+	browseNames        []string
+	browseLoading      bool
+	browseErr          string
+	browseNotice       string
+	browseUnmappedOnly bool
+	browseFilter       textinput.Model
+	browseTable        table.Model
 }
 
 func (m *Model) sizeFormToModalBody(f *huh.Form) *huh.Form {
@@ -91,7 +134,7 @@ func (m *Model) sizeFormToModalBody(f *huh.Form) *huh.Form {
 	return f.WithWidth(innerW).WithHeight(bodyH)
 }
 
-func New(ctx context.Context, configPath string) (*Model, error) {
+func New(ctx context.Context, configPath, envName string, confirmed bool) (*Model, error) {
 	cfg, err := config.LoadKubaConfig(configPath)
 	if err != nil {
 		return nil, err
@@ -118,6 +161,17 @@ func New(ctx context.Context, configPath string) (*Model, error) {
 	l.Title = "Environments"
 	l.SetShowHelp(true)
 	l.Styles = vhsListStyles()
+	l.AdditionalShortHelpKeys = envListHelpKeys
+	l.AdditionalFullHelpKeys = envListHelpKeys
+
+	// Preselect the environment that would be used by run and show
+	selected := cfg.SelectEnvironment(envName, configPath).Name
+	for i, n := range envNames {
+		if n == selected {
+			l.Select(i)
+			break
+		}
+	}
 
 	filter := textinput.New()
 	filter.Placeholder = "Filter secrets…"
@@ -142,9 +196,11 @@ func New(ctx context.Context, configPath string) (*Model, error) {
 		configPath:        configPath,
 		cfg:               cfg,
 		globalCfg:         globalCfg,
+		confirmed:         confirmed,
 		screen:            screenEnvs,
 		envList:           l,
 		secretTable:       t,
+		selected:          map[string]bool{},
 		maskValues:        true,
 		filter:            filter,
 		createReplication: "global",
@@ -187,6 +243,18 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m.updateError(msg)
 	case screenBusy:
 		return m.updateBusy(msg)
+	case screenVersions:
+		return m.updateVersions(msg)
+	case screenCompareSelect:
+		return m.updateCompareSelect(msg)
+	case screenCompare:
+		return m.updateCompare(msg)
+	case screenBulkForm:
+		return m.updateBulkForm(msg)
+	case screenBulkRun:
+		return m.updateBulkRun(msg)
+	case screenBrowse:
+		return m.updateBrowse(msg)
 	default:
 		return m, nil
 	}
@@ -255,6 +323,40 @@ func (m *Model) View() tea.View {
 		v := tea.NewView(m.viewModal("Working…", body))
 		v.AltScreen = true
 		return v
+	case screenVersions:
+		v := tea.NewView(m.viewVersions())
+		v.AltScreen = true
+		return v
+	case screenCompareSelect:
+		if m.compareForm == nil {
+			v := tea.NewView(m.viewModal("Compare environments", "Loading…"))
+			v.AltScreen = true
+			return v
+		}
+		v := tea.NewView(m.viewModal("Compare environments", m.compareForm.View()))
+		v.AltScreen = true
+		return v
+	case screenCompare:
+		v := tea.NewView(m.viewCompare())
+		v.AltScreen = true
+		return v
+	case screenBulkForm:
+		if m.bulkForm == nil {
+			v := tea.NewView(m.viewModal("Bulk action", "Loading…"))
+			v.AltScreen = true
+			return v
+		}
+		v := tea.NewView(m.viewModal("Bulk action", m.bulkForm.View()))
+		v.AltScreen = true
+		return v
+	case screenBulkRun:
+		v := tea.NewView(m.viewBulk())
+		v.AltScreen = true
+		return v
+	case screenBrowse:
+		v := tea.NewView(m.viewBrowse())
+		v.AltScreen = true
+		return v
 	default:
 		v := tea.NewView("")
 		v.AltScreen = true
@@ -323,6 +425,9 @@ func (m *Model) applyFilterToTable() {
 		}
 	}
 
+	m.visibleRows = filtered
+	selecting := len(m.selected) > 0
+
 	trows := make([]table.Row, 0, len(filtered))
 	for _, r := range filtered {
 		val := r.value
@@ -335,7 +440,15 @@ func (m *Model) applyFilterToTable() {
 		} else {
 			ref = r.refKind + ":" + ref
 		}
-		trows = append(trows, table.Row{r.envVar, val, r.provider, ref})
+		name := r.envVar
+		if selecting {
+			if m.selected[r.envVar] {
+				name = "✓ " + name
+			} else {
+				name = "  " + name
+			}
+		}
+		trows = append(trows, table.Row{name, val, r.provider, ref})
 	}
 	m.secretTable.SetRows(trows)
 }
@@ -350,9 +463,15 @@ func mask(v string) string {
 	return strings.Repeat("•", 8)
 }
 
+const secretsHelp = "enter:view  e:edit  v:versions  n:new  d:delete  p:provider  space:select  a:all  b:bulk  /:filter  m:mask  esc:back  q:quit"
+
 func (m *Model) viewSecrets() string {
-	header := sectionHeaderStyle().Render(fmt.Sprintf("Environment: %s", m.selectedEnvName))
-	help := helpStyle().Render("enter:view  e:edit  n:new  d:delete  /:filter  m:mask  esc:back  q:quit")
+	title := fmt.Sprintf("Environment: %s", m.selectedEnvName)
+	if n := len(m.selected); n > 0 {
+		title += fmt.Sprintf(" (%d selected)", n)
+	}
+	header := sectionHeaderStyle().Render(title)
+	help := helpStyle().Render(secretsHelp)
 	if m.errMsg != "" {
 		help = errorStyle().Render("Error: "+m.errMsg) + "\n" + help
 	}
@@ -389,7 +508,7 @@ func (m *Model) updateSecrets(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// line between blocks. So total height is:
 		// sum(blockHeights) + (numBlocks-1).
 		headerH := lipgloss.Height(sectionHeaderStyle().Render("Environment: X"))
-		helpH := lipgloss.Height(helpStyle().Render("enter:view  e:edit  n:new  d:delete  /:filter  m:mask  esc:back  q:quit"))
+		helpH := lipgloss.Height(helpStyle().Render(secretsHelp))
 		filterVisible := m.filter.Focused() || strings.TrimSpace(m.filter.Value()) != ""
 		filterH := 0
 		if filterVisible {
@@ -431,11 +550,39 @@ func (m *Model) updateSecrets(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.filter.Blur()
 				return m, nil
 			}
+			if len(m.selected) > 0 {
+				m.selected = map[string]bool{}
+				m.applyFilterToTable()
+				return m, nil
+			}
+			if m.compareReturn {
+				return m.returnToCompare()
+			}
 			m.screen = screenEnvs
 			return m, nil
 		case "/":
 			m.filter.Focus()
 			return m, nil
+		case "space":
+			r, ok := m.selectedRow()
+			if !ok {
+				return m, nil
+			}
+			if m.selected[r.envVar] {
+				delete(m.selected, r.envVar)
+			} else {
+				m.selected[r.envVar] = true
+			}
+			m.applyFilterToTable()
+			m.secretTable.MoveDown(1)
+			return m, nil
+		case "a":
+			m.toggleSelectAllFiltered()
+			return m, nil
+		case "b":
+			return m.openBulk()
+		case "p":
+			return m.openBrowse()
 		case "m":
 			m.maskValues = !m.maskValues
 			m.applyFilterToTable()
@@ -449,21 +596,21 @@ func (m *Model) updateSecrets(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.screen = screenView
 			return m, nil
 		case "e":
+			r, ok := m.selectedRow()
+			if !ok {
+				return m, nil
+			}
+			return m.startEdit(r)
+		case "v":
 			r, ok := m.selectedRow()
 			if !ok {
 				return m, nil
 			}
 			if r.refKind != "secret-key" {
-				m.errMsg = "edit is only supported for secret-key mappings"
+				m.errMsg = "versions are only supported for secret-key mappings"
 				return m, nil
 			}
-			m.editTarget = &r
-			m.editValue = r.value
-			m.editSave = false
-			m.editForm = m.newEditForm()
-			m.editForm = m.sizeFormToModalBody(m.editForm)
-			m.screen = screenEdit
-			return m, m.editForm.Init()
+			return m.openVersions(r)
 		case "d":
 			r, ok := m.selectedRow()
 			if !ok {
@@ -473,6 +620,9 @@ func (m *Model) updateSecrets(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.errMsg = "delete is only supported for secret-key mappings"
 				return m, nil
 			}
+			if !m.editAllowed(m.selectedEnvName) {
+				return m, nil
+			}
 			m.editTarget = &r
 			m.confirmText = fmt.Sprintf("Delete provider secret '%s'?\n\nEnv var: %s\nProvider: %s", r.ref, r.envVar, r.provider)
 			m.deleteYes = false
@@ -481,25 +631,7 @@ func (m *Model) updateSecrets(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.screen = screenConfirmDelete
 			return m, m.deleteForm.Init()
 		case "n":
-			m.createEnvVar = ""
-			m.createSecretKey = ""
-			m.createValue = ""
-			m.createDesc = ""
-			m.createReplication = "global"
-			m.createLocations = nil
-			m.createAction = "create"
-			m.createSummaryTick = 0
-			m.createSummaryKey = ""
-			// Lazy-load GCP locations for region multiselect.
-			if err := m.ensureGCPLocationsLoaded(); err != nil {
-				m.errMsg = err.Error()
-				return m, nil
-			}
-			m.applyCreateDefaults()
-			m.createForm = m.newCreateForm()
-			m.createForm = m.sizeFormToModalBody(m.createForm)
-			m.screen = screenCreate
-			return m, m.createForm.Init()
+			return m.startCreate("")
 		}
 	}
 
@@ -508,18 +640,71 @@ func (m *Model) updateSecrets(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// editAllowed reports whether the policy allows edits of the environments
+// and sets the error message when it does not.
+func (m *Model) editAllowed(envNames ...string) bool {
+	if m.confirmed {
+		return true
+	}
+	for _, name := range envNames {
+		if name != "" && m.cfg.PolicyFor(name).RequireConfirm {
+			m.errMsg = fmt.Sprintf("policy requires kuba tui --confirm to edit '%s'", name)
+			return false
+		}
+	}
+	return true
+}
+
+// startEdit opens the edit form for a secret-key row.
+func (m *Model) startEdit(r secretRow) (tea.Model, tea.Cmd) {
+	if !m.editAllowed(m.selectedEnvName) {
+		return m, nil
+	}
+	if r.refKind != "secret-key" {
+		m.errMsg = "edit is only supported for secret-key mappings"
+		return m, nil
+	}
+	m.editTarget = &r
+	m.editValue = r.value
+	m.editSave = false
+	m.editForm = m.newEditForm()
+	m.editForm = m.sizeFormToModalBody(m.editForm)
+	m.screen = screenEdit
+	return m, m.editForm.Init()
+}
+
+// startCreate opens the create form, optionally prefilled with an env var name.
+func (m *Model) startCreate(envVar string) (tea.Model, tea.Cmd) {
+	if !m.editAllowed(m.selectedEnvName) {
+		return m, nil
+	}
+	m.createEnvVar = envVar
+	m.createSecretKey = ""
+	m.createValue = ""
+	m.createDesc = ""
+	m.createReplication = "global"
+	m.createLocations = nil
+	m.createAction = "create"
+	m.createSummaryTick = 0
+	m.createSummaryKey = ""
+	// Lazy-load GCP locations for region multiselect.
+	if err := m.ensureGCPLocationsLoaded(); err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	m.applyCreateDefaults()
+	m.createForm = m.newCreateForm()
+	m.createForm = m.sizeFormToModalBody(m.createForm)
+	m.screen = screenCreate
+	return m, m.createForm.Init()
+}
+
 func (m *Model) selectedRow() (secretRow, bool) {
 	i := m.secretTable.Cursor()
-	if i < 0 || i >= len(m.secretTable.Rows()) {
+	if i < 0 || i >= len(m.visibleRows) {
 		return secretRow{}, false
 	}
-	envVar := m.secretTable.Rows()[i][0]
-	for _, r := range m.allRows {
-		if r.envVar == envVar {
-			return r, true
-		}
-	}
-	return secretRow{}, false
+	return m.visibleRows[i], true
 }
 
 func (m *Model) updateView(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -605,7 +790,17 @@ func (m *Model) saveEdit(row secretRow, newValue string) error {
 		return err
 	}
 
-	return mut.UpdateSecret(row.ref, newValue)
+	if err := mut.UpdateSecret(row.ref, newValue); err != nil {
+		return err
+	}
+	m.invalidateSecret(row.provider, row.project, row.ref)
+	return nil
+}
+
+// invalidateSecret drops cached values of a secret that was changed from the
+// TUI, from the kuba cache and from a running agent
+func (m *Model) invalidateSecret(provider, project, secretKey string) {
+	secrets.InvalidateSecrets(m.cfg, m.configPath, []secrets.ChangedSecret{{Provider: provider, Project: project, SecretKey: secretKey}})
 }
 
 func (m *Model) updateCreate(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -827,6 +1022,7 @@ func (m *Model) doDelete(row secretRow) error {
 	if err := mut.DeleteSecret(row.ref, true); err != nil {
 		return err
 	}
+	m.invalidateSecret(row.provider, row.project, row.ref)
 
 	// Also remove the mapping from kuba.yaml so it doesn't reappear on refresh.
 	if err := config.RemoveEnvMapping(m.configPath, m.selectedEnvName, row.envVar); err != nil {
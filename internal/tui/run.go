@@ -6,8 +6,8 @@ import (
 	tea "charm.land/bubbletea/v2"
 )
 
-func Run(ctx context.Context, configPath string) error {
-	m, err := New(ctx, configPath)
+func Run(ctx context.Context, configPath, envName string, confirmed bool) error {
+	m, err := New(ctx, configPath, envName, confirmed)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,313 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"charm.land/bubbles/v2/table"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+)
+
+const browseHelp = "enter:map  u:unmapped only  pgup/pgdn:page  /:filter  esc:back  q:quit"
+
+// browseRow is a secret exposed by the environment's provider.
+type browseRow struct {
+	secretKey string
+	mappedAs  string // env var mapping the secret, if any
+}
+
+func newBrowseFilter() textinput.Model {
+	filter := textinput.New()
+	filter.Placeholder = "Filter provider secrets…"
+	filter.CharLimit = 256
+	filter.Prompt = "/ "
+	filter.SetStyles(vhsSecretsFilterStyles())
+	return filter
+}
+
+// openBrowse switches to the provider browser for the selected environment
+// and starts listing the provider's secrets.
+func (m *Model) openBrowse() (tea.Model, tea.Cmd) {
+	if m.selectedEnv == nil {
+		return m, nil
+	}
+	m.browseNames = nil
+	m.browseLoading = true
+	m.browseErr = ""
+	m.browseUnmappedOnly = false
+	m.browseFilter = newBrowseFilter()
+	m.browseTable = table.New(
+		table.WithColumns(browseTableColumns(0)),
+		table.WithFocused(true),
+		table.WithStyles(secretsTableStyles()),
+	)
+	m.sizeBrowseTable()
+	m.screen = screenBrowse
+
+	provider, project := m.selectedEnv.Provider, m.selectedEnv.Project
+	return m, tea.Batch(m.spinner.Tick, func() tea.Msg {
+		names, err := m.listProviderSecrets(provider, project)
+		return browseLoadedMsg{names: names, err: err}
+	})
+}
+
+func (m *Model) listProviderSecrets(provider, project string) ([]string, error) {
+	factory := secrets.NewSecretManagerFactory()
+	sm, err := factory.CreateSecretManager(m.ctx, provider, project)
+	if err != nil {
+		return nil, err
+	}
+	defer sm.Close()
+
+	lister, err := secrets.AsLister(sm)
+	if err != nil {
+		return nil, err
+	}
+	names, err := lister.ListSecrets()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// browseRows returns the provider secrets matching the filter, with the env
+// var that maps each of them in the selected environment.
+func (m *Model) browseRows() []browseRow {
+	mapped := map[string]string{}
+	for name, item := range m.selectedEnv.Env {
+		if item.SecretKey == "" {
+			continue
+		}
+		// Only mappings resolved through the environment's provider count.
+		if (item.Provider != "" && item.Provider != m.selectedEnv.Provider) ||
+			(item.Project != "" && item.Project != m.selectedEnv.Project) {
+			continue
+		}
+		mapped[item.SecretKey] = name
+	}
+
+	q := strings.TrimSpace(strings.ToLower(m.browseFilter.Value()))
+	rows := make([]browseRow, 0, len(m.browseNames))
+	for _, name := range m.browseNames {
+		if q != "" && !strings.Contains(strings.ToLower(name), q) {
+			continue
+		}
+		r := browseRow{secretKey: name, mappedAs: mapped[name]}
+		if m.browseUnmappedOnly && r.mappedAs != "" {
+			continue
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+func (m *Model) refreshBrowseTable() {
+	rows := m.browseRows()
+	trows := make([]table.Row, 0, len(rows))
+	for _, r := range rows {
+		mark, mappedAs := " ", ""
+		if r.mappedAs != "" {
+			mark, mappedAs = "●", r.mappedAs
+		}
+		trows = append(trows, table.Row{mark, r.secretKey, mappedAs})
+	}
+	m.browseTable.SetRows(trows)
+	if m.browseTable.Cursor() < 0 && len(trows) > 0 {
+		m.browseTable.SetCursor(0)
+	}
+}
+
+func browseTableColumns(innerW int) []table.Column {
+	markW := 1
+	// Cell padding and column gaps, see setSecretTableColumns.
+	overhead := 3*2 + 2
+	avail := clampMin(innerW-overhead-markW, 20)
+	keyW := avail * 3 / 5
+	return []table.Column{
+		{Title: "", Width: markW},
+		{Title: "Provider secret", Width: keyW},
+		{Title: "Mapped as", Width: avail - keyW},
+	}
+}
+
+func (m *Model) sizeBrowseTable() {
+	innerW, innerH := panelInnerSize(m.winW, m.winH, panelStyle())
+	if innerW > 0 && innerH > 0 {
+		headerH := lipgloss.Height(sectionHeaderStyle().Render("Provider: X"))
+		helpH := lipgloss.Height(helpStyle().Render(browseHelp))
+		filterVisible := m.browseFilter.Focused() || strings.TrimSpace(m.browseFilter.Value()) != ""
+		// header, status line, table and help (plus the filter line) separated
+		// by blank lines
+		numBlocks, filterH := 4, 0
+		if filterVisible {
+			numBlocks, filterH = 5, 1
+		}
+		nonTableH := headerH + 1 + filterH + helpH + (numBlocks - 1)
+		m.browseTable.SetWidth(innerW)
+		m.browseTable.SetColumns(browseTableColumns(innerW))
+		m.browseTable.SetHeight(clampMin(innerH-nonTableH, 1))
+		m.browseFilter.SetWidth(clamp(clampMin(innerW, 1), 1, 60))
+	}
+	m.refreshBrowseTable()
+}
+
+func (m *Model) viewBrowse() string {
+	title := fmt.Sprintf("Provider: %s (%s)", m.selectedEnv.Provider, m.selectedEnvName)
+	if m.selectedEnv.Project != "" {
+		title = fmt.Sprintf("Provider: %s/%s (%s)", m.selectedEnv.Provider, m.selectedEnv.Project, m.selectedEnvName)
+	}
+	header := sectionHeaderStyle().Render(title)
+
+	var status string
+	switch {
+	case m.browseLoading:
+		status = strings.TrimSpace(m.spinner.View() + " Listing secrets…")
+	case m.browseErr != "":
+		status = errorStyle().Render("Error: " + m.browseErr)
+	default:
+		rows := len(m.browseTable.Rows())
+		mapped := 0
+		for _, r := range m.browseTable.Rows() {
+			if r[2] != "" {
+				mapped++
+			}
+		}
+		pageSize := clampMin(m.browseTable.Height(), 1)
+		pages := clampMin((rows+pageSize-1)/pageSize, 1)
+		page := clampMin(m.browseTable.Cursor(), 0)/pageSize + 1
+		status = fmt.Sprintf("%d secret(s), %d mapped (●)  page %d/%d", rows, mapped, page, pages)
+		if m.browseUnmappedOnly {
+			status += "  unmapped only"
+		}
+	}
+
+	help := helpStyle().Render(browseHelp)
+	if m.errMsg != "" {
+		help = errorStyle().Render("Error: "+m.errMsg) + "\n" + help
+	} else if m.browseNotice != "" {
+		help = m.browseNotice + "\n" + help
+	}
+
+	parts := []string{header, status}
+	if m.browseFilter.Focused() {
+		parts = append(parts, m.browseFilter.View())
+	} else if v := strings.TrimSpace(m.browseFilter.Value()); v != "" {
+		parts = append(parts, "/ "+v)
+	}
+	parts = append(parts, m.browseTable.View(), help)
+
+	box := fitPanelToWindow(panelStyle(), m.winW, m.winH)
+	return box.Render(strings.Join(parts, "\n\n"))
+}
+
+func (m *Model) updateBrowse(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.sizeBrowseTable()
+		return m, nil
+	case browseLoadedMsg:
+		m.browseLoading = false
+		if msg.err != nil {
+			m.browseErr = msg.err.Error()
+			return m, nil
+		}
+		m.browseNames = msg.names
+		m.refreshBrowseTable()
+		return m, nil
+	case tea.KeyMsg:
+		m.errMsg = ""
+		m.browseNotice = ""
+		if m.browseFilter.Focused() {
+			var cmd tea.Cmd
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "esc", "enter":
+				m.browseFilter.Blur()
+				m.sizeBrowseTable()
+				return m, nil
+			}
+			m.browseFilter, cmd = m.browseFilter.Update(msg)
+			m.browseTable.SetCursor(0)
+			m.refreshBrowseTable()
+			return m, cmd
+		}
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.screen = screenSecrets
+			return m, nil
+		case "/":
+			m.browseFilter.Focus()
+			m.sizeBrowseTable()
+			return m, nil
+		case "u":
+			m.browseUnmappedOnly = !m.browseUnmappedOnly
+			m.browseTable.SetCursor(0)
+			m.refreshBrowseTable()
+			return m, nil
+		case "enter":
+			return m.mapBrowsedSecret()
+		}
+	default:
+		if m.browseLoading {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	m.browseTable, cmd = m.browseTable.Update(msg)
+	return m, cmd
+}
+
+// mapBrowsedSecret adds a secret-key mapping for the selected provider
+// secret, using the proposed env var name.
+func (m *Model) mapBrowsedSecret() (tea.Model, tea.Cmd) {
+	rows := m.browseRows()
+	i := m.browseTable.Cursor()
+	if i < 0 || i >= len(rows) {
+		return m, nil
+	}
+	r := rows[i]
+	if r.mappedAs != "" {
+		m.errMsg = fmt.Sprintf("'%s' is already mapped as %s", r.secretKey, r.mappedAs)
+		return m, nil
+	}
+
+	if !m.editAllowed(m.selectedEnvName) {
+		return m, nil
+	}
+
+	envVar := secrets.ProposeEnvVarName(r.secretKey)
+	if _, taken := m.selectedEnv.Env[envVar]; taken {
+		m.errMsg = fmt.Sprintf("%s is already defined in '%s'", envVar, m.selectedEnvName)
+		return m, nil
+	}
+	if err := config.AddOrUpdateEnvSecretKeyMapping(m.configPath, m.selectedEnvName, envVar, r.secretKey); err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+
+	if cfg, err := config.LoadKubaConfig(m.configPath); err == nil {
+		m.cfg = cfg
+		if env, err := m.cfg.GetEnvironment(m.selectedEnvName); err == nil {
+			m.selectedEnv = env
+		}
+	}
+	if err := m.reloadSecrets(); err != nil {
+		m.errMsg = err.Error()
+	}
+	m.browseNotice = fmt.Sprintf("Mapped %s -> %s", envVar, r.secretKey)
+	m.refreshBrowseTable()
+	return m, nil
+}
@@ -0,0 +1,398 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"charm.land/bubbles/v2/table"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/huh/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+)
+
+const (
+	bulkRunningHelp = "esc:cancel"
+	bulkDoneHelp    = "enter/esc:back  q:quit"
+)
+
+// selectedRows returns the selected rows in table order.
+func (m *Model) selectedRows() []secretRow {
+	rows := make([]secretRow, 0, len(m.selected))
+	for _, r := range m.allRows {
+		if m.selected[r.envVar] {
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// toggleSelectAllFiltered selects every row matching the filter, or clears
+// the selection of those rows when all of them are selected already.
+func (m *Model) toggleSelectAllFiltered() {
+	allSelected := len(m.visibleRows) > 0
+	for _, r := range m.visibleRows {
+		if !m.selected[r.envVar] {
+			allSelected = false
+			break
+		}
+	}
+	for _, r := range m.visibleRows {
+		if allSelected {
+			delete(m.selected, r.envVar)
+		} else {
+			m.selected[r.envVar] = true
+		}
+	}
+	m.applyFilterToTable()
+}
+
+func (m *Model) newBulkForm(count int) *huh.Form {
+	var targets []string
+	for name := range m.cfg.Environments {
+		if name != m.selectedEnvName {
+			targets = append(targets, name)
+		}
+	}
+	sort.Strings(targets)
+
+	actions := []huh.Option[string]{
+		huh.NewOption("Delete mapping only", bulkUnmap),
+		huh.NewOption("Delete mapping and provider secret", bulkDelete),
+		huh.NewOption("Move to another environment", bulkMove),
+		huh.NewOption("Copy to another environment/provider", bulkCopy),
+		huh.NewOption("Export as dotenv", bulkExport),
+	}
+
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title(fmt.Sprintf("Action for %d selected secret(s)", count)).
+				Options(actions...).
+				Value(&m.bulkAction),
+		),
+		huh.NewGroup(
+			huh.NewSelect[string]().
+				Title("Target environment").
+				Description("Secrets are written into the provider of the target environment if it differs.").
+				Options(huh.NewOptions(targets...)...).
+				Value(&m.bulkTargetEnv).
+				Validate(func(s string) error {
+					if s == "" {
+						return fmt.Errorf("no other environment to target")
+					}
+					return nil
+				}),
+		).WithHideFunc(func() bool {
+			return m.bulkAction != bulkMove && m.bulkAction != bulkCopy
+		}),
+		huh.NewGroup(
+			huh.NewInput().
+				Title("Export file").
+				Value(&m.bulkExportPath).
+				Validate(func(s string) error {
+					if strings.TrimSpace(s) == "" {
+						return fmt.Errorf("file is required")
+					}
+					return nil
+				}),
+		).WithHideFunc(func() bool {
+			return m.bulkAction != bulkExport
+		}),
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title("Proceed?").
+				DescriptionFunc(func() string {
+					return m.bulkSummaryText(count)
+				}, &m.bulkAction).
+				Affirmative("Run").
+				Negative("Cancel").
+				Value(&m.bulkConfirm),
+		),
+	).WithTheme(huh.ThemeFunc(themeVHSEra))
+}
+
+func (m *Model) bulkSummaryText(count int) string {
+	switch m.bulkAction {
+	case bulkUnmap:
+		return fmt.Sprintf("Remove %d mapping(s) from '%s'. Provider secrets are kept.", count, m.selectedEnvName)
+	case bulkDelete:
+		return fmt.Sprintf("Delete %d provider secret(s) and remove their mappings from '%s'.", count, m.selectedEnvName)
+	case bulkMove:
+		return fmt.Sprintf("Move %d mapping(s) from '%s' to '%s'.", count, m.selectedEnvName, m.bulkTargetEnv)
+	case bulkCopy:
+		return fmt.Sprintf("Copy %d secret(s) from '%s' to '%s'.", count, m.selectedEnvName, m.bulkTargetEnv)
+	case bulkExport:
+		return fmt.Sprintf("Write %d value(s) in plain text to %s.", count, m.bulkExportPath)
+	}
+	return ""
+}
+
+func (m *Model) openBulk() (tea.Model, tea.Cmd) {
+	rows := m.selectedRows()
+	if len(rows) == 0 {
+		m.errMsg = "select secrets with space first (a selects all)"
+		return m, nil
+	}
+	m.bulkAction = bulkUnmap
+	m.bulkTargetEnv = ""
+	m.bulkExportPath = ".env." + m.selectedEnvName
+	m.bulkConfirm = false
+	m.bulkForm = m.newBulkForm(len(rows))
+	m.bulkForm = m.sizeFormToModalBody(m.bulkForm)
+	m.screen = screenBulkForm
+	return m, m.bulkForm.Init()
+}
+
+func (m *Model) updateBulkForm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if km, ok := msg.(tea.KeyMsg); ok {
+		switch km.String() {
+		case "esc":
+			m.screen = screenSecrets
+			m.bulkForm = nil
+			return m, nil
+		case "up", "down":
+			if m.bulkForm != nil {
+				if cmd, ok := formArrowNavCmd(m.bulkForm, km.String()); ok {
+					return m, cmd
+				}
+			}
+		}
+	}
+
+	if m.bulkForm == nil {
+		return m.openBulk()
+	}
+
+	var cmd tea.Cmd
+	var mdl huh.Model
+	mdl, cmd = m.bulkForm.Update(msg)
+	if f, ok := mdl.(*huh.Form); ok {
+		m.bulkForm = f
+	}
+
+	if m.bulkForm.State == huh.StateCompleted {
+		m.bulkForm = nil
+		if !m.bulkConfirm {
+			m.screen = screenSecrets
+			return m, nil
+		}
+		var edited []string
+		switch m.bulkAction {
+		case bulkExport:
+		case bulkCopy:
+			edited = []string{m.bulkTargetEnv}
+		case bulkMove:
+			edited = []string{m.selectedEnvName, m.bulkTargetEnv}
+		default:
+			edited = []string{m.selectedEnvName}
+		}
+		if !m.editAllowed(edited...) {
+			m.screen = screenSecrets
+			return m, nil
+		}
+		return m.startBulkJob()
+	}
+
+	return m, cmd
+}
+
+func (m *Model) startBulkJob() (tea.Model, tea.Cmd) {
+	rows := m.selectedRows()
+	ctx, cancel := context.WithCancel(m.ctx)
+	job := &bulkJob{
+		action:     m.bulkAction,
+		rows:       rows,
+		results:    make([]bulkResult, len(rows)),
+		configPath: m.configPath,
+		envName:    m.selectedEnvName,
+		targetEnv:  m.bulkTargetEnv,
+		exportPath: strings.TrimSpace(m.bulkExportPath),
+		ctx:        ctx,
+		cancel:     cancel,
+		managers:   map[string]secrets.SecretManager{},
+	}
+	for i := range job.results {
+		job.results[i] = bulkResult{status: bulkPending}
+	}
+	m.bulkJob = job
+
+	m.bulkTable = table.New(
+		table.WithColumns(bulkTableColumns(0)),
+		table.WithFocused(true),
+		table.WithStyles(secretsTableStyles()),
+	)
+	m.sizeBulkTable()
+	m.screen = screenBulkRun
+	return m, tea.Batch(m.spinner.Tick, m.bulkStepCmd())
+}
+
+func (m *Model) bulkStepCmd() tea.Cmd {
+	job := m.bulkJob
+	if job == nil || job.next >= len(job.rows) {
+		return nil
+	}
+	i := job.next
+	row := job.rows[i]
+	return func() tea.Msg {
+		return bulkStepMsg{index: i, result: m.runBulkStep(job, row)}
+	}
+}
+
+// finishBulkJob marks rows that were not processed as cancelled, writes the
+// export and reloads the configuration and secrets.
+func (m *Model) finishBulkJob() {
+	job := m.bulkJob
+	cancelled := job.ctx.Err() != nil
+	for i := range job.results {
+		if job.results[i].status == bulkPending {
+			job.results[i] = bulkResult{status: bulkCancelled}
+		}
+	}
+	if job.action == bulkExport {
+		if cancelled {
+			for i := range job.results {
+				if job.results[i].status == bulkOK {
+					job.results[i] = bulkResult{status: bulkCancelled, detail: "nothing was written"}
+				}
+			}
+		} else if err := job.writeExport(); err != nil {
+			for i := range job.results {
+				job.results[i] = bulkResult{status: bulkFailed, detail: err.Error()}
+			}
+		}
+	}
+	job.close()
+	job.done = true
+
+	if job.action != bulkExport {
+		if cfg, err := config.LoadKubaConfig(m.configPath); err == nil {
+			m.cfg = cfg
+			if env, err := m.cfg.GetEnvironment(m.selectedEnvName); err == nil {
+				m.selectedEnv = env
+			}
+		}
+		m.selected = map[string]bool{}
+		_ = m.reloadSecrets()
+	}
+}
+
+func (m *Model) setBulkRows() {
+	job := m.bulkJob
+	rows := make([]table.Row, 0, len(job.rows))
+	for i, r := range job.rows {
+		res := job.results[i]
+		rows = append(rows, table.Row{r.envVar, res.status, res.detail})
+	}
+	m.bulkTable.SetRows(rows)
+}
+
+func bulkTableColumns(innerW int) []table.Column {
+	envW, statusW := 28, 10
+	// Cell padding and column gaps, see setSecretTableColumns.
+	overhead := 3*2 + 2
+	detailW := clampMin(innerW-overhead-envW-statusW, 16)
+	return []table.Column{
+		{Title: "Env Var", Width: envW},
+		{Title: "Result", Width: statusW},
+		{Title: "Details", Width: detailW},
+	}
+}
+
+func (m *Model) sizeBulkTable() {
+	innerW, innerH := panelInnerSize(m.winW, m.winH, panelStyle())
+	if innerW > 0 && innerH > 0 {
+		headerH := lipgloss.Height(sectionHeaderStyle().Render("Bulk: X"))
+		helpH := lipgloss.Height(helpStyle().Render(bulkDoneHelp))
+		// header, progress line, table and help separated by blank lines
+		nonTableH := headerH + 1 + helpH + 3
+		m.bulkTable.SetWidth(innerW)
+		m.bulkTable.SetColumns(bulkTableColumns(innerW))
+		m.bulkTable.SetHeight(clampMin(innerH-nonTableH, 1))
+	}
+	m.setBulkRows()
+}
+
+func (m *Model) viewBulk() string {
+	job := m.bulkJob
+	header := sectionHeaderStyle().Render(fmt.Sprintf("Bulk %s: %s", job.action, m.selectedEnvName))
+
+	var progress, help string
+	if job.done {
+		c := job.counts()
+		progress = fmt.Sprintf("Done: %d succeeded, %d skipped, %d failed, %d cancelled",
+			c[bulkOK], c[bulkSkipped], c[bulkFailed], c[bulkCancelled])
+		if job.action == bulkExport && c[bulkOK] > 0 {
+			progress += " (written to " + job.exportPath + ")"
+		}
+		help = helpStyle().Render(bulkDoneHelp)
+	} else {
+		status := fmt.Sprintf("[%d/%d] %s…", job.next+1, len(job.rows), job.rows[job.next].envVar)
+		if job.ctx.Err() != nil {
+			status = "Cancelling…"
+		}
+		progress = strings.TrimSpace(m.spinner.View() + " " + status)
+		help = helpStyle().Render(bulkRunningHelp)
+	}
+
+	content := strings.Join([]string{header, progress, m.bulkTable.View(), help}, "\n\n")
+	box := fitPanelToWindow(panelStyle(), m.winW, m.winH)
+	return box.Render(content)
+}
+
+func (m *Model) updateBulkRun(msg tea.Msg) (tea.Model, tea.Cmd) {
+	job := m.bulkJob
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.sizeBulkTable()
+		return m, nil
+	case bulkStepMsg:
+		job.results[msg.index] = msg.result
+		job.next = msg.index + 1
+		if job.next >= len(job.rows) || job.ctx.Err() != nil {
+			m.finishBulkJob()
+			m.setBulkRows()
+			return m, nil
+		}
+		m.setBulkRows()
+		m.bulkTable.SetCursor(job.next)
+		return m, m.bulkStepCmd()
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			if !job.done {
+				job.cancel()
+			}
+			return m, tea.Quit
+		}
+		if !job.done {
+			if msg.String() == "esc" {
+				// The running step notices the cancelled context; remaining rows
+				// are marked as cancelled once it returns.
+				job.cancel()
+			}
+			return m, nil
+		}
+		switch msg.String() {
+		case "q":
+			return m, tea.Quit
+		case "esc", "enter":
+			m.bulkJob = nil
+			m.screen = screenSecrets
+			return m, nil
+		}
+	default:
+		if !job.done {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	m.bulkTable, cmd = m.bulkTable.Update(msg)
+	return m, cmd
+}
@@ -50,6 +50,15 @@ func (m *Model) updateBusy(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		_ = m.reloadSecrets()
 		return m, nil
+	case promoteDoneMsg:
+		m.busyText = ""
+		m.screen = screenVersions
+		if msg.err != nil {
+			return m.openError(screenVersions, "Promote failed", msg.err.Error())
+		}
+		m.versionPreviewID = ""
+		m.versionPreview = ""
+		return m, m.reloadAfterPromote()
 	case tea.KeyMsg:
 		// Prevent accidental interaction while busy.
 		if msg.String() == "ctrl+c" {
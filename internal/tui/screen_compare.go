@@ -0,0 +1,508 @@
+package tui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"charm.land/bubbles/v2/table"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/huh/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+)
+
+const compareHelp = "←/→:env  enter:jump  r:resolve values  x:drift only  /:filter  esc:back  q:quit"
+
+// compareCell is the mapping of an env var in one environment.
+type compareCell struct {
+	present  bool
+	provider string
+	project  string
+	refKind  string
+	ref      string
+}
+
+// identity identifies where a value comes from, ignoring the value itself.
+func (c compareCell) identity() string {
+	return strings.Join([]string{c.provider, c.project, c.refKind, c.ref}, "|")
+}
+
+func (c compareCell) label() string {
+	switch {
+	case !c.present:
+		return "—"
+	case c.refKind == "secret-path":
+		return "path:" + c.ref
+	case c.refKind == "value":
+		return "value"
+	default:
+		return c.ref
+	}
+}
+
+// compareRow is a single env var across all compared environments.
+type compareRow struct {
+	envVar string
+	cells  []compareCell
+}
+
+func (m *Model) newCompareSelectForm() *huh.Form {
+	names := make([]string, 0, len(m.cfg.Environments))
+	for name := range m.cfg.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return huh.NewForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title("Environments to compare").
+				Description("space to toggle, enter to compare").
+				Options(huh.NewOptions(names...)...).
+				Value(&m.compareEnvNames).
+				Validate(func(v []string) error {
+					if len(v) < 2 {
+						return fmt.Errorf("select at least two environments")
+					}
+					return nil
+				}),
+		),
+	).WithTheme(huh.ThemeFunc(themeVHSEra))
+}
+
+func (m *Model) openCompareSelect() (tea.Model, tea.Cmd) {
+	m.compareForm = m.newCompareSelectForm()
+	m.compareForm = m.sizeFormToModalBody(m.compareForm)
+	m.screen = screenCompareSelect
+	return m, m.compareForm.Init()
+}
+
+func (m *Model) updateCompareSelect(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if km, ok := msg.(tea.KeyMsg); ok && km.String() == "esc" {
+		m.screen = screenEnvs
+		m.compareForm = nil
+		return m, nil
+	}
+
+	if m.compareForm == nil {
+		return m.openCompareSelect()
+	}
+
+	var cmd tea.Cmd
+	var mdl huh.Model
+	mdl, cmd = m.compareForm.Update(msg)
+	if f, ok := mdl.(*huh.Form); ok {
+		m.compareForm = f
+	}
+
+	if m.compareForm.State == huh.StateCompleted {
+		m.compareForm = nil
+		m.compareHashes = nil
+		m.compareCol = 0
+		m.compareDriftOnly = false
+		m.compareFilter = newCompareFilter()
+		m.compareTable = table.New(
+			table.WithRows(nil),
+			table.WithFocused(true),
+			table.WithStyles(secretsTableStyles()),
+		)
+		if err := m.buildCompareRows(); err != nil {
+			return m.openError(screenEnvs, "Compare failed", err.Error())
+		}
+		m.screen = screenCompare
+		m.sizeCompareTable()
+		return m, nil
+	}
+
+	return m, cmd
+}
+
+func newCompareFilter() textinput.Model {
+	filter := textinput.New()
+	filter.Placeholder = "Filter env vars…"
+	filter.CharLimit = 256
+	filter.Prompt = "/ "
+	filter.SetStyles(vhsSecretsFilterStyles())
+	return filter
+}
+
+// buildCompareRows collects the mappings of all compared environments.
+func (m *Model) buildCompareRows() error {
+	byVar := map[string][]compareCell{}
+	for i, name := range m.compareEnvNames {
+		env, err := m.cfg.GetEnvironment(name)
+		if err != nil {
+			return err
+		}
+		for _, it := range env.GetEnvItems() {
+			cells, ok := byVar[it.EnvironmentVariable]
+			if !ok {
+				cells = make([]compareCell, len(m.compareEnvNames))
+				byVar[it.EnvironmentVariable] = cells
+			}
+			cell := compareCell{
+				present:  true,
+				provider: it.Provider,
+				project:  it.Project,
+				refKind:  "value",
+			}
+			if cell.provider == "" {
+				cell.provider = env.Provider
+			}
+			if cell.project == "" {
+				cell.project = env.Project
+			}
+			if it.SecretKey != "" {
+				cell.refKind, cell.ref = "secret-key", it.SecretKey
+			} else if it.SecretPath != "" {
+				cell.refKind, cell.ref = "secret-path", it.SecretPath
+			}
+			cells[i] = cell
+		}
+	}
+
+	rows := make([]compareRow, 0, len(byVar))
+	for envVar, cells := range byVar {
+		rows = append(rows, compareRow{envVar: envVar, cells: cells})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].envVar < rows[j].envVar })
+	m.compareRows = rows
+	return nil
+}
+
+// valueGroups assigns a letter to every distinct value hash of a row, so equal
+// values can be recognized without revealing them. Cells without a resolved
+// value get an empty group.
+func (m *Model) valueGroups(r compareRow) []string {
+	groups := make([]string, len(r.cells))
+	if m.compareHashes == nil {
+		return groups
+	}
+	letters := map[string]string{}
+	for i, name := range m.compareEnvNames {
+		if !r.cells[i].present {
+			continue
+		}
+		h, ok := m.compareHashes[name][r.envVar]
+		if !ok {
+			continue
+		}
+		if _, seen := letters[h]; !seen {
+			letters[h] = string(rune('A' + len(letters)%26))
+		}
+		groups[i] = letters[h]
+	}
+	return groups
+}
+
+// driftOf describes how a row differs between the compared environments.
+func (m *Model) driftOf(r compareRow) string {
+	var drift []string
+	identities := map[string]bool{}
+	missing := false
+	for _, c := range r.cells {
+		if !c.present {
+			missing = true
+			continue
+		}
+		identities[c.identity()] = true
+	}
+	if missing {
+		drift = append(drift, "missing")
+	}
+	if len(identities) > 1 {
+		drift = append(drift, "ref")
+	}
+	groups := map[string]bool{}
+	for _, g := range m.valueGroups(r) {
+		if g != "" {
+			groups[g] = true
+		}
+	}
+	if len(groups) > 1 {
+		drift = append(drift, "value")
+	}
+	if len(drift) == 0 {
+		return "ok"
+	}
+	return strings.Join(drift, ",")
+}
+
+func (m *Model) visibleCompareRows() []compareRow {
+	q := strings.TrimSpace(strings.ToLower(m.compareFilter.Value()))
+	rows := make([]compareRow, 0, len(m.compareRows))
+	for _, r := range m.compareRows {
+		if q != "" && !strings.Contains(strings.ToLower(r.envVar), q) {
+			continue
+		}
+		if m.compareDriftOnly && m.driftOf(r) == "ok" {
+			continue
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+func (m *Model) refreshCompareTable() {
+	innerW, _ := panelInnerSize(m.winW, m.winH, panelStyle())
+	// Rows must not have more cells than there are columns while swapping
+	// columns, so clear them first and restore the cursor afterwards.
+	cursor := m.compareTable.Cursor()
+	m.compareTable.SetRows(nil)
+	m.compareTable.SetColumns(m.compareColumns(innerW))
+
+	visible := m.visibleCompareRows()
+	trows := make([]table.Row, 0, len(visible))
+	for _, r := range visible {
+		groups := m.valueGroups(r)
+		row := table.Row{r.envVar}
+		for i, c := range r.cells {
+			label := c.label()
+			if groups[i] != "" {
+				label += " [" + groups[i] + "]"
+			}
+			row = append(row, label)
+		}
+		row = append(row, m.driftOf(r))
+		trows = append(trows, row)
+	}
+	m.compareTable.SetRows(trows)
+	m.compareTable.SetCursor(cursor)
+}
+
+func (m *Model) compareColumns(innerW int) []table.Column {
+	n := len(m.compareEnvNames)
+	cols := n + 2
+	// Cell padding and column gaps, see setSecretTableColumns.
+	overhead := cols*2 + cols - 1
+	envVarW, driftW := 24, 16
+	envW := 12
+	if innerW > 0 && n > 0 {
+		envW = clampMin((innerW-overhead-envVarW-driftW)/n, 8)
+	}
+
+	columns := []table.Column{{Title: "Env Var", Width: envVarW}}
+	for i, name := range m.compareEnvNames {
+		title := name
+		if i == m.compareCol {
+			title = "▸ " + name
+		}
+		columns = append(columns, table.Column{Title: title, Width: envW})
+	}
+	return append(columns, table.Column{Title: "Drift", Width: driftW})
+}
+
+func (m *Model) sizeCompareTable() {
+	innerW, innerH := panelInnerSize(m.winW, m.winH, panelStyle())
+	if innerW <= 0 || innerH <= 0 {
+		m.refreshCompareTable()
+		return
+	}
+	headerH := lipgloss.Height(sectionHeaderStyle().Render("Compare: X"))
+	helpH := lipgloss.Height(helpStyle().Render(compareHelp))
+	filterVisible := m.compareFilter.Focused() || strings.TrimSpace(m.compareFilter.Value()) != ""
+	numBlocks, filterH := 3, 0
+	if filterVisible {
+		numBlocks, filterH = 4, 1
+	}
+	nonTableH := headerH + filterH + helpH + (numBlocks - 1)
+	m.compareTable.SetWidth(innerW)
+	m.compareTable.SetHeight(clampMin(innerH-nonTableH, 1))
+	m.compareFilter.SetWidth(clamp(clampMin(innerW, 1), 1, 60))
+	m.refreshCompareTable()
+}
+
+func (m *Model) viewCompare() string {
+	title := "Compare: " + strings.Join(m.compareEnvNames, " / ")
+	if m.compareDriftOnly {
+		title += " (drift only)"
+	}
+	header := sectionHeaderStyle().Render(title)
+
+	help := helpStyle().Render(compareHelp)
+	switch {
+	case m.compareResolving:
+		help = strings.TrimSpace(m.spinner.View()+" Resolving values…") + "\n" + help
+	case m.errMsg != "":
+		help = errorStyle().Render("Error: "+m.errMsg) + "\n" + help
+	case m.compareHashes != nil:
+		help = helpStyle().Render("Values resolved: cells with the same letter have equal values.") + "\n" + help
+	}
+
+	parts := []string{header}
+	if m.compareFilter.Focused() {
+		parts = append(parts, m.compareFilter.View())
+	} else if v := strings.TrimSpace(m.compareFilter.Value()); v != "" {
+		parts = append(parts, "/ "+v)
+	}
+	parts = append(parts, m.compareTable.View(), help)
+
+	box := fitPanelToWindow(panelStyle(), m.winW, m.winH)
+	return box.Render(strings.Join(parts, "\n\n"))
+}
+
+func (m *Model) updateCompare(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.sizeCompareTable()
+		return m, nil
+	case compareResolvedMsg:
+		m.compareResolving = false
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, nil
+		}
+		m.compareHashes = msg.hashes
+		m.refreshCompareTable()
+		return m, nil
+	case tea.KeyMsg:
+		if m.errMsg != "" {
+			m.errMsg = ""
+		}
+		if m.compareFilter.Focused() {
+			var cmd tea.Cmd
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "esc", "enter":
+				m.compareFilter.Blur()
+				m.sizeCompareTable()
+				return m, nil
+			}
+			m.compareFilter, cmd = m.compareFilter.Update(msg)
+			m.refreshCompareTable()
+			return m, cmd
+		}
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.screen = screenEnvs
+			return m, nil
+		case "/":
+			m.compareFilter.Focus()
+			m.sizeCompareTable()
+			return m, nil
+		case "left", "h":
+			if m.compareCol > 0 {
+				m.compareCol--
+				m.refreshCompareTable()
+			}
+			return m, nil
+		case "right", "l":
+			if m.compareCol < len(m.compareEnvNames)-1 {
+				m.compareCol++
+				m.refreshCompareTable()
+			}
+			return m, nil
+		case "x":
+			m.compareDriftOnly = !m.compareDriftOnly
+			m.refreshCompareTable()
+			return m, nil
+		case "r":
+			if m.compareResolving {
+				return m, nil
+			}
+			m.compareResolving = true
+			return m, tea.Batch(m.spinner.Tick, m.resolveCompareCmd())
+		case "enter":
+			return m.jumpToCompareCell()
+		}
+	default:
+		if m.compareResolving {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	m.compareTable, cmd = m.compareTable.Update(msg)
+	return m, cmd
+}
+
+// resolveCompareCmd resolves the values of all compared environments and
+// keeps only their hashes.
+func (m *Model) resolveCompareCmd() tea.Cmd {
+	names := append([]string(nil), m.compareEnvNames...)
+	return func() tea.Msg {
+		factory := secrets.NewSecretManagerFactory()
+		hashes := make(map[string]map[string]string, len(names))
+		for _, name := range names {
+			env, err := m.cfg.GetEnvironment(name)
+			if err != nil {
+				return compareResolvedMsg{err: err}
+			}
+			values, err := factory.GetSecretsForEnvironmentWithCache(m.ctx, env, m.configPath, name)
+			if err != nil {
+				return compareResolvedMsg{err: fmt.Errorf("failed to resolve '%s': %w", name, err)}
+			}
+			hashes[name] = make(map[string]string, len(values))
+			for envVar, value := range values {
+				sum := sha256.Sum256([]byte(value))
+				hashes[name][envVar] = hex.EncodeToString(sum[:])
+			}
+		}
+		return compareResolvedMsg{hashes: hashes}
+	}
+}
+
+// jumpToCompareCell opens the selected cell's environment on its row and
+// starts editing it, or creating it when the mapping is missing.
+func (m *Model) jumpToCompareCell() (tea.Model, tea.Cmd) {
+	visible := m.visibleCompareRows()
+	i := m.compareTable.Cursor()
+	if i < 0 || i >= len(visible) || m.compareCol >= len(m.compareEnvNames) {
+		return m, nil
+	}
+	row := visible[i]
+	cell := row.cells[m.compareCol]
+
+	m.compareReturn = true
+	mdl, cmd := m.openEnv(m.compareEnvNames[m.compareCol])
+	if m.screen != screenSecrets {
+		m.compareReturn = false
+		return mdl, cmd
+	}
+	if !cell.present {
+		return m.startCreate(row.envVar)
+	}
+
+	for idx, r := range m.visibleRows {
+		if r.envVar == row.envVar {
+			m.secretTable.SetCursor(idx)
+			break
+		}
+	}
+	if cell.refKind != "secret-key" {
+		return m, cmd
+	}
+	r, ok := m.selectedRow()
+	if !ok {
+		return m, cmd
+	}
+	return m.startEdit(r)
+}
+
+// returnToCompare goes back to the comparison after jumping to a cell. The
+// mappings are rebuilt since they may have changed and resolved values are
+// dropped as they may be outdated.
+func (m *Model) returnToCompare() (tea.Model, tea.Cmd) {
+	m.compareReturn = false
+	if cfg, err := config.LoadKubaConfig(m.configPath); err == nil {
+		m.cfg = cfg
+	}
+	m.compareHashes = nil
+	if err := m.buildCompareRows(); err != nil {
+		m.errMsg = err.Error()
+	}
+	m.screen = screenCompare
+	m.sizeCompareTable()
+	return m, nil
+}
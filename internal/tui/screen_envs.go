@@ -1,9 +1,17 @@
 package tui
 
 import (
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/list"
 	tea "charm.land/bubbletea/v2"
 )
 
+func envListHelpKeys() []key.Binding {
+	return []key.Binding{
+		key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "compare")),
+	}
+}
+
 func (m *Model) updateEnvs(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
@@ -14,26 +22,13 @@ func (m *Model) updateEnvs(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "enter":
 			if it, ok := m.envList.SelectedItem().(envItem); ok {
-				m.selectedEnvName = it.name
-				env, err := m.cfg.GetEnvironment(it.name)
-				if err != nil {
-					m.errMsg = err.Error()
-					return m, nil
-				}
-				m.selectedEnv = env
-				if err := m.reloadSecrets(); err != nil {
-					m.errMsg = err.Error()
-					return m, nil
-				}
-				m.screen = screenSecrets
-				m.filter.SetValue("")
-				m.filter.Blur()
-				// Ensure the secrets table is sized immediately, even if we haven't
-				// received a WindowSizeMsg on this screen yet.
-				if m.winW > 0 && m.winH > 0 {
-					return m.updateSecrets(tea.WindowSizeMsg{Width: m.winW, Height: m.winH})
-				}
-				return m, nil
+				m.compareReturn = false
+				return m.openEnv(it.name)
+			}
+		case "c":
+			// Let the list filter receive the key while typing.
+			if m.envList.FilterState() != list.Filtering {
+				return m.openCompareSelect()
 			}
 		}
 	}
@@ -42,3 +37,28 @@ func (m *Model) updateEnvs(msg tea.Msg) (tea.Model, tea.Cmd) {
 	m.envList, cmd = m.envList.Update(msg)
 	return m, cmd
 }
+
+// openEnv loads the secrets of an environment and switches to the secrets screen.
+func (m *Model) openEnv(name string) (tea.Model, tea.Cmd) {
+	m.selectedEnvName = name
+	env, err := m.cfg.GetEnvironment(name)
+	if err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	m.selectedEnv = env
+	m.selected = map[string]bool{}
+	if err := m.reloadSecrets(); err != nil {
+		m.errMsg = err.Error()
+		return m, nil
+	}
+	m.screen = screenSecrets
+	m.filter.SetValue("")
+	m.filter.Blur()
+	// Ensure the secrets table is sized immediately, even if we haven't
+	// received a WindowSizeMsg on this screen yet.
+	if m.winW > 0 && m.winH > 0 {
+		return m.updateSecrets(tea.WindowSizeMsg{Width: m.winW, Height: m.winH})
+	}
+	return m, nil
+}
@@ -0,0 +1,286 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/table"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
+)
+
+const versionsHelp = "enter:preview  r:reveal  p:promote  esc:back  q:quit"
+
+// openVersions switches to the versions screen for a secret-key row and
+// starts loading its version history.
+func (m *Model) openVersions(r secretRow) (tea.Model, tea.Cmd) {
+	m.versionsTarget = &r
+	m.versions = nil
+	m.versionsLoading = true
+	m.versionsErr = ""
+	m.versionPreviewID = ""
+	m.versionPreview = ""
+	m.versionReveal = false
+	m.versionConfirm = ""
+	m.versionsTable = table.New(
+		table.WithColumns(versionsTableColumns(0)),
+		table.WithRows(nil),
+		table.WithFocused(true),
+		table.WithStyles(secretsTableStyles()),
+	)
+	m.sizeVersionsTable()
+	m.screen = screenVersions
+	return m, tea.Batch(m.spinner.Tick, m.loadVersionsCmd(r))
+}
+
+func (m *Model) loadVersionsCmd(r secretRow) tea.Cmd {
+	return func() tea.Msg {
+		versions, err := m.listVersions(r)
+		return versionsLoadedMsg{versions: versions, err: err}
+	}
+}
+
+func (m *Model) historyFor(r secretRow) (secrets.SecretHistory, func(), error) {
+	factory := secrets.NewSecretManagerFactory()
+	sm, err := factory.CreateSecretManager(m.ctx, r.provider, r.project)
+	if err != nil {
+		return nil, nil, err
+	}
+	h, err := secrets.AsHistory(sm)
+	if err != nil {
+		sm.Close()
+		return nil, nil, err
+	}
+	return h, func() { sm.Close() }, nil
+}
+
+func (m *Model) listVersions(r secretRow) ([]secrets.SecretVersion, error) {
+	h, closeFn, err := m.historyFor(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFn()
+	return h.ListVersions(r.ref)
+}
+
+func (m *Model) accessVersion(r secretRow, versionID string) (string, error) {
+	h, closeFn, err := m.historyFor(r)
+	if err != nil {
+		return "", err
+	}
+	defer closeFn()
+	return h.AccessVersion(r.ref, versionID)
+}
+
+func (m *Model) promoteVersion(r secretRow, versionID string) error {
+	h, closeFn, err := m.historyFor(r)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	if _, err := h.PromoteVersion(r.ref, versionID); err != nil {
+		return err
+	}
+
+	// Drop the cached value so the secrets table shows the promoted version.
+	m.invalidateSecret(r.provider, r.project, r.ref)
+	return nil
+}
+
+func (m *Model) selectedVersion() (secrets.SecretVersion, bool) {
+	i := m.versionsTable.Cursor()
+	if i < 0 || i >= len(m.versions) {
+		return secrets.SecretVersion{}, false
+	}
+	return m.versions[i], true
+}
+
+func (m *Model) setVersionRows() {
+	rows := make([]table.Row, 0, len(m.versions))
+	for _, v := range m.versions {
+		created := ""
+		if !v.CreatedAt.IsZero() {
+			created = v.CreatedAt.Local().Format("2006-01-02 15:04:05")
+		}
+		current := ""
+		if v.Current {
+			current = "●"
+		}
+		rows = append(rows, table.Row{v.ID, created, v.State, current})
+	}
+	m.versionsTable.SetRows(rows)
+}
+
+func versionsTableColumns(innerW int) []table.Column {
+	createdW, stateW, currentW := 19, 18, 7
+	// Cell padding and column gaps, see setSecretTableColumns.
+	overhead := 4*2 + 3
+	idW := clampMin(innerW-overhead-createdW-stateW-currentW, 8)
+	return []table.Column{
+		{Title: "Version", Width: idW},
+		{Title: "Created", Width: createdW},
+		{Title: "State", Width: stateW},
+		{Title: "Current", Width: currentW},
+	}
+}
+
+func (m *Model) sizeVersionsTable() {
+	innerW, innerH := panelInnerSize(m.winW, m.winH, panelStyle())
+	if innerW <= 0 || innerH <= 0 {
+		return
+	}
+	// Header, preview and help blocks are separated by blank lines.
+	headerH := lipgloss.Height(sectionHeaderStyle().Render("Versions: X"))
+	helpH := lipgloss.Height(helpStyle().Render(versionsHelp))
+	previewH := 3
+	nonTableH := headerH + previewH + helpH + 3
+	m.versionsTable.SetWidth(innerW)
+	m.versionsTable.SetColumns(versionsTableColumns(innerW))
+	m.versionsTable.SetHeight(clampMin(innerH-nonTableH, 1))
+}
+
+func (m *Model) viewVersions() string {
+	title := "Versions"
+	if m.versionsTarget != nil {
+		title = fmt.Sprintf("Versions: %s (%s)", m.versionsTarget.envVar, m.versionsTarget.ref)
+	}
+	header := sectionHeaderStyle().Render(title)
+
+	var body string
+	switch {
+	case m.versionsLoading:
+		body = strings.TrimSpace(m.spinner.View() + " Loading versions…")
+	case m.versionsErr != "":
+		body = errorStyle().Render("Error: " + m.versionsErr)
+	default:
+		body = m.versionsTable.View()
+	}
+
+	preview := "Select a version and press enter to preview it."
+	if m.versionPreviewID != "" {
+		val := m.versionPreview
+		if !m.versionReveal {
+			val = mask(val)
+		}
+		preview = fmt.Sprintf("Version %s\n%s", m.versionPreviewID, val)
+	}
+
+	help := helpStyle().Render(versionsHelp)
+	if m.versionConfirm != "" {
+		help = errorStyle().Render(fmt.Sprintf("Promote version %s to current? y/n", m.versionConfirm))
+	} else if m.errMsg != "" {
+		help = errorStyle().Render("Error: "+m.errMsg) + "\n" + help
+	}
+
+	content := strings.Join([]string{header, body, preview, help}, "\n\n")
+	box := fitPanelToWindow(panelStyle(), m.winW, m.winH)
+	return box.Render(content)
+}
+
+func (m *Model) updateVersions(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.sizeVersionsTable()
+		return m, nil
+	case versionsLoadedMsg:
+		m.versionsLoading = false
+		if msg.err != nil {
+			m.versionsErr = msg.err.Error()
+			return m, nil
+		}
+		m.versions = msg.versions
+		m.setVersionRows()
+		return m, nil
+	case versionValueMsg:
+		if msg.err != nil {
+			m.errMsg = msg.err.Error()
+			return m, nil
+		}
+		m.versionPreviewID = msg.versionID
+		m.versionPreview = msg.value
+		return m, nil
+	case tea.KeyMsg:
+		if m.errMsg != "" {
+			m.errMsg = ""
+		}
+		if m.versionConfirm != "" {
+			versionID := m.versionConfirm
+			m.versionConfirm = ""
+			if msg.String() != "y" || m.versionsTarget == nil {
+				return m, nil
+			}
+			row := *m.versionsTarget
+			m.busyText = fmt.Sprintf("Promoting version %s…", versionID)
+			m.screen = screenBusy
+			return m, tea.Batch(
+				m.spinner.Tick,
+				func() tea.Msg {
+					return promoteDoneMsg{err: m.promoteVersion(row, versionID)}
+				},
+			)
+		}
+		switch msg.String() {
+		case "q", "ctrl+c":
+			return m, tea.Quit
+		case "esc":
+			m.screen = screenSecrets
+			m.versionsTarget = nil
+			m.versionPreview = ""
+			return m, nil
+		case "r":
+			m.versionReveal = !m.versionReveal
+			return m, nil
+		case "enter":
+			v, ok := m.selectedVersion()
+			if !ok || m.versionsTarget == nil {
+				return m, nil
+			}
+			if v.State == "destroyed" {
+				m.errMsg = "destroyed versions have no value"
+				return m, nil
+			}
+			row := *m.versionsTarget
+			return m, func() tea.Msg {
+				value, err := m.accessVersion(row, v.ID)
+				return versionValueMsg{versionID: v.ID, value: value, err: err}
+			}
+		case "p":
+			v, ok := m.selectedVersion()
+			if !ok {
+				return m, nil
+			}
+			if v.Current {
+				m.errMsg = fmt.Sprintf("version %s is already current", v.ID)
+				return m, nil
+			}
+			if !m.editAllowed(m.selectedEnvName) {
+				return m, nil
+			}
+			m.versionConfirm = v.ID
+			return m, nil
+		}
+	default:
+		if m.versionsLoading {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+	}
+
+	var cmd tea.Cmd
+	m.versionsTable, cmd = m.versionsTable.Update(msg)
+	return m, cmd
+}
+
+// reloadAfterPromote refreshes the secrets table and the version list after
+// a version was promoted.
+func (m *Model) reloadAfterPromote() tea.Cmd {
+	_ = m.reloadSecrets()
+	if m.versionsTarget == nil {
+		return nil
+	}
+	m.versionsLoading = true
+	return tea.Batch(m.spinner.Tick, m.loadVersionsCmd(*m.versionsTarget))
+}
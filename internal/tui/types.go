@@ -4,6 +4,7 @@ import (
 	"charm.land/bubbles/v2/list"
 
 	"github.com/mistweaverco/kuba/internal/config"
+	"github.com/mistweaverco/kuba/internal/lib/secrets"
 )
 
 type Screen int
@@ -17,6 +18,12 @@ const (
 	screenConfirmDelete
 	screenError
 	screenBusy
+	screenVersions
+	screenCompareSelect
+	screenCompare
+	screenBulkForm
+	screenBulkRun
+	screenBrowse
 )
 
 type envItem struct{ name string }
@@ -39,6 +46,28 @@ type secretRow struct {
 type createDoneMsg struct{ err error }
 type editDoneMsg struct{ err error }
 type deleteDoneMsg struct{ err error }
+type promoteDoneMsg struct{ err error }
+type versionsLoadedMsg struct {
+	versions []secrets.SecretVersion
+	err      error
+}
+type browseLoadedMsg struct {
+	names []string
+	err   error
+}
+type bulkStepMsg struct {
+	index  int
+	result bulkResult
+}
+type compareResolvedMsg struct {
+	hashes map[string]map[string]string // env -> env var -> value hash
+	err    error
+}
+type versionValueMsg struct {
+	versionID string
+	value     string
+	err       error
+}
 
 // Compile-time assertion for list.Item.
 var _ list.Item = envItem{}